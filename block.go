@@ -0,0 +1,339 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
+)
+
+var (
+	// frontierBlockReward is the reward of the miner of a block in wei
+	frontierBlockReward = big.NewInt(5e18)
+
+	// byzantiumBlockReward is the reward since byzantium (eip-649)
+	byzantiumBlockReward = big.NewInt(3e18)
+
+	// constantinopleBlockReward is the reward since constantinople (eip-1234)
+	constantinopleBlockReward = big.NewInt(2e18)
+)
+
+var (
+	// BeaconRootsAddress is the contract that stores the roots of the beacon
+	// blocks (eip-4788)
+	BeaconRootsAddress = evmc.Address{
+		0x00, 0x0f, 0x3d, 0xf6, 0xd7, 0x32, 0x80, 0x7e, 0xf1, 0x31,
+		0x9f, 0xb7, 0xb8, 0xbb, 0x85, 0x22, 0xd0, 0xbe, 0xac, 0x02,
+	}
+)
+
+const (
+	// cancunBlobBaseFeeUpdateFraction and pragueBlobBaseFeeUpdateFraction
+	// are the update fractions of the blob base fee (eip-4844, eip-7691)
+	cancunBlobBaseFeeUpdateFraction = 3338477
+	pragueBlobBaseFeeUpdateFraction = 5007716
+
+	// minBlobBaseFee is the minimum blob base fee (eip-4844)
+	minBlobBaseFee = 1
+
+	// maxUncleDepth is the maximum distance between a block and its uncles
+	maxUncleDepth = 6
+)
+
+// MaxBlobGasPerBlock returns the maximum blob gas of a block in the
+// revision (eip-4844, eip-7691)
+func MaxBlobGasPerBlock(rev evmc.Revision) uint64 {
+	if rev >= evm.Prague {
+		return 9 * GasPerBlob
+	}
+	return 6 * GasPerBlob
+}
+
+// TargetBlobGasPerBlock returns the target blob gas of a block in the
+// revision (eip-4844, eip-7691)
+func TargetBlobGasPerBlock(rev evmc.Revision) uint64 {
+	if rev >= evm.Prague {
+		return 6 * GasPerBlob
+	}
+	return 3 * GasPerBlob
+}
+
+// CalcExcessBlobGas returns the excess blob gas of a block from the excess
+// blob gas and the blob gas used by its parent (eip-4844)
+func CalcExcessBlobGas(rev evmc.Revision, parentExcessBlobGas, parentBlobGasUsed uint64) uint64 {
+	target := TargetBlobGasPerBlock(rev)
+	if parentExcessBlobGas+parentBlobGasUsed < target {
+		return 0
+	}
+	return parentExcessBlobGas + parentBlobGasUsed - target
+}
+
+// CalcBlobBaseFee returns the blob base fee from the excess blob gas of
+// the block (eip-4844)
+func CalcBlobBaseFee(rev evmc.Revision, excessBlobGas uint64) *big.Int {
+	fraction := int64(cancunBlobBaseFeeUpdateFraction)
+	if rev >= evm.Prague {
+		fraction = pragueBlobBaseFeeUpdateFraction
+	}
+	return fakeExponential(big.NewInt(minBlobBaseFee), new(big.Int).SetUint64(excessBlobGas), big.NewInt(fraction))
+}
+
+// fakeExponential approximates factor * e ** (numerator / denominator)
+// with the taylor expansion (eip-4844)
+func fakeExponential(factor, numerator, denominator *big.Int) *big.Int {
+	output := new(big.Int)
+	accum := new(big.Int).Mul(factor, denominator)
+	for i := int64(1); accum.Sign() > 0; i++ {
+		output.Add(output, accum)
+
+		accum.Mul(accum, numerator)
+		accum.Div(accum, denominator)
+		accum.Div(accum, big.NewInt(i))
+	}
+	return output.Div(output, denominator)
+}
+
+// Header is the header of the block processed by the BlockProcessor
+type Header struct {
+	ParentHash evmc.Hash
+	Coinbase   evmc.Address
+	Number     uint64
+	Timestamp  uint64
+	GasLimit   uint64
+	Difficulty *big.Int
+
+	// MixDigest is the randao of the block after the merge (eip-4399)
+	MixDigest evmc.Hash
+
+	// BaseFee is the base fee of the block since london (eip-1559)
+	BaseFee *big.Int
+
+	// ExcessBlobGas is the excess blob gas of the block since cancun (eip-4844)
+	ExcessBlobGas *uint64
+
+	// ParentBeaconRoot is the root of the parent beacon block since
+	// cancun (eip-4788)
+	ParentBeaconRoot *evmc.Hash
+}
+
+// Uncle is the header of an ommer of the block, only the miner and the
+// number are used for the rewards
+type Uncle struct {
+	Coinbase evmc.Address
+	Number   uint64
+}
+
+// Withdrawal is a withdrawal from the beacon chain (eip-4895)
+type Withdrawal struct {
+	Index          uint64
+	ValidatorIndex uint64
+	Address        evmc.Address
+
+	// Amount is the amount of the withdrawal in gwei
+	Amount uint64
+}
+
+// Block is the block processed by the BlockProcessor
+type Block struct {
+	Header       *Header
+	Transactions []*Message
+	Uncles       []*Uncle
+	Withdrawals  []*Withdrawal
+}
+
+// RejectedTx is a transaction of the block that could not be applied
+type RejectedTx struct {
+	Index int
+	Err   error
+}
+
+// BlockResult is the result of processing a block
+type BlockResult struct {
+	// Receipts are the receipts of the transactions applied
+	Receipts []*Receipt
+
+	// Rejected are the invalid transactions skipped by the processor
+	Rejected []*RejectedTx
+
+	GasUsed     uint64
+	BlobGasUsed uint64
+	Bloom       Bloom
+
+	// Objects are the accounts modified by the block
+	Objects []*Object
+}
+
+// BlockProcessor applies the blocks of a chain on top of a state
+type BlockProcessor struct {
+	chainConfig *ChainConfig
+	opts        []ConfigOption
+
+	// SkipInvalid skips the invalid transactions instead of failing the
+	// block, it is used to build blocks
+	SkipInvalid bool
+
+	// StateRoot returns the state root with the objects modified since the
+	// start of the block. It sets the post state of the receipts before
+	// byzantium, which are encoded with the status when it is not set.
+	StateRoot func(objs []*Object) evmc.Hash
+
+	// BlockReward returns the reward of the miner of a block before the
+	// merge, it defaults to the reward of the revision. A nil reward
+	// disables the rewards of the miner and of the uncles.
+	BlockReward func(rev evmc.Revision) *big.Int
+}
+
+// NewBlockProcessor creates a processor for the chain. The options are used
+// to create the transition of each block, the revision and the context are
+// the ones of the block.
+func NewBlockProcessor(chainConfig *ChainConfig, opts ...ConfigOption) *BlockProcessor {
+	return &BlockProcessor{
+		chainConfig: chainConfig,
+		opts:        opts,
+	}
+}
+
+// txContext returns the context of the transactions of the block
+func (p *BlockProcessor) txContext(header *Header, rev evmc.Revision) TxContext {
+	ctx := TxContext{
+		Coinbase:  header.Coinbase,
+		Number:    int64(header.Number),
+		Timestamp: int64(header.Timestamp),
+		GasLimit:  int64(header.GasLimit),
+	}
+	if p.chainConfig.IsParis(header.Number) {
+		// after the merge the difficulty opcode returns the randao (eip-4399)
+		ctx.Difficulty = header.MixDigest
+	} else if header.Difficulty != nil {
+		header.Difficulty.FillBytes(ctx.Difficulty[:])
+	}
+	if header.BaseFee != nil {
+		header.BaseFee.FillBytes(ctx.BaseFee[:])
+	}
+	if header.ExcessBlobGas != nil {
+		CalcBlobBaseFee(rev, *header.ExcessBlobGas).FillBytes(ctx.BlobBaseFee[:])
+	}
+	return ctx
+}
+
+// Process applies the transactions, the rewards and the withdrawals of the
+// block. An invalid transaction fails the block unless SkipInvalid is set.
+// The blocks from Prague on are not supported, their system calls and
+// requests (eip-2935, eip-7002, eip-7251, eip-7685) are not implemented.
+func (p *BlockProcessor) Process(block *Block) (*BlockResult, error) {
+	header := block.Header
+	rev := p.chainConfig.Revision(header.Number, header.Timestamp)
+	if rev >= evm.Prague {
+		return nil, fmt.Errorf("unsupported fork prague")
+	}
+
+	opts := append([]ConfigOption{}, p.opts...)
+	opts = append(opts, WithChainConfig(p.chainConfig), WithContext(p.txContext(header, rev)))
+	transition := NewTransition(opts...)
+
+	// store the root of the beacon block
+	if rev >= evm.Cancun && header.ParentBeaconRoot != nil {
+		if _, err := transition.SystemCall(BeaconRootsAddress, header.ParentBeaconRoot[:]); err != nil {
+			return nil, fmt.Errorf("failed to store the beacon root: %v", err)
+		}
+	}
+
+	result := &BlockResult{}
+
+	gasPool := header.GasLimit
+	blobGasPool := MaxBlobGasPerBlock(rev)
+
+	for i, msg := range block.Transactions {
+		blobGas := uint64(len(msg.BlobHashes)) * GasPerBlob
+
+		var err error
+		if msg.Gas > gasPool {
+			err = fmt.Errorf("gas limit reached")
+		} else if blobGas > blobGasPool {
+			err = fmt.Errorf("blob gas limit reached")
+		} else {
+			var output *Output
+			if output, err = transition.Write(msg); err == nil {
+				if rev < evmc.Byzantium && p.StateRoot != nil {
+					root := p.StateRoot(transition.Commit())
+					output.Receipt.PostState = root[:]
+				}
+				gasPool -= output.Receipt.GasUsed
+				blobGasPool -= blobGas
+				result.BlobGasUsed += blobGas
+				result.Bloom.Or(&output.Receipt.Bloom)
+			}
+		}
+		if err != nil {
+			if !p.SkipInvalid {
+				return nil, fmt.Errorf("invalid transaction %d: %v", i, err)
+			}
+			result.Rejected = append(result.Rejected, &RejectedTx{Index: i, Err: err})
+		}
+	}
+
+	if !p.chainConfig.IsParis(header.Number) {
+		for _, uncle := range block.Uncles {
+			// an uncle is one of the last maxUncleDepth ancestors of the block
+			if uncle.Number >= header.Number || header.Number-uncle.Number > maxUncleDepth {
+				return nil, fmt.Errorf("invalid uncle number %d", uncle.Number)
+			}
+		}
+		p.applyRewards(transition.Txn(), rev, header, block.Uncles)
+	} else if len(block.Uncles) != 0 {
+		return nil, fmt.Errorf("uncles after the merge")
+	}
+
+	if len(block.Withdrawals) != 0 && rev < evmc.Shanghai {
+		return nil, fmt.Errorf("withdrawals before shanghai")
+	}
+	for _, w := range block.Withdrawals {
+		amount := new(big.Int).Mul(new(big.Int).SetUint64(w.Amount), big.NewInt(1e9))
+		transition.Txn().AddBalance(w.Address, amount)
+	}
+
+	// the rewarded accounts that are empty are removed (eip-158)
+	transition.Txn().CleanDeleteObjects(rev >= evmc.SpuriousDragon)
+
+	result.Receipts = transition.Receipts()
+	result.GasUsed = transition.GasUsed()
+	result.Objects = transition.Commit()
+	return result, nil
+}
+
+// applyRewards pays the miner of the block and of the uncles before the merge
+func (p *BlockProcessor) applyRewards(txn *Txn, rev evmc.Revision, header *Header, uncles []*Uncle) {
+	reward := p.blockReward(rev)
+	if reward == nil {
+		return
+	}
+
+	minerReward := new(big.Int).Set(reward)
+	for _, uncle := range uncles {
+		// the uncle gets (8 - depth) / 8 of the reward
+		uncleReward := new(big.Int).SetUint64(8 - (header.Number - uncle.Number))
+		uncleReward.Mul(uncleReward, reward)
+		uncleReward.Div(uncleReward, big.NewInt(8))
+		txn.AddSealingReward(uncle.Coinbase, uncleReward)
+
+		// and the miner 1/32 of the reward for each uncle
+		minerReward.Add(minerReward, new(big.Int).Div(reward, big.NewInt(32)))
+	}
+	txn.AddSealingReward(header.Coinbase, minerReward)
+}
+
+// blockReward returns the reward of the miner of a block in the revision
+func (p *BlockProcessor) blockReward(rev evmc.Revision) *big.Int {
+	if p.BlockReward != nil {
+		return p.BlockReward(rev)
+	}
+	switch {
+	case rev >= evmc.Constantinople:
+		return constantinopleBlockReward
+	case rev >= evmc.Byzantium:
+		return byzantiumBlockReward
+	default:
+		return frontierBlockReward
+	}
+}
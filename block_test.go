@@ -0,0 +1,246 @@
+package state
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/go-evm/evm"
+)
+
+func newTestTransfer(nonce uint64, gas uint64) *Message {
+	to := testContract
+	return &Message{
+		From:     testSender,
+		To:       &to,
+		Nonce:    nonce,
+		Gas:      gas,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(1),
+	}
+}
+
+func findObject(objs []*Object, addr evmc.Address) *Object {
+	for _, obj := range objs {
+		if obj.Address == addr {
+			return obj
+		}
+	}
+	return nil
+}
+
+func TestBlockProcessor_Rewards(t *testing.T) {
+	chainConfig := &ChainConfig{
+		ChainID:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+		EIP150Block:    big.NewInt(0),
+		EIP155Block:    big.NewInt(0),
+		EIP158Block:    big.NewInt(0),
+		ByzantiumBlock: big.NewInt(0),
+	}
+
+	pre := newTestState()
+	pre.accounts[testSender] = &Account{Balance: big.NewInt(1000000000)}
+
+	miner, uncleMiner := evmc.Address{0x10}, evmc.Address{0x11}
+	block := &Block{
+		Header: &Header{
+			Coinbase:   miner,
+			Number:     10,
+			GasLimit:   50000,
+			Difficulty: big.NewInt(1),
+		},
+		Transactions: []*Message{
+			newTestTransfer(0, 30000),
+			newTestTransfer(1, 30000),
+		},
+		Uncles: []*Uncle{
+			{Coinbase: uncleMiner, Number: 9},
+		},
+	}
+
+	// the second transaction does not fit in the gas left of the block
+	p := NewBlockProcessor(chainConfig, WithState(pre))
+	_, err := p.Process(block)
+	assert.EqualError(t, err, "invalid transaction 1: gas limit reached")
+
+	p.SkipInvalid = true
+	result, err := p.Process(block)
+	require.NoError(t, err)
+
+	require.Len(t, result.Rejected, 1)
+	assert.Equal(t, 1, result.Rejected[0].Index)
+	require.Len(t, result.Receipts, 1)
+	assert.Equal(t, uint64(21000), result.GasUsed)
+
+	// 3 eth and 1/32 for the uncle plus the fees of the transaction
+	minerReward, _ := new(big.Int).SetString("3093750000000021000", 10)
+	assert.Equal(t, minerReward, findObject(result.Objects, miner).Balance)
+
+	// 7/8 of 3 eth
+	uncleReward, _ := new(big.Int).SetString("2625000000000000000", 10)
+	assert.Equal(t, uncleReward, findObject(result.Objects, uncleMiner).Balance)
+
+	// the receipts have the post state only before byzantium
+	assert.Nil(t, result.Receipts[0].PostState)
+
+	// the rewards are disabled with a nil block reward
+	p.BlockReward = func(evmc.Revision) *big.Int { return nil }
+	result, err = p.Process(block)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(21000), findObject(result.Objects, miner).Balance)
+	assert.Nil(t, findObject(result.Objects, uncleMiner))
+
+	// the uncles are within the six ancestors of the block
+	for _, number := range []uint64{3, 10, 11} {
+		block.Uncles[0].Number = number
+		_, err = p.Process(block)
+		assert.EqualError(t, err, fmt.Sprintf("invalid uncle number %d", number))
+	}
+}
+
+func TestBlockProcessor_PostState(t *testing.T) {
+	chainConfig := &ChainConfig{
+		ChainID:        big.NewInt(1),
+		HomesteadBlock: big.NewInt(0),
+	}
+
+	pre := newTestState()
+	pre.accounts[testSender] = &Account{Balance: big.NewInt(1000000000)}
+
+	block := &Block{
+		Header: &Header{
+			Number:     1,
+			GasLimit:   50000,
+			Difficulty: big.NewInt(1),
+		},
+		Transactions: []*Message{
+			newTestTransfer(0, 21000),
+			newTestTransfer(1, 21000),
+		},
+	}
+
+	// the root is computed with the objects modified up to each transaction
+	p := NewBlockProcessor(chainConfig, WithState(pre))
+	p.StateRoot = func(objs []*Object) evmc.Hash {
+		return evmc.Hash{byte(findObject(objs, testSender).Nonce)}
+	}
+	result, err := p.Process(block)
+	require.NoError(t, err)
+
+	require.Len(t, result.Receipts, 2)
+	assert.Equal(t, []byte{0x1}, result.Receipts[0].PostState[:1])
+	assert.Equal(t, []byte{0x2}, result.Receipts[1].PostState[:1])
+}
+
+func TestBlockProcessor_Cancun(t *testing.T) {
+	chainConfig := &ChainConfig{
+		ChainID:             big.NewInt(1),
+		HomesteadBlock:      big.NewInt(0),
+		EIP150Block:         big.NewInt(0),
+		EIP155Block:         big.NewInt(0),
+		EIP158Block:         big.NewInt(0),
+		ByzantiumBlock:      big.NewInt(0),
+		ConstantinopleBlock: big.NewInt(0),
+		PetersburgBlock:     big.NewInt(0),
+		IstanbulBlock:       big.NewInt(0),
+		BerlinBlock:         big.NewInt(0),
+		LondonBlock:         big.NewInt(0),
+		ParisBlock:          big.NewInt(0),
+		ShanghaiTime:        newUint64(0),
+		CancunTime:          newUint64(0),
+	}
+
+	// SSTORE(TIMESTAMP, CALLDATALOAD(0))
+	beaconRoots := []byte{0x60, 0x00, 0x35, 0x42, 0x55, 0x00}
+
+	pre := newTestState()
+	pre.accounts[testSender] = &Account{Balance: big.NewInt(1000000000)}
+	pre.accounts[BeaconRootsAddress] = &Account{
+		Balance:  big.NewInt(0),
+		Nonce:    1,
+		CodeHash: ethgo.Keccak256(beaconRoots),
+		Code:     beaconRoots,
+	}
+
+	miner := evmc.Address{0x10}
+	beaconRoot := evmc.Hash{0x1}
+	excessBlobGas := uint64(0)
+
+	block := &Block{
+		Header: &Header{
+			Coinbase:         miner,
+			Number:           1,
+			Timestamp:        12,
+			GasLimit:         50000,
+			BaseFee:          big.NewInt(0),
+			ExcessBlobGas:    &excessBlobGas,
+			ParentBeaconRoot: &beaconRoot,
+		},
+		Transactions: []*Message{
+			newTestTransfer(0, 21000),
+		},
+		Uncles: []*Uncle{},
+		Withdrawals: []*Withdrawal{
+			{Index: 0, Address: evmc.Address{0x20}, Amount: 2},
+			{Index: 1, Address: evmc.Address{0x21}, Amount: 0},
+		},
+	}
+
+	result, err := NewBlockProcessor(chainConfig, WithState(pre)).Process(block)
+	require.NoError(t, err)
+	assert.Empty(t, result.Rejected)
+	assert.Equal(t, uint64(21000), result.GasUsed)
+
+	// no rewards after the merge, only the tip of the transaction
+	assert.Equal(t, big.NewInt(21000), findObject(result.Objects, miner).Balance)
+
+	// the system call stores the beacon root and the caller is not created
+	obj := findObject(result.Objects, BeaconRootsAddress)
+	require.NotNil(t, obj)
+	require.Len(t, obj.Storage, 1)
+	assert.Equal(t, beaconRoot[:], obj.Storage[0].Val)
+	if obj := findObject(result.Objects, SystemAddress); obj != nil {
+		assert.True(t, obj.Deleted)
+	}
+
+	// the amount of the withdrawals is in gwei and the empty accounts are removed
+	assert.Equal(t, big.NewInt(2e9), findObject(result.Objects, evmc.Address{0x20}).Balance)
+	if obj := findObject(result.Objects, evmc.Address{0x21}); obj != nil {
+		assert.True(t, obj.Deleted)
+	}
+
+	// withdrawals are not allowed before shanghai
+	chainConfig.ShanghaiTime, chainConfig.CancunTime = newUint64(100), newUint64(100)
+	_, err = NewBlockProcessor(chainConfig, WithState(pre)).Process(block)
+	assert.EqualError(t, err, "withdrawals before shanghai")
+
+	// the blocks of prague are not supported
+	chainConfig.ShanghaiTime, chainConfig.CancunTime, chainConfig.PragueTime = newUint64(0), newUint64(0), newUint64(0)
+	_, err = NewBlockProcessor(chainConfig, WithState(pre)).Process(block)
+	assert.EqualError(t, err, "unsupported fork prague")
+}
+
+func TestCalcBlobBaseFee(t *testing.T) {
+	assert.Equal(t, big.NewInt(1), CalcBlobBaseFee(evm.Cancun, 0))
+	assert.Equal(t, big.NewInt(1), CalcBlobBaseFee(evm.Cancun, 2314057))
+	assert.Equal(t, big.NewInt(2), CalcBlobBaseFee(evm.Cancun, 2314058))
+	assert.Equal(t, big.NewInt(23), CalcBlobBaseFee(evm.Cancun, 10*1024*1024))
+
+	// the update fraction is higher after prague (eip-7691)
+	assert.Equal(t, big.NewInt(1), CalcBlobBaseFee(evm.Prague, 2314058))
+}
+
+func TestCalcExcessBlobGas(t *testing.T) {
+	assert.Equal(t, uint64(0), CalcExcessBlobGas(evm.Cancun, 0, 2*GasPerBlob))
+	assert.Equal(t, GasPerBlob, CalcExcessBlobGas(evm.Cancun, 0, 4*GasPerBlob))
+	assert.Equal(t, 4*GasPerBlob, CalcExcessBlobGas(evm.Cancun, GasPerBlob, 6*GasPerBlob))
+
+	// the target is six blobs since prague
+	assert.Equal(t, uint64(0), CalcExcessBlobGas(evm.Prague, 0, 6*GasPerBlob))
+	assert.Equal(t, 3*GasPerBlob, CalcExcessBlobGas(evm.Prague, 0, 9*GasPerBlob))
+}
@@ -0,0 +1,219 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
+)
+
+// ChainConfig is the schedule of the forks of a chain. The forks up to
+// London are activated by the block number, Paris by the block number or
+// the terminal total difficulty and the forks from Shanghai on by the
+// timestamp of the block. A nil activation means that the fork is not
+// scheduled. The json encoding is the one of the config of a geth genesis.
+type ChainConfig struct {
+	ChainID *big.Int `json:"chainId"`
+
+	HomesteadBlock      *big.Int `json:"homesteadBlock,omitempty"`
+	EIP150Block         *big.Int `json:"eip150Block,omitempty"`
+	EIP155Block         *big.Int `json:"eip155Block,omitempty"`
+	EIP158Block         *big.Int `json:"eip158Block,omitempty"`
+	ByzantiumBlock      *big.Int `json:"byzantiumBlock,omitempty"`
+	ConstantinopleBlock *big.Int `json:"constantinopleBlock,omitempty"`
+	PetersburgBlock     *big.Int `json:"petersburgBlock,omitempty"`
+	IstanbulBlock       *big.Int `json:"istanbulBlock,omitempty"`
+	BerlinBlock         *big.Int `json:"berlinBlock,omitempty"`
+	LondonBlock         *big.Int `json:"londonBlock,omitempty"`
+
+	// ParisBlock is the first block after the merge. It is not part of the
+	// config of geth, which activates the merge with the total difficulty.
+	ParisBlock *big.Int `json:"parisBlock,omitempty"`
+
+	// TerminalTotalDifficulty is the total difficulty that triggers the
+	// merge. Without a ParisBlock, a zero value or a difficulty marked as
+	// passed means that the chain is merged from the genesis.
+	TerminalTotalDifficulty       *big.Int `json:"terminalTotalDifficulty,omitempty"`
+	TerminalTotalDifficultyPassed bool     `json:"terminalTotalDifficultyPassed,omitempty"`
+
+	// MergeNetsplitBlock is the block used to split the network after the
+	// merge, it does not change the rules of the chain
+	MergeNetsplitBlock *big.Int `json:"mergeNetsplitBlock,omitempty"`
+
+	ShanghaiTime *uint64 `json:"shanghaiTime,omitempty"`
+	CancunTime   *uint64 `json:"cancunTime,omitempty"`
+	PragueTime   *uint64 `json:"pragueTime,omitempty"`
+}
+
+func newUint64(i uint64) *uint64 {
+	return &i
+}
+
+func newBigInt(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(fmt.Sprintf("invalid number %s", s))
+	}
+	return i
+}
+
+// The presets stop at Cancun, the execution of Prague is not implemented
+var (
+	// MainnetChainConfig is the fork schedule of the mainnet
+	MainnetChainConfig = &ChainConfig{
+		ChainID:                 big.NewInt(1),
+		HomesteadBlock:          big.NewInt(1_150_000),
+		EIP150Block:             big.NewInt(2_463_000),
+		EIP155Block:             big.NewInt(2_675_000),
+		EIP158Block:             big.NewInt(2_675_000),
+		ByzantiumBlock:          big.NewInt(4_370_000),
+		ConstantinopleBlock:     big.NewInt(7_280_000),
+		PetersburgBlock:         big.NewInt(7_280_000),
+		IstanbulBlock:           big.NewInt(9_069_000),
+		BerlinBlock:             big.NewInt(12_244_000),
+		LondonBlock:             big.NewInt(12_965_000),
+		ParisBlock:              big.NewInt(15_537_394),
+		TerminalTotalDifficulty: newBigInt("58750000000000000000000"),
+		ShanghaiTime:            newUint64(1681338455),
+		CancunTime:              newUint64(1710338135),
+	}
+
+	// SepoliaChainConfig is the fork schedule of the Sepolia testnet
+	SepoliaChainConfig = &ChainConfig{
+		ChainID:                 big.NewInt(11155111),
+		HomesteadBlock:          big.NewInt(0),
+		EIP150Block:             big.NewInt(0),
+		EIP155Block:             big.NewInt(0),
+		EIP158Block:             big.NewInt(0),
+		ByzantiumBlock:          big.NewInt(0),
+		ConstantinopleBlock:     big.NewInt(0),
+		PetersburgBlock:         big.NewInt(0),
+		IstanbulBlock:           big.NewInt(0),
+		BerlinBlock:             big.NewInt(0),
+		LondonBlock:             big.NewInt(0),
+		ParisBlock:              big.NewInt(1_450_409),
+		TerminalTotalDifficulty: newBigInt("17000000000000000"),
+		MergeNetsplitBlock:      big.NewInt(1_735_371),
+		ShanghaiTime:            newUint64(1677557088),
+		CancunTime:              newUint64(1706655072),
+	}
+
+	// HoleskyChainConfig is the fork schedule of the Holesky testnet,
+	// which started after the merge
+	HoleskyChainConfig = &ChainConfig{
+		ChainID:                 big.NewInt(17000),
+		HomesteadBlock:          big.NewInt(0),
+		EIP150Block:             big.NewInt(0),
+		EIP155Block:             big.NewInt(0),
+		EIP158Block:             big.NewInt(0),
+		ByzantiumBlock:          big.NewInt(0),
+		ConstantinopleBlock:     big.NewInt(0),
+		PetersburgBlock:         big.NewInt(0),
+		IstanbulBlock:           big.NewInt(0),
+		BerlinBlock:             big.NewInt(0),
+		LondonBlock:             big.NewInt(0),
+		ParisBlock:              big.NewInt(0),
+		TerminalTotalDifficulty: big.NewInt(0),
+		ShanghaiTime:            newUint64(1696000704),
+		CancunTime:              newUint64(1707305664),
+	}
+)
+
+func isBlockForked(fork *big.Int, number uint64) bool {
+	if fork == nil {
+		return false
+	}
+	return fork.Cmp(new(big.Int).SetUint64(number)) <= 0
+}
+
+func isTimestampForked(fork *uint64, timestamp uint64) bool {
+	if fork == nil {
+		return false
+	}
+	return *fork <= timestamp
+}
+
+// IsParis returns whether the block is after the merge. Without a ParisBlock
+// the merge only happens at the genesis, when the terminal total difficulty
+// is zero or already passed.
+func (c *ChainConfig) IsParis(number uint64) bool {
+	if c.ParisBlock != nil {
+		return isBlockForked(c.ParisBlock, number)
+	}
+	ttd := c.TerminalTotalDifficulty
+	return ttd != nil && (ttd.Sign() == 0 || c.TerminalTotalDifficultyPassed)
+}
+
+// IsEIP155 returns whether the transactions of the block can be replay
+// protected with the chain id
+func (c *ChainConfig) IsEIP155(number uint64) bool {
+	return isBlockForked(c.EIP155Block, number)
+}
+
+// Revision returns the revision of the block with the number and timestamp.
+// Paris does not have its own revision, the changes of the merge are in the
+// context of the block.
+func (c *ChainConfig) Revision(number, timestamp uint64) evmc.Revision {
+	switch {
+	case isTimestampForked(c.PragueTime, timestamp):
+		return evm.Prague
+	case isTimestampForked(c.CancunTime, timestamp):
+		return evm.Cancun
+	case isTimestampForked(c.ShanghaiTime, timestamp):
+		return evmc.Shanghai
+	case isBlockForked(c.LondonBlock, number):
+		return evmc.London
+	case isBlockForked(c.BerlinBlock, number):
+		return evmc.Berlin
+	case isBlockForked(c.IstanbulBlock, number):
+		return evmc.Istanbul
+	case isBlockForked(c.PetersburgBlock, number):
+		return evmc.Petersburg
+	case isBlockForked(c.ConstantinopleBlock, number):
+		return evmc.Constantinople
+	case isBlockForked(c.ByzantiumBlock, number):
+		return evmc.Byzantium
+	case isBlockForked(c.EIP158Block, number):
+		return evmc.SpuriousDragon
+	case isBlockForked(c.EIP150Block, number):
+		return evmc.TangerineWhistle
+	case isBlockForked(c.HomesteadBlock, number):
+		return evmc.Homestead
+	default:
+		return evmc.Frontier
+	}
+}
+
+// ParseChainConfig decodes the chain config of a geth genesis file. The data
+// can be either the whole genesis or only its config object. Since geth does
+// not store the merge block, the ParisBlock of the known networks is taken
+// from their preset when the terminal total difficulty matches.
+func ParseChainConfig(data []byte) (*ChainConfig, error) {
+	var genesis struct {
+		Config *ChainConfig `json:"config"`
+	}
+	if err := json.Unmarshal(data, &genesis); err != nil {
+		return nil, err
+	}
+
+	config := genesis.Config
+	if config == nil {
+		config = &ChainConfig{}
+		if err := json.Unmarshal(data, config); err != nil {
+			return nil, err
+		}
+	}
+	if config.ChainID == nil {
+		return nil, fmt.Errorf("chain id not set")
+	}
+	if config.ParisBlock == nil && config.TerminalTotalDifficulty != nil {
+		for _, preset := range []*ChainConfig{MainnetChainConfig, SepoliaChainConfig, HoleskyChainConfig} {
+			if preset.ChainID.Cmp(config.ChainID) == 0 && preset.TerminalTotalDifficulty.Cmp(config.TerminalTotalDifficulty) == 0 {
+				config.ParisBlock = new(big.Int).Set(preset.ParisBlock)
+			}
+		}
+	}
+	return config, nil
+}
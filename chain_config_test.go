@@ -0,0 +1,171 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/go-evm/evm"
+)
+
+func TestChainConfig_Revision(t *testing.T) {
+	cases := []struct {
+		number    uint64
+		timestamp uint64
+		rev       evmc.Revision
+	}{
+		{0, 0, evmc.Frontier},
+		{1_149_999, 0, evmc.Frontier},
+		{1_150_000, 0, evmc.Homestead},
+		{2_463_000, 0, evmc.TangerineWhistle},
+		{2_675_000, 0, evmc.SpuriousDragon},
+		{4_370_000, 0, evmc.Byzantium},
+		{7_280_000, 0, evmc.Petersburg},
+		{9_069_000, 0, evmc.Istanbul},
+		{12_244_000, 0, evmc.Berlin},
+		{12_965_000, 0, evmc.London},
+		{15_537_394, 1663224162, evmc.London},
+		{17_034_870, 1681338455, evmc.Shanghai},
+		{19_426_587, 1710338135, evm.Cancun},
+		{22_431_084, 1746612311, evm.Cancun},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.rev, MainnetChainConfig.Revision(c.number, c.timestamp), "block %d", c.number)
+	}
+
+	assert.False(t, MainnetChainConfig.IsParis(15_537_393))
+	assert.True(t, MainnetChainConfig.IsParis(15_537_394))
+	assert.True(t, HoleskyChainConfig.IsParis(0))
+	assert.Equal(t, evmc.London, SepoliaChainConfig.Revision(0, 0))
+
+	// the merge of sepolia is before the netsplit block
+	assert.False(t, SepoliaChainConfig.IsParis(1_450_408))
+	assert.True(t, SepoliaChainConfig.IsParis(1_450_409))
+}
+
+func TestChainConfig_Parse(t *testing.T) {
+	genesis := `{
+		"config": {
+			"chainId": 1337,
+			"homesteadBlock": 0,
+			"eip150Block": 0,
+			"eip155Block": 0,
+			"eip158Block": 0,
+			"byzantiumBlock": 0,
+			"constantinopleBlock": 0,
+			"petersburgBlock": 0,
+			"istanbulBlock": 0,
+			"muirGlacierBlock": 0,
+			"berlinBlock": 0,
+			"londonBlock": 10,
+			"terminalTotalDifficulty": 0,
+			"shanghaiTime": 100,
+			"cancunTime": 200
+		},
+		"alloc": {}
+	}`
+
+	config, err := ParseChainConfig([]byte(genesis))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1337), config.ChainID)
+	assert.Nil(t, config.PragueTime)
+
+	assert.Equal(t, evmc.Berlin, config.Revision(9, 0))
+	assert.Equal(t, evmc.London, config.Revision(10, 99))
+	assert.Equal(t, evmc.Shanghai, config.Revision(11, 100))
+	assert.Equal(t, evm.Cancun, config.Revision(12, 300))
+
+	// a zero terminal total difficulty is merged from the genesis
+	assert.True(t, config.IsParis(0))
+
+	// only the config object
+	config, err = ParseChainConfig([]byte(`{"chainId": 5, "homesteadBlock": 3}`))
+	assert.NoError(t, err)
+	assert.Equal(t, evmc.Homestead, config.Revision(3, 0))
+
+	_, err = ParseChainConfig([]byte(`{"homesteadBlock": 3}`))
+	assert.Error(t, err)
+
+	// the terminal total difficulty is not reached
+	config, err = ParseChainConfig([]byte(`{"chainId": 5, "terminalTotalDifficulty": 100}`))
+	assert.NoError(t, err)
+	assert.False(t, config.IsParis(1000))
+
+	config, err = ParseChainConfig([]byte(`{"chainId": 5, "terminalTotalDifficulty": 100, "terminalTotalDifficultyPassed": true}`))
+	assert.NoError(t, err)
+	assert.True(t, config.IsParis(0))
+}
+
+func TestChainConfig_ParseMainnet(t *testing.T) {
+	// config of the mainnet genesis of geth
+	genesis := `{
+		"chainId": 1,
+		"homesteadBlock": 1150000,
+		"daoForkBlock": 1920000,
+		"daoForkSupport": true,
+		"eip150Block": 2463000,
+		"eip155Block": 2675000,
+		"eip158Block": 2675000,
+		"byzantiumBlock": 4370000,
+		"constantinopleBlock": 7280000,
+		"petersburgBlock": 7280000,
+		"istanbulBlock": 9069000,
+		"muirGlacierBlock": 9200000,
+		"berlinBlock": 12244000,
+		"londonBlock": 12965000,
+		"arrowGlacierBlock": 13773000,
+		"grayGlacierBlock": 15050000,
+		"terminalTotalDifficulty": 58750000000000000000000,
+		"shanghaiTime": 1681338455,
+		"cancunTime": 1710338135,
+		"pragueTime": 1746612311,
+		"depositContractAddress": "0x00000000219ab540356cbb839cbe05303d7705fa",
+		"ethash": {},
+		"blobSchedule": {
+			"cancun": {
+				"target": 3,
+				"max": 6,
+				"baseFeeUpdateFraction": 3338477
+			},
+			"prague": {
+				"target": 6,
+				"max": 9,
+				"baseFeeUpdateFraction": 5007716
+			}
+		}
+	}`
+
+	config, err := ParseChainConfig([]byte(genesis))
+	assert.NoError(t, err)
+
+	assert.False(t, config.IsParis(15_537_393))
+	assert.True(t, config.IsParis(15_537_394))
+	assert.Equal(t, evm.Prague, config.Revision(22_431_084, 1746612311))
+
+	// the preset does not schedule prague
+	config.PragueTime = nil
+	assert.Equal(t, MainnetChainConfig, config)
+}
+
+func TestTransition_ChainConfig(t *testing.T) {
+	ctx := TxContext{
+		Number:    12_965_000,
+		Timestamp: 1628166822,
+	}
+	transition := NewTransition(WithChainConfig(MainnetChainConfig), WithContext(ctx))
+	assert.Equal(t, evmc.London, transition.config.Rev)
+	assert.Equal(t, int64(1), transition.config.Ctx.ChainID)
+
+	// the chain config takes precedence over the revision
+	ctx.Timestamp = 1710338135
+	transition = NewTransition(WithRevision(evmc.Berlin), WithContext(ctx), WithChainConfig(MainnetChainConfig))
+	assert.Equal(t, evm.Cancun, transition.config.Rev)
+	assert.Equal(t, evm.Cancun, transition.txn.rev)
+
+	// a config without chain id does not override the one of the context
+	ctx.ChainID = 5
+	transition = NewTransition(WithContext(ctx), WithChainConfig(&ChainConfig{}))
+	assert.Equal(t, evmc.Frontier, transition.config.Rev)
+	assert.Equal(t, int64(5), transition.config.Ctx.ChainID)
+}
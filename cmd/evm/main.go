@@ -0,0 +1,51 @@
+// Command evm runs raw bytecode and state transitions with the go-evm interpreter
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: evm <command> [flags]
+
+Commands:
+  run        Runs bytecode in an in-memory state
+  statetest  Runs the GeneralStateTests json files
+  t8n        Applies the transactions of a block on a state (execution-spec-tests format)
+
+Run 'evm <command> -h' for the flags of a command.
+`
+
+func main() {
+	os.Exit(runMain(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func runMain(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 1
+	}
+
+	var err error
+	switch args[0] {
+	case "run":
+		err = runCommand(args[1:], stdout, stderr)
+	case "statetest":
+		err = statetestCommand(args[1:], stdout, stderr)
+	case "t8n":
+		err = t8nCommand(args[1:], os.Stdin, stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 1
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
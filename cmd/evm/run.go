@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/evm"
+	"github.com/umbracle/go-evm/tracer"
+)
+
+var forks = map[string]evmc.Revision{
+	"Frontier":         evmc.Frontier,
+	"Homestead":        evmc.Homestead,
+	"TangerineWhistle": evmc.TangerineWhistle,
+	"SpuriousDragon":   evmc.SpuriousDragon,
+	"Byzantium":        evmc.Byzantium,
+	"Constantinople":   evmc.Constantinople,
+	"Petersburg":       evmc.Petersburg,
+	"Istanbul":         evmc.Istanbul,
+	"Berlin":           evmc.Berlin,
+	"London":           evmc.London,
+	"Shanghai":         evmc.Shanghai,
+	"Cancun":           evm.Cancun,
+	"Prague":           evm.Prague,
+}
+
+func forkNames() string {
+	names := make([]string, 0, len(forks))
+	for name := range forks {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return forks[names[i]] < forks[names[j]]
+	})
+	return strings.Join(names, ", ")
+}
+
+type runConfig struct {
+	code     string
+	codeFile string
+	input    string
+	value    string
+	gas      uint64
+	sender   string
+	receiver string
+	fork     string
+	create   bool
+	trace    bool
+	json     bool
+}
+
+func runCommand(args []string, stdout, stderr io.Writer) error {
+	var cfg runConfig
+
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: evm run [flags] [code]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&cfg.code, "code", "", "hex encoded code to run, it can also be the first argument")
+	flags.StringVar(&cfg.codeFile, "codefile", "", "file with the hex encoded code to run, '-' reads from stdin")
+	flags.StringVar(&cfg.input, "input", "", "hex encoded input of the call")
+	flags.StringVar(&cfg.value, "value", "0", "value in wei sent with the call")
+	flags.Uint64Var(&cfg.gas, "gas", 10000000, "gas limit of the transaction")
+	flags.StringVar(&cfg.sender, "sender", "0x0000000000000000000000000000000073656e64", "address of the sender")
+	flags.StringVar(&cfg.receiver, "receiver", "0x0000000000000000000000000000000000007265", "address that holds the code")
+	flags.StringVar(&cfg.fork, "fork", "Cancun", "name of the fork, one of "+forkNames())
+	flags.BoolVar(&cfg.create, "create", false, "run the code as the init code of a contract creation")
+	flags.BoolVar(&cfg.trace, "trace", false, "print the trace of every opcode to stderr")
+	flags.BoolVar(&cfg.json, "json", false, "print the result and the trace as json")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() > 1 {
+		return fmt.Errorf("too many arguments")
+	}
+	if flags.NArg() == 1 {
+		if cfg.code != "" {
+			return fmt.Errorf("the code is set both as flag and argument")
+		}
+		cfg.code = flags.Arg(0)
+	}
+
+	return run(&cfg, stdout, stderr)
+}
+
+func run(cfg *runConfig, stdout, stderr io.Writer) error {
+	code, err := readCode(cfg)
+	if err != nil {
+		return err
+	}
+	input, err := decodeHex(cfg.input)
+	if err != nil {
+		return fmt.Errorf("failed to decode input: %v", err)
+	}
+	value, ok := new(big.Int).SetString(cfg.value, 0)
+	if !ok || value.Sign() < 0 {
+		return fmt.Errorf("invalid value %q", cfg.value)
+	}
+	sender, err := decodeAddress(cfg.sender)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %v", err)
+	}
+	receiver, err := decodeAddress(cfg.receiver)
+	if err != nil {
+		return fmt.Errorf("invalid receiver: %v", err)
+	}
+	rev, ok := forks[cfg.fork]
+	if !ok {
+		return fmt.Errorf("unknown fork %q, expected one of %s", cfg.fork, forkNames())
+	}
+
+	opts := []state.ConfigOption{
+		state.WithRevision(rev),
+	}
+
+	var structLogger *tracer.StructLogger
+	if cfg.trace {
+		if cfg.json {
+			opts = append(opts, state.WithTracer(tracer.NewJSONLogger(nil, stderr)))
+		} else {
+			structLogger = tracer.NewStructLogger(&tracer.Config{DisableMemory: true, DisableStorage: true})
+			opts = append(opts, state.WithTracer(structLogger))
+		}
+	}
+
+	transition := state.NewTransition(opts...)
+
+	// the gas price is zero so the sender only needs to pay the value
+	transition.Txn().AddBalance(sender, value)
+
+	msg := &state.Message{
+		From:     sender,
+		Gas:      cfg.gas,
+		GasPrice: big.NewInt(0),
+		Value:    value,
+	}
+	if cfg.create {
+		msg.Input = code
+	} else {
+		transition.Txn().SetCode(receiver, code)
+		msg.To = &receiver
+		msg.Input = input
+	}
+
+	output, err := transition.Write(msg)
+	if err != nil {
+		return err
+	}
+
+	if structLogger != nil {
+		writeStructLogs(stderr, structLogger.StructLogs())
+	}
+
+	res := newRunResult(output, cfg.gas-output.GasLeft, transition.Commit())
+	if cfg.json {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(res)
+	}
+	res.write(stdout)
+	return nil
+}
+
+func readCode(cfg *runConfig) ([]byte, error) {
+	str := cfg.code
+	if cfg.codeFile != "" {
+		if str != "" {
+			return nil, fmt.Errorf("the code and the code file are both set")
+		}
+
+		var data []byte
+		var err error
+		if cfg.codeFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(cfg.codeFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read code file: %v", err)
+		}
+		str = string(bytes.TrimSpace(data))
+	}
+	if str == "" {
+		return nil, fmt.Errorf("no code to run")
+	}
+
+	code, err := decodeHex(str)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode code: %v", err)
+	}
+	return code, nil
+}
+
+func decodeHex(str string) ([]byte, error) {
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X")
+	if len(str)%2 == 1 {
+		str = "0" + str
+	}
+	return hex.DecodeString(str)
+}
+
+func decodeAddress(str string) (evmc.Address, error) {
+	var addr evmc.Address
+
+	buf, err := decodeHex(str)
+	if err != nil {
+		return addr, err
+	}
+	if len(buf) > len(addr) {
+		return addr, errors.New("address too long")
+	}
+	copy(addr[len(addr)-len(buf):], buf)
+	return addr, nil
+}
+
+// runResult is the result of the run command
+type runResult struct {
+	Success bool                         `json:"success"`
+	Output  string                       `json:"output"`
+	GasUsed uint64                       `json:"gasUsed"`
+	Address *string                      `json:"contractAddress,omitempty"`
+	Logs    []runLog                     `json:"logs"`
+	Storage map[string]map[string]string `json:"storage"`
+}
+
+type runLog struct {
+	Address string   `json:"address"`
+	Topics  []string `json:"topics"`
+	Data    string   `json:"data"`
+}
+
+func newRunResult(output *state.Output, gasUsed uint64, objs []*state.Object) *runResult {
+	res := &runResult{
+		Success: output.Success,
+		Output:  encodeHex(output.ReturnValue),
+		GasUsed: gasUsed,
+		Logs:    []runLog{},
+		Storage: map[string]map[string]string{},
+	}
+	if output.ContractAddress != (evmc.Address{}) {
+		addr := encodeHex(output.ContractAddress[:])
+		res.Address = &addr
+	}
+
+	for _, log := range output.Logs {
+		item := runLog{
+			Address: encodeHex(log.Address[:]),
+			Topics:  []string{},
+			Data:    encodeHex(log.Data),
+		}
+		for _, topic := range log.Topics {
+			item.Topics = append(item.Topics, encodeHex(topic[:]))
+		}
+		res.Logs = append(res.Logs, item)
+	}
+
+	for _, obj := range objs {
+		storage := map[string]string{}
+		for _, entry := range obj.Storage {
+			if entry.Deleted {
+				continue
+			}
+			storage[encodeHex(leftPad(entry.Key))] = encodeHex(leftPad(entry.Val))
+		}
+		if len(storage) != 0 {
+			res.Storage[encodeHex(obj.Address[:])] = storage
+		}
+	}
+	return res
+}
+
+func (r *runResult) write(w io.Writer) {
+	fmt.Fprintf(w, "success:  %v\n", r.Success)
+	fmt.Fprintf(w, "output:   %s\n", r.Output)
+	fmt.Fprintf(w, "gas used: %d\n", r.GasUsed)
+	if r.Address != nil {
+		fmt.Fprintf(w, "address:  %s\n", *r.Address)
+	}
+
+	if len(r.Logs) != 0 {
+		fmt.Fprintf(w, "logs:\n")
+		for _, log := range r.Logs {
+			fmt.Fprintf(w, "  %s\n", log.Address)
+			for i, topic := range log.Topics {
+				fmt.Fprintf(w, "    topic %d: %s\n", i, topic)
+			}
+			fmt.Fprintf(w, "    data:    %s\n", log.Data)
+		}
+	}
+
+	if len(r.Storage) != 0 {
+		fmt.Fprintf(w, "storage:\n")
+		for _, addr := range sortedKeys(r.Storage) {
+			fmt.Fprintf(w, "  %s\n", addr)
+
+			slots := r.Storage[addr]
+			for _, key := range sortedKeys(slots) {
+				fmt.Fprintf(w, "    %s: %s\n", key, slots[key])
+			}
+		}
+	}
+}
+
+func writeStructLogs(w io.Writer, logs []*tracer.StructLog) {
+	fmt.Fprintf(w, "%-6s %-16s %-10s %-8s %-6s %s\n", "PC", "OP", "GAS", "COST", "DEPTH", "STACK")
+	for _, log := range logs {
+		stack := make([]string, len(log.Stack))
+		for i, item := range log.Stack {
+			stack[i] = "0x" + item.Text(16)
+		}
+		fmt.Fprintf(w, "%-6d %-16s %-10d %-8d %-6d [%s]\n", log.Pc, log.Op.String(), log.Gas, log.GasCost, log.Depth, strings.Join(stack, " "))
+		if log.Err != nil {
+			fmt.Fprintf(w, "error: %v\n", log.Err)
+		}
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func leftPad(b []byte) []byte {
+	if len(b) >= 32 {
+		return b
+	}
+	res := make([]byte, 32)
+	copy(res[32-len(b):], b)
+	return res
+}
+
+func encodeHex(b []byte) string {
+	return "0x" + hex.EncodeToString(b)
+}
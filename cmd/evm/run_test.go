@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommand(t *testing.T) {
+	// SSTORE(0x1, 0x1) MSTORE(0, 0x2a) RETURN(0, 32)
+	code := "0x6001600155602a60005260206000f3"
+
+	t.Run("Text", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, runMain([]string{"run", "--fork", "Berlin", code}, &stdout, &stderr))
+
+		out := stdout.String()
+		assert.Contains(t, out, "success:  true")
+		assert.Contains(t, out, "output:   0x000000000000000000000000000000000000000000000000000000000000002a")
+		assert.Contains(t, out, "gas used: 43124")
+		assert.Empty(t, stderr.String())
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 0, runMain([]string{"run", "--json", "--trace", "--code", code}, &stdout, &stderr))
+
+		var res runResult
+		assert.NoError(t, json.Unmarshal(stdout.Bytes(), &res))
+		assert.True(t, res.Success)
+
+		slot := "0x0000000000000000000000000000000000000000000000000000000000000001"
+		assert.Equal(t, map[string]map[string]string{
+			"0x0000000000000000000000000000000000007265": {slot: slot},
+		}, res.Storage)
+
+		// one line per opcode and the summary
+		lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+		assert.Len(t, lines, 10)
+	})
+
+	t.Run("UnknownFork", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		assert.Equal(t, 1, runMain([]string{"run", "--fork", "Foo", code}, &stdout, &stderr))
+		assert.Contains(t, stderr.String(), `unknown fork "Foo"`)
+	})
+}
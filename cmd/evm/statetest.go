@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/umbracle/go-evm/tests"
+	"github.com/umbracle/go-evm/tracer"
+)
+
+type statetestConfig struct {
+	fork  string
+	run   string
+	index int
+	trace bool
+}
+
+func statetestCommand(args []string, stdout, stderr io.Writer) error {
+	var cfg statetestConfig
+
+	flags := flag.NewFlagSet("statetest", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: evm statetest [flags] <file or directory>...\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&cfg.fork, "fork", "", "only run the subtests of the fork")
+	flags.StringVar(&cfg.run, "run", "", "only run the tests with a name that matches the regular expression")
+	flags.IntVar(&cfg.index, "index", -1, "only run the subtest with the index, a negative value runs all of them")
+	flags.BoolVar(&cfg.trace, "trace", false, "print the eip-3155 trace of the failing subtests to stderr")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf("no test files")
+	}
+
+	return statetest(&cfg, flags.Args(), stdout, stderr)
+}
+
+func statetest(cfg *statetestConfig, paths []string, stdout, stderr io.Writer) error {
+	var filter *regexp.Regexp
+	if cfg.run != "" {
+		var err error
+		if filter, err = regexp.Compile(cfg.run); err != nil {
+			return fmt.Errorf("invalid test name filter: %v", err)
+		}
+	}
+
+	files, err := listTestFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	results := []*tests.StateResult{}
+	failed := 0
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		var cases map[string]*tests.StateCase
+		if err := json.Unmarshal(data, &cases); err != nil {
+			return fmt.Errorf("failed to decode %s: %v", file, err)
+		}
+
+		for _, name := range sortedKeys(cases) {
+			if filter != nil && !filter.MatchString(name) {
+				continue
+			}
+
+			c := cases[name]
+			for _, subtest := range c.Subtests() {
+				if cfg.fork != "" && subtest.Fork != cfg.fork {
+					continue
+				}
+				if cfg.index >= 0 && subtest.Index != cfg.index {
+					continue
+				}
+
+				res := c.Run(name, subtest, nil)
+				if !res.Pass {
+					failed++
+					if cfg.trace {
+						// run the subtest again to trace it
+						res = c.Run(name, subtest, tracer.NewJSONLogger(nil, stderr))
+						fmt.Fprintf(stderr, "{\"stateRoot\": \"%s\"}\n", encodeHex(res.Root[:]))
+					}
+				}
+				results = append(results, res)
+			}
+		}
+	}
+
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(results); err != nil {
+		return err
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(results))
+	}
+	return nil
+}
+
+// listTestFiles returns the json files of the paths, the directories are
+// walked recursively
+func listTestFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && strings.HasSuffix(path, ".json") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the subtest 0 stores a value and emits a log, the subtest 1 has
+// the wrong post state
+const statetestJSON = `{
+  "simpleStore": {
+    "env": {
+      "currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+      "currentDifficulty": "0x020000",
+      "currentGasLimit": "0x05f5e100",
+      "currentNumber": "0x01",
+      "currentTimestamp": "0x03e8",
+      "currentBaseFee": "0x0a"
+    },
+    "pre": {
+      "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {
+        "balance": "0x0de0b6b3a7640000",
+        "code": "0x",
+        "nonce": "0x00",
+        "storage": {}
+      },
+      "0x0000000000000000000000000000000000001000": {
+        "balance": "0x00",
+        "code": "0x600160015560006000a0",
+        "nonce": "0x00",
+        "storage": {}
+      }
+    },
+    "transaction": {
+      "data": ["0x", "0x01"],
+      "gasLimit": ["0x0186a0"],
+      "gasPrice": "0x0a",
+      "nonce": "0x00",
+      "secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8",
+      "to": "0x0000000000000000000000000000000000001000",
+      "value": ["0x01"]
+    },
+    "post": {
+      "Berlin": [
+        {"hash": "0x64285652c3d3d5a0b557b4431fb21a57c8d725ddb43571b67b6a0a3b56935512", "logs": "0x735d9a20499b246c4cc69577c75fd22b7bd74916ef6f106ee358bb688e5c155a", "indexes": {"data": 0, "gas": 0, "value": 0}},
+        {"hash": "0x00", "logs": "0x00", "indexes": {"data": 1, "gas": 0, "value": 0}}
+      ]
+    }
+  }
+}`
+
+func TestStatetestCommand(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "simple.json"), []byte(statetestJSON), 0644))
+
+	type result struct {
+		Name  string `json:"name"`
+		Index int    `json:"index"`
+		Pass  bool   `json:"pass"`
+		Error string `json:"error"`
+	}
+
+	t.Run("Pass", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.Equal(t, 0, runMain([]string{"statetest", "--index", "0", dir}, &stdout, &stderr), stderr.String())
+
+		var res []result
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &res))
+		assert.Equal(t, []result{{Name: "simpleStore", Index: 0, Pass: true}}, res)
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.Equal(t, 1, runMain([]string{"statetest", "--trace", "--fork", "Berlin", filepath.Join(dir, "simple.json")}, &stdout, &stderr))
+
+		var res []result
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &res))
+		assert.Len(t, res, 2)
+		assert.True(t, res[0].Pass)
+		assert.False(t, res[1].Pass)
+		assert.Equal(t, "root mismatch", res[1].Error)
+
+		// the trace of the failing subtest only
+		out := stderr.String()
+		assert.Contains(t, out, `"opName":"SSTORE"`)
+		assert.Contains(t, out, `"stateRoot": "0xa8bd061fe51c77b29c35cb1b8a4bca481f33ec148a2f97d2b9ab9f7f14388816"`)
+		assert.Equal(t, 1, strings.Count(out, `"opName":"SSTORE"`))
+		assert.Contains(t, out, "error: 1 of 2 tests failed")
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		var stdout, stderr bytes.Buffer
+		require.Equal(t, 0, runMain([]string{"statetest", "--run", "^other$", dir}, &stdout, &stderr))
+		assert.Equal(t, "[]", strings.TrimSpace(stdout.String()))
+	})
+}
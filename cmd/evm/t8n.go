@@ -0,0 +1,624 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/fastrlp"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/evm"
+	"github.com/umbracle/go-evm/transaction"
+	"github.com/umbracle/go-evm/trie"
+)
+
+type t8nConfig struct {
+	inputAlloc    string
+	inputEnv      string
+	inputTxs      string
+	outputBasedir string
+	outputResult  string
+	outputAlloc   string
+	outputBody    string
+	fork          string
+	reward        int64
+	chainID       uint64
+}
+
+// t8nInput is the input of the transition when it is read from stdin
+type t8nInput struct {
+	Alloc  alloc     `json:"alloc"`
+	Env    *env      `json:"env"`
+	Txs    []*txJSON `json:"txs"`
+	TxsRlp string    `json:"txsRlp"`
+}
+
+func t8nCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	var cfg t8nConfig
+
+	flags := flag.NewFlagSet("t8n", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: evm t8n [flags]\n\nFlags:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&cfg.inputAlloc, "input.alloc", "alloc.json", "file with the pre state, 'stdin' reads it from the stdin")
+	flags.StringVar(&cfg.inputEnv, "input.env", "env.json", "file with the block environment, 'stdin' reads it from the stdin")
+	flags.StringVar(&cfg.inputTxs, "input.txs", "txs.json", "file with the transactions in json or, with the .rlp extension, in rlp. 'stdin' reads them from the stdin")
+	flags.StringVar(&cfg.outputBasedir, "output.basedir", "", "directory of the output files")
+	flags.StringVar(&cfg.outputResult, "output.result", "result.json", "file of the result, 'stdout' or 'stderr' writes it to the stream")
+	flags.StringVar(&cfg.outputAlloc, "output.alloc", "alloc.json", "file of the post state, 'stdout' or 'stderr' writes it to the stream")
+	flags.StringVar(&cfg.outputBody, "output.body", "", "file of the rlp encoded transactions of the block, it is not written if empty")
+	flags.StringVar(&cfg.fork, "state.fork", "Cancun", "name of the fork, one of "+forkNames()+" or a transition like ShanghaiToCancunAtTime15k")
+	flags.Int64Var(&cfg.reward, "state.reward", -1, "mining reward in wei, a negative value disables the reward")
+	flags.Uint64Var(&cfg.chainID, "state.chainid", 1, "chain id")
+
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() != 0 {
+		return fmt.Errorf("unexpected arguments")
+	}
+
+	return t8n(&cfg, stdin, stdout, stderr)
+}
+
+func t8n(cfg *t8nConfig, stdin io.Reader, stdout, stderr io.Writer) error {
+	chainConfig, ok := state.ForkChainConfig(cfg.fork)
+	if !ok {
+		return fmt.Errorf("unknown fork %q, expected one of %s or a transition like ShanghaiToCancunAtTime15k", cfg.fork, forkNames())
+	}
+
+	pre, env, txs, invalid, err := readT8nInput(cfg, stdin)
+	if err != nil {
+		return err
+	}
+
+	t := &t8nTransition{
+		config:  chainConfig,
+		rev:     chainConfig.Revision(uint64(env.Number), uint64(env.Timestamp)),
+		chainID: cfg.chainID,
+		reward:  cfg.reward,
+		pre:     pre,
+		env:     env,
+		invalid: invalid,
+	}
+	result, post, err := t.apply(txs)
+	if err != nil {
+		return err
+	}
+
+	outputs := map[string]map[string]interface{}{}
+	write := func(name, key string, obj interface{}) error {
+		if name == "" {
+			return nil
+		}
+		if name == "stdout" || name == "stderr" {
+			if outputs[name] == nil {
+				outputs[name] = map[string]interface{}{}
+			}
+			outputs[name][key] = obj
+			return nil
+		}
+
+		data, err := json.MarshalIndent(obj, "", "  ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(cfg.outputBasedir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %v", key, err)
+		}
+		return nil
+	}
+
+	if err := write(cfg.outputResult, "result", result); err != nil {
+		return err
+	}
+	if err := write(cfg.outputAlloc, "alloc", post); err != nil {
+		return err
+	}
+	body := hexBytes(transaction.EncodeList(t.included))
+	if err := write(cfg.outputBody, "body", body); err != nil {
+		return err
+	}
+
+	streams := map[string]io.Writer{
+		"stdout": stdout,
+		"stderr": stderr,
+	}
+	for name, obj := range outputs {
+		enc := json.NewEncoder(streams[name])
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readT8nInput reads the inputs of the transition. The transactions of
+// txs.json that cannot be decoded are nil and their error is in invalid.
+func readT8nInput(cfg *t8nConfig, stdin io.Reader) (alloc, *env, []*transaction.Transaction, map[int]error, error) {
+	var input t8nInput
+
+	if cfg.inputAlloc == "stdin" || cfg.inputEnv == "stdin" || cfg.inputTxs == "stdin" {
+		if err := json.NewDecoder(stdin).Decode(&input); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to decode stdin: %v", err)
+		}
+	}
+	if cfg.inputAlloc != "stdin" {
+		if err := readJSONFile(cfg.inputAlloc, &input.Alloc); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+	if cfg.inputEnv != "stdin" {
+		if err := readJSONFile(cfg.inputEnv, &input.Env); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+
+	var txs []*transaction.Transaction
+	invalid := map[int]error{}
+	if cfg.inputTxs != "stdin" && strings.HasSuffix(cfg.inputTxs, ".rlp") {
+		// the rlp file is a json string with the hex encoded transactions
+		if err := readJSONFile(cfg.inputTxs, &input.TxsRlp); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	} else if cfg.inputTxs != "stdin" {
+		if err := readJSONFile(cfg.inputTxs, &input.Txs); err != nil {
+			return nil, nil, nil, nil, err
+		}
+	}
+
+	if input.TxsRlp != "" {
+		buf, err := decodeHex(input.TxsRlp)
+		if err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to decode the rlp transactions: %v", err)
+		}
+		if txs, err = transaction.DecodeList(buf); err != nil {
+			return nil, nil, nil, nil, fmt.Errorf("failed to decode the rlp transactions: %v", err)
+		}
+	} else {
+		for i, obj := range input.Txs {
+			tx, err := newTransactionFromJSON(obj, cfg.chainID)
+			if err != nil {
+				invalid[i] = err
+			}
+			txs = append(txs, tx)
+		}
+	}
+
+	if input.Alloc == nil {
+		input.Alloc = alloc{}
+	}
+	if input.Env == nil {
+		return nil, nil, nil, nil, fmt.Errorf("env not set")
+	}
+	return input.Alloc, input.Env, txs, invalid, nil
+}
+
+func readJSONFile(path string, obj interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, obj); err != nil {
+		return fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	return nil
+}
+
+// t8nTransition applies the transactions of a block on top of the alloc
+type t8nTransition struct {
+	config  *state.ChainConfig
+	rev     evmc.Revision
+	chainID uint64
+	reward  int64
+	pre     alloc
+	env     *env
+
+	// invalid are the transactions of txs.json that could not be decoded
+	invalid map[int]error
+
+	// included are the transactions that are part of the block
+	included []*transaction.Transaction
+}
+
+// chainConfig returns the schedule of the fork with the chain id of the
+// transition. Before Paris, the merge is signaled by the randao of the env.
+func (t *t8nTransition) chainConfig() *state.ChainConfig {
+	c := *t.config
+	c.ChainID = new(big.Int).SetUint64(t.chainID)
+	if t.env.Random != nil && c.ParisBlock == nil {
+		c.ParisBlock = big.NewInt(0)
+	}
+	return &c
+}
+
+// block returns the block of the env with the messages of the transactions
+func (t *t8nTransition) block(msgs []*state.Message) *state.Block {
+	header := &state.Header{
+		ParentHash: evmc.Hash(t.env.ParentHash),
+		Coinbase:   evmc.Address(t.env.Coinbase),
+		Number:     uint64(t.env.Number),
+		Timestamp:  uint64(t.env.Timestamp),
+		GasLimit:   uint64(t.env.GasLimit),
+		Difficulty: t.env.Difficulty.Big(),
+		BaseFee:    t.env.BaseFee.Big(),
+	}
+	if t.env.Random != nil {
+		header.MixDigest = evmc.Hash(*t.env.Random)
+	}
+	if t.rev >= evm.Cancun {
+		excessBlobGas := t.excessBlobGas()
+		header.ExcessBlobGas = &excessBlobGas
+	}
+	if t.env.ParentBeaconRoot != nil {
+		root := evmc.Hash(*t.env.ParentBeaconRoot)
+		header.ParentBeaconRoot = &root
+	}
+
+	block := &state.Block{
+		Header:       header,
+		Transactions: msgs,
+	}
+	for _, o := range t.env.Ommers {
+		block.Uncles = append(block.Uncles, &state.Uncle{
+			Coinbase: evmc.Address(o.Address),
+			Number:   uint64(t.env.Number) - o.Delta,
+		})
+	}
+	for _, w := range t.env.Withdrawals {
+		block.Withdrawals = append(block.Withdrawals, &state.Withdrawal{
+			Index:          uint64(w.Index),
+			ValidatorIndex: uint64(w.ValidatorIndex),
+			Address:        evmc.Address(w.Address),
+			Amount:         uint64(w.Amount),
+		})
+	}
+	return block
+}
+
+// excessBlobGas returns the excess blob gas of the env or the one computed
+// from the parent, it is zero if neither is set
+func (t *t8nTransition) excessBlobGas() uint64 {
+	if t.env.ExcessBlobGas != nil {
+		return uint64(*t.env.ExcessBlobGas)
+	}
+	if t.env.ParentExcessBlobGas != nil && t.env.ParentBlobGasUsed != nil {
+		return state.CalcExcessBlobGas(t.rev, uint64(*t.env.ParentExcessBlobGas), uint64(*t.env.ParentBlobGasUsed))
+	}
+	return 0
+}
+
+// blockReward returns the reward of the state.reward flag, a negative
+// value disables the rewards
+func (t *t8nTransition) blockReward(evmc.Revision) *big.Int {
+	if t.reward < 0 {
+		return nil
+	}
+	return big.NewInt(t.reward)
+}
+
+func (t *t8nTransition) getHash(num uint64) evmc.Hash {
+	return evmc.Hash(t.env.BlockHashes[hexUint64(num)])
+}
+
+func (t *t8nTransition) apply(txs []*transaction.Transaction) (*executionResult, alloc, error) {
+	result := &executionResult{
+		Receipts:   []*receiptJSON{},
+		Difficulty: t.env.Difficulty,
+		BaseFee:    t.env.BaseFee,
+	}
+	reject := func(i int, err error) {
+		result.Rejected = append(result.Rejected, &rejectedTx{Index: i, Error: err.Error()})
+	}
+
+	// the transactions that cannot be converted to messages are rejected
+	// before the block is processed
+	var msgs []*state.Message
+	var valid []int
+	for i, tx := range txs {
+		if err, ok := t.invalid[i]; ok {
+			reject(i, err)
+			continue
+		}
+		if err := t.validateTx(tx); err != nil {
+			reject(i, err)
+			continue
+		}
+		msg, err := tx.ToMessage(t.rev >= evmc.Homestead)
+		if err != nil {
+			reject(i, err)
+			continue
+		}
+		msgs = append(msgs, msg)
+		valid = append(valid, i)
+	}
+
+	processor := state.NewBlockProcessor(
+		t.chainConfig(),
+		state.WithGetHash(t.getHash),
+		state.WithState(&allocState{alloc: t.pre}),
+	)
+	processor.SkipInvalid = true
+	processor.BlockReward = t.blockReward
+	processor.StateRoot = func(objs []*state.Object) evmc.Hash {
+		return stateRoot(mergeAlloc(t.pre, objs))
+	}
+
+	res, err := processor.Process(t.block(msgs))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rejected := map[int]bool{}
+	for _, r := range res.Rejected {
+		rejected[r.Index] = true
+		reject(valid[r.Index], r.Err)
+	}
+	sort.Slice(result.Rejected, func(i, j int) bool {
+		return result.Rejected[i].Index < result.Rejected[j].Index
+	})
+	for i, j := range valid {
+		if !rejected[i] {
+			t.included = append(t.included, txs[j])
+		}
+	}
+
+	var logs []*state.Log
+	for _, r := range res.Receipts {
+		result.Receipts = append(result.Receipts, receiptToJSON(r))
+		logs = append(logs, r.Logs...)
+	}
+
+	post := mergeAlloc(t.pre, res.Objects)
+
+	result.StateRoot = hexHash(stateRoot(post))
+	result.TxRoot = hexHash(listRoot(len(t.included), func(i int) []byte {
+		return t.included[i].MarshalRLP()
+	}))
+	result.ReceiptsRoot = hexHash(listRoot(len(res.Receipts), func(i int) []byte {
+		return res.Receipts[i].MarshalRLP()
+	}))
+	result.LogsHash = hexHash(state.LogsHash(logs))
+	result.LogsBloom = res.Bloom[:]
+	result.GasUsed = hexUint64(res.GasUsed)
+
+	if t.env.Withdrawals != nil {
+		root := hexHash(withdrawalsRoot(t.env.Withdrawals))
+		result.WithdrawalsRoot = &root
+	}
+	if t.rev >= evm.Cancun {
+		excessBlobGas, blobGasUsed := hexUint64(t.excessBlobGas()), hexUint64(res.BlobGasUsed)
+		result.ExcessBlobGas = &excessBlobGas
+		result.BlobGasUsed = &blobGasUsed
+	}
+	return result, post, nil
+}
+
+// validateTx checks that the type and the chain id of the transaction are
+// valid in the fork
+func (t *t8nTransition) validateTx(tx *transaction.Transaction) error {
+	switch tx.Type {
+	case transaction.AccessListTxType:
+		if t.rev < evmc.Berlin {
+			return transaction.ErrTxTypeNotSupported
+		}
+	case transaction.DynamicFeeTxType:
+		if t.rev < evmc.London {
+			return transaction.ErrTxTypeNotSupported
+		}
+	case transaction.BlobTxType:
+		if t.rev < evm.Cancun {
+			return transaction.ErrTxTypeNotSupported
+		}
+	}
+
+	chainID := tx.ChainID
+	if tx.Type == transaction.LegacyTxType && chainID != nil && t.rev < evmc.SpuriousDragon {
+		return fmt.Errorf("replay protected transaction before eip-155")
+	}
+	if chainID != nil && chainID.Cmp(new(big.Int).SetUint64(t.chainID)) != 0 {
+		return fmt.Errorf("invalid chain id %s", chainID)
+	}
+	return nil
+}
+
+// receiptToJSON returns the receipt in the result of the transition
+func receiptToJSON(r *state.Receipt) *receiptJSON {
+	obj := &receiptJSON{
+		Type:              hexUint64(r.Type),
+		Root:              encodeHex(r.PostState),
+		Status:            hexUint64(r.Status),
+		CumulativeGasUsed: hexUint64(r.CumulativeGasUsed),
+		LogsBloom:         r.Bloom[:],
+		Logs:              []*logJSON{},
+		TxHash:            hexHash(r.TxHash),
+		GasUsed:           hexUint64(r.GasUsed),
+		TransactionIndex:  hexUint64(r.TxIndex),
+	}
+	if r.ContractAddress != nil {
+		addr := hexAddr(*r.ContractAddress)
+		obj.ContractAddress = &addr
+	}
+	for _, log := range r.Logs {
+		item := &logJSON{
+			Address:  hexAddr(log.Address),
+			Topics:   []hexHash{},
+			Data:     log.Data,
+			TxHash:   hexHash(log.TxHash),
+			TxIndex:  hexUint64(log.TxIndex),
+			LogIndex: hexUint64(log.Index),
+		}
+		for _, topic := range log.Topics {
+			item.Topics = append(item.Topics, hexHash(topic))
+		}
+		obj.Logs = append(obj.Logs, item)
+	}
+	return obj
+}
+
+// allocState is the snapshot of the pre state of the transition
+type allocState struct {
+	alloc alloc
+}
+
+func (a *allocState) GetStorage(addr evmc.Address, root evmc.Hash, key evmc.Hash) evmc.Hash {
+	acct, ok := a.alloc[hexAddr(addr)]
+	if !ok || acct == nil {
+		return evmc.Hash{}
+	}
+	return evmc.Hash(acct.Storage[hexHash(key)])
+}
+
+func (a *allocState) GetAccount(addr evmc.Address) (*state.Account, error) {
+	acct, ok := a.alloc[hexAddr(addr)]
+	if !ok || acct == nil {
+		return nil, nil
+	}
+
+	balance := acct.Balance.Big()
+	if balance == nil {
+		balance = new(big.Int)
+	}
+	return &state.Account{
+		Balance:  balance,
+		Nonce:    uint64(acct.Nonce),
+		CodeHash: ethgo.Keccak256(acct.Code),
+		Code:     acct.Code,
+	}, nil
+}
+
+// mergeAlloc returns the post state from the pre state and the objects
+// modified by the transition
+func mergeAlloc(pre alloc, objs []*state.Object) alloc {
+	post := alloc{}
+	for addr, acct := range pre {
+		if acct == nil {
+			continue
+		}
+		storage := map[hexHash]hexHash{}
+		for k, v := range acct.Storage {
+			if v != (hexHash{}) {
+				storage[k] = v
+			}
+		}
+		post[addr] = &allocAccount{
+			Code:    acct.Code,
+			Storage: storage,
+			Balance: acct.Balance,
+			Nonce:   acct.Nonce,
+		}
+	}
+
+	for _, obj := range objs {
+		addr := hexAddr(obj.Address)
+		if obj.Deleted {
+			delete(post, addr)
+			continue
+		}
+
+		acct, ok := post[addr]
+		if !ok {
+			acct = &allocAccount{
+				Storage: map[hexHash]hexHash{},
+			}
+			post[addr] = acct
+		}
+		balance := hexBig(*obj.Balance)
+		acct.Balance = &balance
+		acct.Nonce = hexUint64(obj.Nonce)
+
+		if obj.DirtyCode {
+			// the account was created again, the previous storage is gone
+			acct.Code = obj.Code
+			acct.Storage = map[hexHash]hexHash{}
+		}
+		for _, entry := range obj.Storage {
+			var key, val hexHash
+			copy(key[:], leftPad(entry.Key))
+
+			if entry.Deleted || len(bytes.TrimLeft(entry.Val, "\x00")) == 0 {
+				delete(acct.Storage, key)
+			} else {
+				copy(val[:], leftPad(entry.Val))
+				acct.Storage[key] = val
+			}
+		}
+	}
+
+	for _, acct := range post {
+		if len(acct.Storage) == 0 {
+			acct.Storage = nil
+		}
+	}
+	return post
+}
+
+// stateRoot returns the root of the state trie of the alloc
+func stateRoot(post alloc) evmc.Hash {
+	objs := make([]*state.Object, 0, len(post))
+	for addr, acct := range post {
+		obj := &state.Object{
+			Address: evmc.Address(addr),
+			Nonce:   uint64(acct.Nonce),
+			Balance: acct.Balance.Big(),
+		}
+		if obj.Balance == nil {
+			obj.Balance = new(big.Int)
+		}
+		copy(obj.CodeHash[:], ethgo.Keccak256(acct.Code))
+
+		for k, v := range acct.Storage {
+			key, val := k, v
+			obj.Storage = append(obj.Storage, &state.StorageObject{Key: key[:], Val: val[:]})
+		}
+		objs = append(objs, obj)
+	}
+	return trie.StateRoot(objs)
+}
+
+// listRoot returns the root of the trie of a list of the block indexed by
+// the rlp encoding of the position
+func listRoot(n int, item func(i int) []byte) evmc.Hash {
+	a := &fastrlp.Arena{}
+	tt := trie.New(nil)
+
+	for i := 0; i < n; i++ {
+		key := a.NewUint(uint64(i)).MarshalTo(nil)
+		if err := tt.Insert(key, item(i)); err != nil {
+			panic(err)
+		}
+	}
+	return tt.Hash()
+}
+
+// withdrawalsRoot returns the root of the trie of the withdrawals (eip-4895)
+func withdrawalsRoot(withdrawals []*withdrawal) evmc.Hash {
+	return listRoot(len(withdrawals), func(i int) []byte {
+		a := &fastrlp.Arena{}
+		w := withdrawals[i]
+
+		v := a.NewArray()
+		v.Set(a.NewUint(uint64(w.Index)))
+		v.Set(a.NewUint(uint64(w.ValidatorIndex)))
+		v.Set(a.NewCopyBytes(w.Address[:]))
+		v.Set(a.NewUint(uint64(w.Amount)))
+		return v.MarshalTo(nil)
+	})
+}
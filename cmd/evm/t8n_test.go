@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/transaction"
+)
+
+func TestT8nCommand(t *testing.T) {
+	sender := "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b"
+	receiver := "0x0000000000000000000000000000000000001000"
+	coinbase := "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba"
+
+	envJSON := `{
+		"currentCoinbase": "` + coinbase + `",
+		"currentGasLimit": "0x1000000",
+		"currentNumber": "1",
+		"currentTimestamp": "1000",
+		"currentRandom": "0x0",
+		"currentBaseFee": "0x7"
+	}`
+	allocJSON := `{
+		"` + sender + `": {"balance": "0x3b9aca00"}
+	}`
+	txsJSON := `[
+		{
+			"type": "0x0",
+			"nonce": "0x0",
+			"gasPrice": "0xa",
+			"gas": "0x5208",
+			"to": "` + receiver + `",
+			"value": "0x1",
+			"input": "0x",
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+		},
+		{
+			"type": "0x2",
+			"nonce": "0x1",
+			"maxPriorityFeePerGas": "0x1",
+			"maxFeePerGas": "0x8",
+			"gas": "0x5208",
+			"to": "` + receiver + `",
+			"value": "0x2",
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+		},
+		{
+			"nonce": "0x5",
+			"gasPrice": "0xa",
+			"gas": "0x5208",
+			"to": "` + receiver + `",
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+		},
+		{
+			"type": "0x1",
+			"nonce": "0x2",
+			"gas": "0x5208",
+			"to": "` + receiver + `",
+			"accessList": [],
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+		}
+	]`
+
+	writeInputs := func(t *testing.T, txs string) string {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "env.json"), []byte(envJSON), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "alloc.json"), []byte(allocJSON), 0644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "txs.json"), []byte(txs), 0644))
+		return dir
+	}
+
+	type output struct {
+		Alloc  alloc            `json:"alloc"`
+		Result *executionResult `json:"result"`
+		Body   hexBytes         `json:"body"`
+	}
+
+	runT8n := func(t *testing.T, dir string, txsFile string) *output {
+		var stdout, stderr bytes.Buffer
+		code := runMain([]string{
+			"t8n",
+			"--input.alloc", filepath.Join(dir, "alloc.json"),
+			"--input.env", filepath.Join(dir, "env.json"),
+			"--input.txs", filepath.Join(dir, txsFile),
+			"--output.result", "stdout",
+			"--output.alloc", "stdout",
+			"--output.body", "stdout",
+			"--state.fork", "Shanghai",
+		}, &stdout, &stderr)
+		require.Equal(t, 0, code, stderr.String())
+
+		var out output
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+		return &out
+	}
+
+	balance := func(out *output, addr string) *big.Int {
+		var a hexAddr
+		require.NoError(t, a.UnmarshalText([]byte(addr)))
+		acct, ok := out.Alloc[a]
+		require.True(t, ok, addr)
+		return acct.Balance.Big()
+	}
+
+	t.Run("Transfer", func(t *testing.T) {
+		out := runT8n(t, writeInputs(t, txsJSON), "txs.json")
+		res := out.Result
+
+		assert.Equal(t, hexUint64(42000), res.GasUsed)
+		assert.Len(t, res.Receipts, 2)
+		assert.Equal(t, hexUint64(1), res.Receipts[0].Status)
+		assert.Equal(t, hexUint64(21000), res.Receipts[0].CumulativeGasUsed)
+		assert.Equal(t, hexUint64(2), res.Receipts[1].Type)
+		assert.Equal(t, hexUint64(42000), res.Receipts[1].CumulativeGasUsed)
+
+		// the third transaction has the wrong nonce and the fourth has no gas price
+		assert.Len(t, res.Rejected, 2)
+		assert.Equal(t, 2, res.Rejected[0].Index)
+		assert.Equal(t, 3, res.Rejected[1].Index)
+		assert.Equal(t, "gas price not set", res.Rejected[1].Error)
+
+		assert.Equal(t, big.NewInt(3), balance(out, receiver))
+		// the coinbase receives a tip of 3 and 1 per gas
+		assert.Equal(t, big.NewInt(3*21000+1*21000), balance(out, coinbase))
+		assert.Equal(t, big.NewInt(1000000000-3-10*21000-8*21000), balance(out, sender))
+
+		assert.Equal(t, hexUint64(2), out.Alloc[decodeTestAddr(t, sender)].Nonce)
+		assert.NotEqual(t, hexHash(state.EmptyRootHash), res.StateRoot)
+		assert.NotEqual(t, hexHash(state.EmptyRootHash), res.TxRoot)
+		assert.NotEqual(t, hexHash(state.EmptyRootHash), res.ReceiptsRoot)
+
+		// the body can be applied again as rlp and gets the same result
+		dir := writeInputs(t, "")
+		rlp, _ := json.Marshal(out.Body)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "txs.rlp"), rlp, 0644))
+
+		out2 := runT8n(t, dir, "txs.rlp")
+		assert.Equal(t, res.StateRoot, out2.Result.StateRoot)
+		assert.Equal(t, res.TxRoot, out2.Result.TxRoot)
+		assert.Equal(t, res.ReceiptsRoot, out2.Result.ReceiptsRoot)
+		assert.Empty(t, out2.Result.Rejected)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		out := runT8n(t, writeInputs(t, "[]"), "txs.json")
+		res := out.Result
+
+		assert.Equal(t, hexUint64(0), res.GasUsed)
+		assert.Empty(t, res.Receipts)
+		assert.Equal(t, hexHash(state.EmptyRootHash), res.TxRoot)
+		assert.Equal(t, hexHash(state.EmptyRootHash), res.ReceiptsRoot)
+		assert.Equal(t, "0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", encodeHex(res.LogsHash[:]))
+	})
+
+	t.Run("Stdin", func(t *testing.T) {
+		input := `{"alloc": {}, "env": ` + envJSON + `, "txs": []}`
+
+		var stdout, stderr bytes.Buffer
+		cfg := &t8nConfig{
+			inputAlloc:   "stdin",
+			inputEnv:     "stdin",
+			inputTxs:     "stdin",
+			outputResult: "stdout",
+			outputAlloc:  "stderr",
+			fork:         "Paris",
+			reward:       -1,
+			chainID:      1,
+		}
+		require.NoError(t, t8n(cfg, strings.NewReader(input), &stdout, &stderr))
+
+		var out output
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+		assert.Equal(t, hexHash(state.EmptyRootHash), out.Result.StateRoot)
+		assert.Contains(t, stderr.String(), `"alloc": {}`)
+	})
+}
+
+func decodeTestAddr(t *testing.T, str string) hexAddr {
+	addr, err := decodeAddress(str)
+	require.NoError(t, err)
+	return hexAddr(addr)
+}
+
+// t8nOutput is the output of the transition written to the stdout
+type t8nOutput struct {
+	Alloc  alloc            `json:"alloc"`
+	Result *executionResult `json:"result"`
+}
+
+func runT8nStdin(t *testing.T, fork string, reward int64, input string) *t8nOutput {
+	var stdout, stderr bytes.Buffer
+	cfg := &t8nConfig{
+		inputAlloc:   "stdin",
+		inputEnv:     "stdin",
+		inputTxs:     "stdin",
+		outputResult: "stdout",
+		outputAlloc:  "stdout",
+		fork:         fork,
+		reward:       reward,
+		chainID:      1,
+	}
+	require.NoError(t, t8n(cfg, strings.NewReader(input), &stdout, &stderr))
+
+	var out t8nOutput
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &out))
+	return &out
+}
+
+func TestT8nCancun(t *testing.T) {
+	sender := "0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b"
+	key := "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+
+	// runtime code of the beacon roots contract deployed on mainnet (eip-4788)
+	beaconRoots := "0x3373fffffffffffffffffffffffffffffffffffffffe14604d57602036146024575f5ffd5b5f35801560495762001fff810690815414603c575f5ffd5b62001fff01545f5260205ff35b5f5ffd5b62001fff42064281555f359062001fff015500"
+
+	blobTx := func(nonce int, hashes ...string) string {
+		list, _ := json.Marshal(hashes)
+		return `{
+			"type": "0x3",
+			"nonce": "` + fmt.Sprintf("0x%x", nonce) + `",
+			"maxPriorityFeePerGas": "0x1",
+			"maxFeePerGas": "0x8",
+			"maxFeePerBlobGas": "0x1",
+			"gas": "0x5208",
+			"to": "0x0000000000000000000000000000000000001000",
+			"blobVersionedHashes": ` + string(list) + `,
+			"secretKey": "` + key + `"
+		}`
+	}
+	blobHash := "0x01" + strings.Repeat("00", 31)
+
+	input := `{
+		"alloc": {
+			"` + sender + `": {"balance": "0x3b9aca00"},
+			"0x000f3df6d732807ef1319fb7b8bb8522d0beac02": {"balance": "0x0", "nonce": "0x1", "code": "` + beaconRoots + `"}
+		},
+		"env": {
+			"currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+			"currentGasLimit": "0x1000000",
+			"currentNumber": "1",
+			"currentTimestamp": "1000",
+			"currentRandom": "0x0",
+			"currentBaseFee": "0x7",
+			"parentExcessBlobGas": "0x0",
+			"parentBlobGasUsed": "0x80000",
+			"parentBeaconBlockRoot": "0x00000000000000000000000000000000000000000000000000000000000000aa"
+		},
+		"txs": [
+			` + blobTx(0, blobHash, blobHash, blobHash, blobHash) + `,
+			` + blobTx(1, blobHash, blobHash, blobHash) + `,
+			` + blobTx(1) + `,
+			` + blobTx(1, "0x02"+strings.Repeat("00", 31)) + `,
+			` + blobTx(1, blobHash) + `
+		]
+	}`
+
+	t.Run("Cancun", func(t *testing.T) {
+		out := runT8nStdin(t, "Cancun", 0, input)
+		res := out.Result
+
+		require.Len(t, res.Receipts, 2)
+		require.Len(t, res.Rejected, 3)
+		assert.Equal(t, "blob gas limit reached", res.Rejected[0].Error)
+		assert.Equal(t, "blob transaction without blobs", res.Rejected[1].Error)
+		assert.Equal(t, "invalid blob versioned hash version 2", res.Rejected[2].Error)
+
+		// the excess is the blob gas of the parent above the target of 3 blobs
+		require.NotNil(t, res.ExcessBlobGas)
+		assert.Equal(t, hexUint64(state.GasPerBlob), *res.ExcessBlobGas)
+		require.NotNil(t, res.BlobGasUsed)
+		assert.Equal(t, hexUint64(5*state.GasPerBlob), *res.BlobGasUsed)
+
+		// the contract stores the timestamp and the root in the ring buffer
+		acct := out.Alloc[decodeTestAddr(t, "0x000f3df6d732807ef1319fb7b8bb8522d0beac02")]
+		require.NotNil(t, acct)
+		assert.Equal(t, hexHash{31: 0xaa}, acct.Storage[hexHash{30: 0x23, 31: 0xe7}])
+		assert.Equal(t, hexHash{30: 0x03, 31: 0xe8}, acct.Storage[hexHash{30: 0x03, 31: 0xe8}])
+	})
+
+	t.Run("Shanghai", func(t *testing.T) {
+		out := runT8nStdin(t, "Shanghai", 0, input)
+		res := out.Result
+
+		assert.Empty(t, res.Receipts)
+		require.Len(t, res.Rejected, 5)
+		for _, r := range res.Rejected {
+			assert.Equal(t, transaction.ErrTxTypeNotSupported.Error(), r.Error)
+		}
+		assert.Nil(t, res.ExcessBlobGas)
+		assert.Nil(t, res.BlobGasUsed)
+	})
+
+	t.Run("Transition", func(t *testing.T) {
+		// cancun is active from the timestamp 15000
+		out := runT8nStdin(t, "ShanghaiToCancunAtTime15k", 0, input)
+		assert.Empty(t, out.Result.Receipts)
+		assert.Len(t, out.Result.Rejected, 5)
+
+		out = runT8nStdin(t, "ShanghaiToCancunAtTime15k", 0, strings.Replace(input, `"currentTimestamp": "1000"`, `"currentTimestamp": "15000"`, 1))
+		assert.Len(t, out.Result.Receipts, 2)
+		assert.Len(t, out.Result.Rejected, 3)
+	})
+}
+
+func TestT8nOmmers(t *testing.T) {
+	coinbase := "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba"
+	uncle := "0x0000000000000000000000000000000000000ccc"
+
+	input := `{
+		"alloc": {},
+		"env": {
+			"currentCoinbase": "` + coinbase + `",
+			"currentDifficulty": "0x20000",
+			"currentGasLimit": "0x1000000",
+			"currentNumber": "10",
+			"currentTimestamp": "1000",
+			"currentBaseFee": "0x7",
+			"ommers": [{"delta": 1, "address": "` + uncle + `"}]
+		},
+		"txs": []
+	}`
+
+	out := runT8nStdin(t, "London", 2e18, input)
+
+	// 2 eth and 1/32 for the uncle, and 7/8 of 2 eth for the uncle
+	minerReward, _ := new(big.Int).SetString("2062500000000000000", 10)
+	assert.Equal(t, minerReward, out.Alloc[decodeTestAddr(t, coinbase)].Balance.Big())
+	uncleReward, _ := new(big.Int).SetString("1750000000000000000", 10)
+	assert.Equal(t, uncleReward, out.Alloc[decodeTestAddr(t, uncle)].Balance.Big())
+}
+
+func TestT8nPostState(t *testing.T) {
+	input := `{
+		"alloc": {
+			"0xa94f5374fce5edbc8e2a8697c15331677e6ebf0b": {"balance": "0x3b9aca00"}
+		},
+		"env": {
+			"currentCoinbase": "0x2adc25665018aa1fe0e6bc666dac8fc2697ff9ba",
+			"currentDifficulty": "0x20000",
+			"currentGasLimit": "0x1000000",
+			"currentNumber": "1",
+			"currentTimestamp": "1000"
+		},
+		"txs": [{
+			"nonce": "0x0",
+			"gasPrice": "0xa",
+			"gas": "0x5208",
+			"to": "0x0000000000000000000000000000000000001000",
+			"value": "0x1",
+			"protected": false,
+			"secretKey": "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"
+		}]
+	}`
+
+	// without rewards the root after the only transaction is the state root
+	out := runT8nStdin(t, "Homestead", -1, input)
+	require.Len(t, out.Result.Receipts, 1)
+	assert.Equal(t, encodeHex(out.Result.StateRoot[:]), out.Result.Receipts[0].Root)
+
+	out = runT8nStdin(t, "Byzantium", -1, input)
+	require.Len(t, out.Result.Receipts, 1)
+	assert.Equal(t, "0x", out.Result.Receipts[0].Root)
+}
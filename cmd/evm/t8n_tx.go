@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo/wallet"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/transaction"
+)
+
+// newTransactionFromJSON creates a transaction from its json form and signs
+// it if it has a secret key
+func newTransactionFromJSON(obj *txJSON, chainID uint64) (*transaction.Transaction, error) {
+	tx := &transaction.Transaction{
+		Nonce:         uint64(obj.Nonce),
+		Gas:           uint64(obj.Gas),
+		GasPrice:      obj.GasPrice.Big(),
+		GasTipCap:     obj.MaxPriorityFeePerGas.Big(),
+		GasFeeCap:     obj.MaxFeePerGas.Big(),
+		BlobGasFeeCap: obj.MaxFeePerBlobGas.Big(),
+		ChainID:       obj.ChainID.Big(),
+		Value:         obj.Value.Big(),
+		V:             obj.V.Big(),
+		R:             obj.R.Big(),
+		S:             obj.S.Big(),
+	}
+	if obj.Type != nil {
+		tx.Type = transaction.Type(*obj.Type)
+	} else if obj.BlobVersionedHashes != nil {
+		tx.Type = transaction.BlobTxType
+	} else if obj.MaxFeePerGas != nil {
+		tx.Type = transaction.DynamicFeeTxType
+	} else if obj.AccessList != nil {
+		tx.Type = transaction.AccessListTxType
+	}
+	if tx.Type > transaction.BlobTxType {
+		return nil, transaction.ErrTxTypeNotSupported
+	}
+
+	if tx.Value == nil {
+		tx.Value = new(big.Int)
+	}
+	if obj.Input != nil {
+		tx.Input = *obj.Input
+	} else if obj.Data != nil {
+		tx.Input = *obj.Data
+	}
+	if obj.To != nil {
+		to := evmc.Address(*obj.To)
+		tx.To = &to
+	}
+	for _, tuple := range obj.AccessList {
+		entry := state.AccessTuple{
+			Address: evmc.Address(tuple.Address),
+		}
+		for _, key := range tuple.StorageKeys {
+			entry.StorageKeys = append(entry.StorageKeys, evmc.Hash(key))
+		}
+		tx.AccessList = append(tx.AccessList, entry)
+	}
+	for _, hash := range obj.BlobVersionedHashes {
+		tx.BlobHashes = append(tx.BlobHashes, evmc.Hash(hash))
+	}
+
+	switch tx.Type {
+	case transaction.LegacyTxType:
+		if tx.GasPrice == nil {
+			return nil, fmt.Errorf("gas price not set")
+		}
+		if obj.SecretKey == nil {
+			// the chain id of a signed transaction is part of v
+			if tx.V != nil {
+				// an invalid v is rejected when the sender is recovered
+				tx.ChainID, _ = transaction.LegacyChainID(tx.V)
+			}
+		} else if obj.Protected != nil && !*obj.Protected {
+			tx.ChainID = nil
+		} else if tx.ChainID == nil {
+			// legacy transactions are replay protected by default (eip-155)
+			tx.ChainID = new(big.Int).SetUint64(chainID)
+		}
+	default:
+		if tx.ChainID == nil {
+			tx.ChainID = new(big.Int).SetUint64(chainID)
+		}
+		if tx.Type == transaction.AccessListTxType && tx.GasPrice == nil {
+			return nil, fmt.Errorf("gas price not set")
+		}
+		if tx.Type != transaction.AccessListTxType && (tx.GasTipCap == nil || tx.GasFeeCap == nil) {
+			return nil, fmt.Errorf("fee caps not set")
+		}
+		if tx.Type == transaction.BlobTxType {
+			if tx.To == nil {
+				return nil, fmt.Errorf("blob transaction without recipient")
+			}
+			if tx.BlobGasFeeCap == nil {
+				return nil, fmt.Errorf("blob fee cap not set")
+			}
+		}
+	}
+
+	if obj.SecretKey != nil {
+		priv, err := wallet.ParsePrivateKey(obj.SecretKey[:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid secret key: %v", err)
+		}
+		if err := tx.Sign(priv); err != nil {
+			return nil, err
+		}
+	}
+	if tx.V == nil || tx.R == nil || tx.S == nil {
+		return nil, fmt.Errorf("transaction not signed")
+	}
+	return tx, nil
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+)
+
+// The json types of the t8n tool. The numbers are accepted both in hex
+// and in decimal like in the geth tool, and are always written in hex.
+
+type hexBig big.Int
+
+func (b *hexBig) UnmarshalText(input []byte) error {
+	str := string(input)
+
+	num, ok := new(big.Int), false
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		if str = str[2:]; str == "" {
+			// an empty 0x is a zero
+			str = "0"
+		}
+		_, ok = num.SetString(str, 16)
+	} else {
+		_, ok = num.SetString(str, 10)
+	}
+	if !ok || num.Sign() < 0 {
+		return fmt.Errorf("invalid number %q", string(input))
+	}
+	*b = hexBig(*num)
+	return nil
+}
+
+func (b *hexBig) MarshalText() ([]byte, error) {
+	return []byte("0x" + b.Big().Text(16)), nil
+}
+
+func (b *hexBig) Big() *big.Int {
+	if b == nil {
+		return nil
+	}
+	return new(big.Int).Set((*big.Int)(b))
+}
+
+type hexUint64 uint64
+
+func (u *hexUint64) UnmarshalText(input []byte) error {
+	str := string(input)
+
+	var num uint64
+	var err error
+	if strings.HasPrefix(str, "0x") || strings.HasPrefix(str, "0X") {
+		if str = str[2:]; str != "" {
+			num, err = strconv.ParseUint(str, 16, 64)
+		}
+	} else {
+		num, err = strconv.ParseUint(str, 10, 64)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid number %q", string(input))
+	}
+	*u = hexUint64(num)
+	return nil
+}
+
+func (u hexUint64) MarshalText() ([]byte, error) {
+	return []byte("0x" + strconv.FormatUint(uint64(u), 16)), nil
+}
+
+type hexBytes []byte
+
+func (b *hexBytes) UnmarshalText(input []byte) error {
+	buf, err := decodeHex(string(input))
+	if err != nil {
+		return err
+	}
+	*b = buf
+	return nil
+}
+
+func (b hexBytes) MarshalText() ([]byte, error) {
+	return []byte(encodeHex(b)), nil
+}
+
+// hexHash is a hash that is left padded if it is shorter than 32 bytes
+type hexHash evmc.Hash
+
+func (h *hexHash) UnmarshalText(input []byte) error {
+	buf, err := decodeHex(string(input))
+	if err != nil {
+		return err
+	}
+	if len(buf) > 32 {
+		return fmt.Errorf("hash too long")
+	}
+	*h = hexHash{}
+	copy(h[32-len(buf):], buf)
+	return nil
+}
+
+func (h hexHash) MarshalText() ([]byte, error) {
+	return []byte(encodeHex(h[:])), nil
+}
+
+type hexAddr evmc.Address
+
+func (a *hexAddr) UnmarshalText(input []byte) error {
+	addr, err := decodeAddress(string(input))
+	if err != nil {
+		return err
+	}
+	*a = hexAddr(addr)
+	return nil
+}
+
+func (a hexAddr) MarshalText() ([]byte, error) {
+	return []byte(encodeHex(a[:])), nil
+}
+
+// allocAccount is an account of the alloc.json file
+type allocAccount struct {
+	Code    hexBytes            `json:"code,omitempty"`
+	Storage map[hexHash]hexHash `json:"storage,omitempty"`
+	Balance *hexBig             `json:"balance"`
+	Nonce   hexUint64           `json:"nonce,omitempty"`
+}
+
+// alloc is the state of the alloc.json file
+type alloc map[hexAddr]*allocAccount
+
+// withdrawal is a withdrawal of the beacon chain (eip-4895) with the amount in gwei
+type withdrawal struct {
+	Index          hexUint64 `json:"index"`
+	ValidatorIndex hexUint64 `json:"validatorIndex"`
+	Address        hexAddr   `json:"address"`
+	Amount         hexUint64 `json:"amount"`
+}
+
+// ommer is an uncle of the block, delta blocks behind it
+type ommer struct {
+	Delta   uint64  `json:"delta"`
+	Address hexAddr `json:"address"`
+}
+
+// env is the block environment of the env.json file. The excess blob gas
+// is computed from the one of the parent when it is not set.
+type env struct {
+	Coinbase            hexAddr               `json:"currentCoinbase"`
+	Difficulty          *hexBig               `json:"currentDifficulty"`
+	Random              *hexHash              `json:"currentRandom"`
+	GasLimit            hexUint64             `json:"currentGasLimit"`
+	Number              hexUint64             `json:"currentNumber"`
+	Timestamp           hexUint64             `json:"currentTimestamp"`
+	BaseFee             *hexBig               `json:"currentBaseFee"`
+	ExcessBlobGas       *hexUint64            `json:"currentExcessBlobGas"`
+	ParentExcessBlobGas *hexUint64            `json:"parentExcessBlobGas"`
+	ParentBlobGasUsed   *hexUint64            `json:"parentBlobGasUsed"`
+	ParentHash          hexHash               `json:"parentHash"`
+	ParentBeaconRoot    *hexHash              `json:"parentBeaconBlockRoot"`
+	BlockHashes         map[hexUint64]hexHash `json:"blockHashes"`
+	Ommers              []*ommer              `json:"ommers"`
+	Withdrawals         []*withdrawal         `json:"withdrawals"`
+}
+
+// accessTuple is an entry of the access list of a transaction
+type accessTuple struct {
+	Address     hexAddr   `json:"address"`
+	StorageKeys []hexHash `json:"storageKeys"`
+}
+
+// txJSON is a transaction of the txs.json file. The transactions with
+// a secret key are signed by the tool.
+type txJSON struct {
+	Type                 *hexUint64    `json:"type"`
+	ChainID              *hexBig       `json:"chainId"`
+	Nonce                hexUint64     `json:"nonce"`
+	GasPrice             *hexBig       `json:"gasPrice"`
+	MaxPriorityFeePerGas *hexBig       `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexBig       `json:"maxFeePerGas"`
+	MaxFeePerBlobGas     *hexBig       `json:"maxFeePerBlobGas"`
+	Gas                  hexUint64     `json:"gas"`
+	To                   *hexAddr      `json:"to"`
+	Value                *hexBig       `json:"value"`
+	Input                *hexBytes     `json:"input"`
+	Data                 *hexBytes     `json:"data"`
+	AccessList           []accessTuple `json:"accessList"`
+	BlobVersionedHashes  []hexHash     `json:"blobVersionedHashes"`
+	V                    *hexBig       `json:"v"`
+	R                    *hexBig       `json:"r"`
+	S                    *hexBig       `json:"s"`
+	SecretKey            *hexHash      `json:"secretKey"`
+	Protected            *bool         `json:"protected"`
+}
+
+// receiptJSON is a receipt in the result of the transition
+type receiptJSON struct {
+	Type              hexUint64  `json:"type"`
+	Root              string     `json:"root"`
+	Status            hexUint64  `json:"status"`
+	CumulativeGasUsed hexUint64  `json:"cumulativeGasUsed"`
+	LogsBloom         hexBytes   `json:"logsBloom"`
+	Logs              []*logJSON `json:"logs"`
+	TxHash            hexHash    `json:"transactionHash"`
+	ContractAddress   *hexAddr   `json:"contractAddress,omitempty"`
+	GasUsed           hexUint64  `json:"gasUsed"`
+	TransactionIndex  hexUint64  `json:"transactionIndex"`
+}
+
+type logJSON struct {
+	Address  hexAddr   `json:"address"`
+	Topics   []hexHash `json:"topics"`
+	Data     hexBytes  `json:"data"`
+	TxHash   hexHash   `json:"transactionHash"`
+	TxIndex  hexUint64 `json:"transactionIndex"`
+	LogIndex hexUint64 `json:"logIndex"`
+}
+
+// rejectedTx is a transaction that could not be applied
+type rejectedTx struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+// executionResult is the result.json of the transition
+type executionResult struct {
+	StateRoot       hexHash        `json:"stateRoot"`
+	TxRoot          hexHash        `json:"txRoot"`
+	ReceiptsRoot    hexHash        `json:"receiptsRoot"`
+	LogsHash        hexHash        `json:"logsHash"`
+	LogsBloom       hexBytes       `json:"logsBloom"`
+	Receipts        []*receiptJSON `json:"receipts"`
+	Rejected        []*rejectedTx  `json:"rejected,omitempty"`
+	Difficulty      *hexBig        `json:"currentDifficulty"`
+	GasUsed         hexUint64      `json:"gasUsed"`
+	BaseFee         *hexBig        `json:"currentBaseFee,omitempty"`
+	WithdrawalsRoot *hexHash       `json:"withdrawalsRoot,omitempty"`
+
+	// ExcessBlobGas and BlobGasUsed are only set since cancun
+	ExcessBlobGas *hexUint64 `json:"currentExcessBlobGas,omitempty"`
+	BlobGasUsed   *hexUint64 `json:"blobGasUsed,omitempty"`
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 	"github.com/umbracle/ethgo"
+	"github.com/umbracle/go-evm/evm"
 )
 
 type Config struct {
@@ -13,6 +14,22 @@ type Config struct {
 	Rev        evmc.Revision
 	State      Snapshot
 	Cheatcodes []Cheatcode
+	Tracer     evm.Tracer
+
+	// JumpdestCache caches the jumpdest analysis of the contracts between
+	// calls and transitions. It is keyed on the code hash of the State,
+	// which must be the hash of the code.
+	JumpdestCache *evm.JumpdestCache
+
+	// ChainConfig is the fork schedule of the chain. If it is set, the
+	// revision and the chain id are the ones of the block of the context.
+	ChainConfig *ChainConfig
+
+	// StepLimit is the maximum number of opcodes executed by a transaction,
+	// zero means no limit
+	StepLimit uint64
+
+	precompiles map[evmc.Address]*precompile
 }
 
 func DefaultConfig() *Config {
@@ -23,6 +40,11 @@ func DefaultConfig() *Config {
 		State:      &EmptyState{},
 		Cheatcodes: []Cheatcode{},
 	}
+
+	c.precompiles = make(map[evmc.Address]*precompile, len(defaultPrecompiles))
+	for addr, p := range defaultPrecompiles {
+		c.precompiles[addr] = p
+	}
 	return c
 }
 
@@ -46,6 +68,14 @@ func WithRevision(rev evmc.Revision) ConfigOption {
 	}
 }
 
+// WithChainConfig sets the fork schedule used to select the revision
+// from the number and the timestamp of the block
+func WithChainConfig(chainConfig *ChainConfig) ConfigOption {
+	return func(c *Config) {
+		c.ChainConfig = chainConfig
+	}
+}
+
 func WithState(state Snapshot) ConfigOption {
 	return func(c *Config) {
 		c.State = state
@@ -63,6 +93,43 @@ func WithCheatcode(cheat Cheatcode) ConfigOption {
 	}
 }
 
+// WithTracer sets the tracer that receives the events of the execution
+func WithTracer(tracer evm.Tracer) ConfigOption {
+	return func(c *Config) {
+		c.Tracer = tracer
+	}
+}
+
+// WithJumpdestCache sets the cache of the jumpdest analysis, the code is
+// analyzed on every call without it. The cache can be shared by the
+// transitions whose states return the hash of the code as its code hash.
+func WithJumpdestCache(cache *evm.JumpdestCache) ConfigOption {
+	return func(c *Config) {
+		c.JumpdestCache = cache
+	}
+}
+
+// WithStepLimit sets the maximum number of opcodes executed by a transaction
+// before it is aborted with evm.ErrExecutionAborted
+func WithStepLimit(limit uint64) ConfigOption {
+	return func(c *Config) {
+		c.StepLimit = limit
+	}
+}
+
+// WithPrecompile registers the precompile at addr from the revision fromRev,
+// replacing any existing precompile at that address. A nil precompile
+// removes the one registered at addr.
+func WithPrecompile(addr evmc.Address, p Precompile, fromRev evmc.Revision) ConfigOption {
+	return func(c *Config) {
+		if p == nil {
+			delete(c.precompiles, addr)
+			return
+		}
+		c.precompiles[addr] = &precompile{contract: p, rev: fromRev}
+	}
+}
+
 func getHashDefault(n uint64) (res evmc.Hash) {
 	hash := ethgo.Keccak256([]byte(big.NewInt(int64(n)).String()))
 	copy(res[:], hash)
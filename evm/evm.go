@@ -6,9 +6,52 @@ import (
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 )
 
+// Revisions after Shanghai are not defined in the evmc bindings. They extend
+// the evmc.Revision sequence so that the revision checks keep working.
+const (
+	// Cancun is the revision of the Cancun hard fork
+	Cancun evmc.Revision = evmc.Shanghai + 1
+
+	// Prague is the revision of the Prague hard fork
+	Prague evmc.Revision = Cancun + 1
+)
+
+// Host is the host of the evm. It extends the evmc host with the methods
+// required by the revisions not supported by the evmc bindings.
+type Host interface {
+	evmc.HostContext
+
+	// GetTransientStorage returns the transient storage of an account (eip-1153)
+	GetTransientStorage(addr evmc.Address, key evmc.Hash) evmc.Hash
+
+	// SetTransientStorage sets the transient storage of an account (eip-1153)
+	SetTransientStorage(addr evmc.Address, key evmc.Hash, value evmc.Hash)
+
+	// GetBlobTxContext returns the blob fields of the transaction context (eip-4844, eip-7516)
+	GetBlobTxContext() BlobTxContext
+
+	// GetRefund returns the gas refund counter of the transaction
+	GetRefund() uint64
+}
+
+// BlobTxContext is the blob context of the transaction
+type BlobTxContext struct {
+	BlobHashes  []evmc.Hash
+	BlobBaseFee evmc.Hash
+}
+
 type EVM struct {
-	Host evmc.HostContext
-	Rev  evmc.Revision
+	Host   Host
+	Rev    evmc.Revision
+	Tracer Tracer
+
+	// JumpdestCache caches the jumpdest analysis of the called contracts,
+	// the code is analyzed on every call if it is nil
+	JumpdestCache *JumpdestCache
+
+	// Interrupt aborts the execution with ErrExecutionAborted, it has to
+	// be shared by all the call frames of the execution
+	Interrupt *Interrupt
 }
 
 // Run implements the runtime interface
@@ -30,20 +73,37 @@ func (e *EVM) Run(typ evmc.CallKind, recipient evmc.Address, sender evmc.Address
 		s.Input = input
 	}
 
+	var codeHash evmc.Hash
 	if typ == evmc.Create || typ == evmc.Create2 {
 		// code creation
 		s.code = input
 	} else {
 		// code call
 		s.code = e.Host.GetCode(codeAddress)
+		if e.JumpdestCache != nil && len(s.code) != 0 {
+			codeHash = e.Host.GetCodeHash(codeAddress)
+		}
 	}
 
 	s.gas = uint64(gas)
 	s.host = e.Host
 	s.rev = e.Rev
-	s.bitmap.setCode(s.code)
+	s.setTracer(e.Tracer)
+	s.interrupt = e.Interrupt
+
+	if codeHash != (evmc.Hash{}) {
+		s.jumpdests = e.JumpdestCache.get(codeHash, s.code)
+	} else {
+		// the init code is not cached
+		s.bitmap.setCode(s.code)
+		s.jumpdests = &s.bitmap
+	}
 
 	ret, err := s.Run()
+	if e.Interrupt != nil && e.Interrupt.Aborted() {
+		// a sub call was aborted
+		err = ErrExecutionAborted
+	}
 
 	// We are probably doing this append magic to make sure that the slice doesn't have more capacity than it needs
 	var returnValue []byte
@@ -0,0 +1,500 @@
+package evm_test
+
+import (
+	"encoding/binary"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	state "github.com/umbracle/go-evm"
+	. "github.com/umbracle/go-evm/evm"
+)
+
+var (
+	benchSender   = evmc.Address{0x1}
+	benchContract = evmc.Address{0x2}
+)
+
+const (
+	dup2  = DUP1 + 1
+	dup3  = DUP1 + 2
+	dup4  = DUP1 + 3
+	swap2 = SWAP1 + 1
+)
+
+// benchState is an in-memory snapshot
+type benchState struct {
+	accounts map[evmc.Address]*state.Account
+	storage  map[evmc.Address]map[evmc.Hash]evmc.Hash
+}
+
+func newBenchState() *benchState {
+	return &benchState{
+		accounts: map[evmc.Address]*state.Account{},
+		storage:  map[evmc.Address]map[evmc.Hash]evmc.Hash{},
+	}
+}
+
+func (s *benchState) GetStorage(addr evmc.Address, root evmc.Hash, key evmc.Hash) evmc.Hash {
+	return s.storage[addr][key]
+}
+
+func (s *benchState) GetAccount(addr evmc.Address) (*state.Account, error) {
+	return s.accounts[addr], nil
+}
+
+func (s *benchState) setAccount(addr evmc.Address, balance *big.Int, code []byte) {
+	s.accounts[addr] = &state.Account{
+		Balance:  balance,
+		CodeHash: ethgo.Keccak256(code),
+		Code:     code,
+	}
+}
+
+func (s *benchState) setStorage(addr evmc.Address, key, val evmc.Hash) {
+	if _, ok := s.storage[addr]; !ok {
+		s.storage[addr] = map[evmc.Hash]evmc.Hash{}
+	}
+	s.storage[addr][key] = val
+}
+
+// program is a minimal assembler with labels for the benchmarks
+type program struct {
+	code   []byte
+	labels map[string]int
+	jumps  map[int]string
+}
+
+func newProgram() *program {
+	return &program{
+		labels: map[string]int{},
+		jumps:  map[int]string{},
+	}
+}
+
+func (p *program) op(ops ...OpCode) *program {
+	for _, op := range ops {
+		p.code = append(p.code, byte(op))
+	}
+	return p
+}
+
+func (p *program) push(v uint64) *program {
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, v)
+	for len(buf) > 1 && buf[0] == 0 {
+		buf = buf[1:]
+	}
+	return p.pushBytes(buf)
+}
+
+func (p *program) pushBytes(buf []byte) *program {
+	p.code = append(p.code, byte(PUSH1)+byte(len(buf)-1))
+	p.code = append(p.code, buf...)
+	return p
+}
+
+func (p *program) label(name string) *program {
+	p.labels[name] = len(p.code)
+	return p.op(JUMPDEST)
+}
+
+// pushLabel pushes the position of the label, i.e. a return address
+func (p *program) pushLabel(name string) *program {
+	p.jumps[len(p.code)+1] = name
+	return p.pushBytes([]byte{0, 0})
+}
+
+func (p *program) jumpTo(name string, op OpCode) *program {
+	return p.pushLabel(name).op(op)
+}
+
+func (p *program) jump(name string) *program {
+	return p.jumpTo(name, JUMP)
+}
+
+func (p *program) jumpi(name string) *program {
+	return p.jumpTo(name, JUMPI)
+}
+
+func (p *program) bytes() []byte {
+	for pos, name := range p.jumps {
+		binary.BigEndian.PutUint16(p.code[pos:], uint16(p.labels[name]))
+	}
+	return p.code
+}
+
+// loop repeats the body n times with the counter at the top of the stack
+func loop(n uint64, body func(p *program)) []byte {
+	p := newProgram()
+	p.push(n).label("loop")
+	body(p)
+	p.push(1).op(SWAP1, SUB, DUP1).jumpi("loop")
+	return p.op(STOP).bytes()
+}
+
+func benchmarkTransition(b *testing.B, st *benchState, to evmc.Address, input []byte) {
+	st.setAccount(benchSender, new(big.Int).Lsh(big.NewInt(1), 100), nil)
+
+	msg := &state.Message{
+		From:     benchSender,
+		To:       &to,
+		Gas:      30000000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+		Input:    input,
+	}
+
+	var gasUsed uint64
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		transition := state.NewTransition(state.WithState(st), state.WithRevision(Cancun))
+
+		output, err := transition.Write(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !output.Success {
+			b.Fatal("execution failed")
+		}
+		gasUsed += msg.Gas - output.GasLeft
+	}
+	elapsed := time.Since(start)
+
+	b.ReportMetric(float64(gasUsed)/elapsed.Seconds(), "gas/s")
+}
+
+func benchmarkCode(b *testing.B, code []byte) {
+	st := newBenchState()
+	st.setAccount(benchContract, big.NewInt(0), code)
+
+	benchmarkTransition(b, st, benchContract, nil)
+}
+
+func BenchmarkEVM_Loop(b *testing.B) {
+	benchmarkCode(b, loop(100000, func(p *program) {}))
+}
+
+func BenchmarkEVM_Arithmetic(b *testing.B) {
+	benchmarkCode(b, loop(20000, func(p *program) {
+		p.op(DUP1, DUP1, MUL, dup2, ADD, dup2, SWAP1, DIV)
+		p.push(7).op(dup3, MULMOD)
+		p.push(3).op(EXP, POP)
+	}))
+}
+
+func BenchmarkEVM_SignedArithmetic(b *testing.B) {
+	benchmarkCode(b, loop(20000, func(p *program) {
+		p.op(DUP1, PUSH0, SUB)
+		p.push(7).op(SWAP1, SDIV)
+		p.push(5).op(SWAP1, SMOD)
+		p.push(1).op(SIGNEXTEND)
+		p.push(2).op(SAR, POP)
+	}))
+}
+
+func BenchmarkEVM_WideArithmetic(b *testing.B) {
+	// the operands and the modulus use the 256 bits
+	x := ethgo.Keccak256([]byte{0x1})
+	m := ethgo.Keccak256([]byte{0x2})
+
+	benchmarkCode(b, loop(20000, func(p *program) {
+		p.pushBytes(m).op(DUP1).pushBytes(x).op(dup3, ADD, MULMOD)
+		p.pushBytes(m).op(SWAP1, DUP1, ADDMOD)
+		p.pushBytes(x).op(DIV)
+		p.pushBytes(x).op(EXP, POP)
+	}))
+}
+
+func BenchmarkEVM_Keccak(b *testing.B) {
+	benchmarkCode(b, loop(20000, func(p *program) {
+		p.op(DUP1).push(0).op(MSTORE)
+		p.push(64).push(0).op(SHA3, POP)
+	}))
+}
+
+func BenchmarkEVM_MemoryExpansion(b *testing.B) {
+	benchmarkCode(b, loop(5000, func(p *program) {
+		p.op(DUP1, DUP1).push(5).op(SHL, MSTORE)
+	}))
+}
+
+func BenchmarkEVM_SstoreChurn(b *testing.B) {
+	benchmarkCode(b, loop(10000, func(p *program) {
+		p.op(DUP1, DUP1).push(15).op(AND, SSTORE)
+	}))
+}
+
+func BenchmarkEVM_DeepCall(b *testing.B) {
+	// the contract calls itself until it runs out of depth or gas
+	p := newProgram()
+	p.push(0).push(0).push(0).push(0).push(0)
+	p.op(ADDRESS, GAS, CALL, STOP)
+
+	benchmarkCode(b, p.bytes())
+}
+
+var (
+	transferTopic = ethgo.Keccak256([]byte("Transfer(address,address,uint256)"))
+	approvalTopic = ethgo.Keccak256([]byte("Approval(address,address,uint256)"))
+)
+
+const (
+	// storage slots of the OpenZeppelin ERC20 contract
+	balancesSlot    = 0
+	allowancesSlot  = 1
+	totalSupplySlot = 2
+)
+
+// erc20 is the runtime code of the OpenZeppelin v5 ERC20 contract laid out
+// like the solc 0.8 output: free memory pointer, abi decoding with the
+// calldata size and address checks, the mappings hashed with keccak and the
+// internal functions called with a return address.
+func erc20() []byte {
+	p := newProgram()
+
+	p.push(0x80).push(0x40).op(MSTORE)
+
+	// all the functions are non payable
+	p.op(CALLVALUE, DUP1, ISZERO).jumpi("dispatch")
+	p.op(PUSH0, DUP1, REVERT)
+
+	p.label("dispatch")
+	p.op(POP)
+	p.push(4).op(CALLDATASIZE, LT).jumpi("revert")
+	p.op(PUSH0, CALLDATALOAD).push(0xe0).op(SHR)
+	for _, fn := range []struct {
+		selector uint64
+		name     string
+	}{
+		{0x095ea7b3, "approve"},
+		{0x18160ddd, "totalSupply"},
+		{0x23b872dd, "transferFrom"},
+		{0x70a08231, "balanceOf"},
+		{0xa9059cbb, "transfer"},
+		{0xdd62ed3e, "allowance"},
+	} {
+		p.op(DUP1).push(fn.selector).op(EQ).jumpi(fn.name)
+	}
+	p.label("revert")
+	p.op(PUSH0, DUP1, REVERT)
+
+	// totalSupply()
+	p.label("totalSupply")
+	p.push(totalSupplySlot).op(SLOAD)
+	returnUint(p)
+
+	// balanceOf(address account)
+	p.label("balanceOf")
+	checkCalldata(p, 0x20)
+	abiAddress(p, 4)
+	mapping(p, balancesSlot)
+	p.op(SLOAD)
+	returnUint(p)
+
+	// allowance(address owner, address spender)
+	p.label("allowance")
+	checkCalldata(p, 0x40)
+	abiAddress(p, 4)
+	mapping(p, allowancesSlot)
+	abiAddress(p, 36)
+	nestedMapping(p)
+	p.op(SLOAD)
+	returnUint(p)
+
+	// transfer(address to, uint256 value)
+	p.label("transfer")
+	checkCalldata(p, 0x40)
+	p.pushLabel("return true").op(CALLER)
+	abiAddress(p, 4)
+	p.push(36).op(CALLDATALOAD)
+	p.jump("_transfer")
+
+	// approve(address spender, uint256 value)
+	p.label("approve")
+	checkCalldata(p, 0x40)
+	p.pushLabel("return true").op(CALLER)
+	abiAddress(p, 4)
+	p.push(36).op(CALLDATALOAD)
+	p.jump("_approve")
+
+	// transferFrom(address from, address to, uint256 value)
+	p.label("transferFrom")
+	checkCalldata(p, 0x60)
+	p.pushLabel("return true")
+	abiAddress(p, 4)
+	abiAddress(p, 36)
+	p.push(68).op(CALLDATALOAD)
+
+	// _spendAllowance(from, msg.sender, value), the max allowance is not updated
+	// [ret, from, to, value]
+	p.op(dup3)
+	mapping(p, allowancesSlot)
+	p.op(CALLER)
+	nestedMapping(p)
+	p.op(DUP1, SLOAD)
+	p.op(DUP1, NOT, ISZERO).jumpi("infinite allowance")
+	p.op(dup3, dup2, LT).jumpi("insufficient allowance")
+	p.op(dup3, SWAP1, SUB, SWAP1, SSTORE)
+	p.jump("_transfer")
+	p.label("infinite allowance")
+	p.op(POP, POP)
+	p.jump("_transfer")
+
+	p.label("return true")
+	p.push(0x40).op(MLOAD).push(1).op(dup2, MSTORE)
+	p.push(0x20).op(SWAP1, RETURN)
+
+	// _transfer(from, to, value)
+	// [ret, from, to, value]
+	p.label("_transfer")
+	p.op(dup3, ISZERO).jumpi("invalid sender")
+	p.op(dup2, ISZERO).jumpi("invalid receiver")
+	p.op(dup3)
+	mapping(p, balancesSlot)
+	p.op(DUP1, SLOAD)
+	p.op(DUP1, dup4, GT).jumpi("insufficient balance")
+	p.op(dup3, SWAP1, SUB, SWAP1, SSTORE)
+	// the total supply bounds the balances, then the sum is unchecked
+	p.op(dup2)
+	mapping(p, balancesSlot)
+	p.op(DUP1, SLOAD, dup3, ADD, SWAP1, SSTORE)
+	emit(p, transferTopic)
+	p.op(JUMP)
+
+	// _approve(owner, spender, value)
+	// [ret, owner, spender, value]
+	p.label("_approve")
+	p.op(dup3, ISZERO).jumpi("invalid approver")
+	p.op(dup2, ISZERO).jumpi("invalid spender")
+	p.op(DUP1, dup4)
+	mapping(p, allowancesSlot)
+	p.op(dup4)
+	nestedMapping(p)
+	p.op(SSTORE)
+	emit(p, approvalTopic)
+	p.op(JUMP)
+
+	// the custom errors of the contract
+	for _, err := range []struct {
+		selector uint64
+		name     string
+	}{
+		{0xe450d38c, "insufficient balance"},
+		{0x96c6fd1e, "invalid sender"},
+		{0xec442f05, "invalid receiver"},
+		{0xfb8f41b2, "insufficient allowance"},
+		{0xe602df05, "invalid approver"},
+		{0x94280d62, "invalid spender"},
+	} {
+		p.label(err.name)
+		p.push(err.selector).op(PUSH0, MSTORE)
+		p.push(4).push(0x1c).op(REVERT)
+	}
+
+	return p.bytes()
+}
+
+// checkCalldata reverts if the arguments are shorter than size
+func checkCalldata(p *program, size uint64) {
+	p.push(size).push(4).op(CALLDATASIZE, SUB, SLT).jumpi("revert")
+}
+
+// abiAddress pushes the address argument at offset and reverts if
+// it has dirty upper bits
+func abiAddress(p *program, offset uint64) {
+	mask := make([]byte, 20)
+	for i := range mask {
+		mask[i] = 0xff
+	}
+	p.push(offset).op(CALLDATALOAD)
+	p.op(DUP1).pushBytes(mask).op(AND, dup2, EQ, ISZERO).jumpi("revert")
+}
+
+// mapping replaces the key at the top of the stack with its storage
+// slot in the mapping at slot
+func mapping(p *program, slot uint64) {
+	p.op(PUSH0, MSTORE)
+	p.push(slot).push(0x20).op(MSTORE)
+	p.push(0x40).op(PUSH0, SHA3)
+}
+
+// nestedMapping replaces the slot of a mapping and the key at the top
+// of the stack with the storage slot of the key
+func nestedMapping(p *program) {
+	p.op(PUSH0, MSTORE)
+	p.push(0x20).op(MSTORE)
+	p.push(0x40).op(PUSH0, SHA3)
+}
+
+// emit logs the [from, to, value] at the top of the stack as an event
+func emit(p *program, topic []byte) {
+	p.push(0x40).op(MLOAD, SWAP1, dup2, MSTORE)
+	p.op(SWAP1, swap2, SWAP1)
+	p.pushBytes(topic).op(SWAP1)
+	p.push(0x20).op(SWAP1, LOG3)
+}
+
+// returnUint returns the value at the top of the stack
+func returnUint(p *program) {
+	p.push(0x40).op(MLOAD, SWAP1, dup2, MSTORE)
+	p.push(0x20).op(SWAP1, RETURN)
+}
+
+// erc20Slot returns the storage slot of the key in the mapping at slot
+func erc20Slot(slot evmc.Hash, key evmc.Address) evmc.Hash {
+	var buf [64]byte
+	copy(buf[12:32], key[:])
+	copy(buf[32:], slot[:])
+	return evmc.Hash(ethgo.BytesToHash(ethgo.Keccak256(buf[:])))
+}
+
+func erc20Input(selector uint32, args ...[]byte) []byte {
+	input := make([]byte, 4, 4+32*len(args))
+	binary.BigEndian.PutUint32(input[0:4], selector)
+	for _, arg := range args {
+		input = append(input, make([]byte, 32-len(arg))...)
+		input = append(input, arg...)
+	}
+	return input
+}
+
+func newERC20State(holder evmc.Address) *benchState {
+	st := newBenchState()
+	st.setAccount(benchContract, big.NewInt(0), erc20())
+	st.setStorage(benchContract, evmc.Hash{31: totalSupplySlot}, evmc.Hash{31: 100})
+	st.setStorage(benchContract, erc20Slot(evmc.Hash{31: balancesSlot}, holder), evmc.Hash{31: 100})
+	return st
+}
+
+func BenchmarkEVM_ERC20Transfer(b *testing.B) {
+	st := newERC20State(benchSender)
+
+	to := evmc.Address{0x3}
+	benchmarkTransition(b, st, benchContract, erc20Input(0xa9059cbb, to[:], []byte{1}))
+}
+
+func BenchmarkEVM_ERC20Approve(b *testing.B) {
+	st := newERC20State(benchSender)
+
+	spender := evmc.Address{0x3}
+	benchmarkTransition(b, st, benchContract, erc20Input(0x095ea7b3, spender[:], []byte{1}))
+}
+
+func BenchmarkEVM_ERC20TransferFrom(b *testing.B) {
+	owner := evmc.Address{0x4}
+	st := newERC20State(owner)
+
+	// the sender spends the allowance of the owner
+	allowance := erc20Slot(erc20Slot(evmc.Hash{31: allowancesSlot}, owner), benchSender)
+	st.setStorage(benchContract, allowance, evmc.Hash{31: 10})
+
+	to := evmc.Address{0x3}
+	benchmarkTransition(b, st, benchContract, erc20Input(0x23b872dd, owner[:], to[:], []byte{1}))
+}
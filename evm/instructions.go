@@ -1,20 +1,20 @@
 package evm
 
 import (
-	"math/big"
-	"math/bits"
+	"math"
 	"sync"
 
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 	"github.com/umbracle/ethgo"
+	"github.com/umbracle/go-evm/evm/uint256"
 )
 
 type instruction func(c *state)
 
 var (
-	zero     = big.NewInt(0)
-	one      = big.NewInt(1)
-	wordSize = big.NewInt(32)
+	zero     = uint256.NewInt(0)
+	one      = uint256.NewInt(1)
+	wordSize = uint256.NewInt(32)
 )
 
 func opAdd(c *state) {
@@ -22,7 +22,6 @@ func opAdd(c *state) {
 	b := c.top()
 
 	b.Add(a, b)
-	toU256(b)
 }
 
 func opMul(c *state) {
@@ -30,7 +29,6 @@ func opMul(c *state) {
 	b := c.top()
 
 	b.Mul(a, b)
-	toU256(b)
 }
 
 func opSub(c *state) {
@@ -38,81 +36,34 @@ func opSub(c *state) {
 	b := c.top()
 
 	b.Sub(a, b)
-	toU256(b)
 }
 
 func opDiv(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		b.Div(a, b)
-		toU256(b)
-	}
+	b.Div(a, b)
 }
 
 func opSDiv(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
+	a := c.pop()
+	b := c.top()
 
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		neg := a.Sign() != b.Sign()
-		b.Div(a.Abs(a), b.Abs(b))
-		if neg {
-			b.Neg(b)
-		}
-		toU256(b)
-	}
+	b.SDiv(a, b)
 }
 
 func opMod(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		b.Mod(a, b)
-		toU256(b)
-	}
+	b.Mod(a, b)
 }
 
 func opSMod(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
-
-	if b.Sign() == 0 {
-		// division by zero
-		b.Set(zero)
-	} else {
-		neg := a.Sign() < 0
-		b.Mod(a.Abs(a), b.Abs(b))
-		if neg {
-			b.Neg(b)
-		}
-		toU256(b)
-	}
-}
-
-var bigPool = sync.Pool{
-	New: func() interface{} {
-		return new(big.Int)
-	},
-}
-
-func acquireBig() *big.Int {
-	return bigPool.Get().(*big.Int)
-}
+	a := c.pop()
+	b := c.top()
 
-func releaseBig(b *big.Int) {
-	bigPool.Put(b)
+	b.SMod(a, b)
 }
 
 func opExp(c *state) {
@@ -130,20 +81,7 @@ func opExp(c *state) {
 		return
 	}
 
-	z := acquireBig().Set(one)
-
-	// https://www.programminglogic.com/fast-exponentiation-algorithms/
-	for _, d := range y.Bits() {
-		for i := 0; i < _W; i++ {
-			if d&1 == 1 {
-				toU256(z.Mul(z, x))
-			}
-			d >>= 1
-			toU256(x.Mul(x, x))
-		}
-	}
-	y.Set(z)
-	releaseBig(z)
+	y.Exp(x, y)
 }
 
 func opAddMod(c *state) {
@@ -151,14 +89,7 @@ func opAddMod(c *state) {
 	b := c.pop()
 	z := c.top()
 
-	if z.Sign() == 0 {
-		// divison by zero
-		z.Set(zero)
-	} else {
-		a = a.Add(a, b)
-		z = z.Mod(a, z)
-		toU256(z)
-	}
+	z.AddMod(a, b, z)
 }
 
 func opMulMod(c *state) {
@@ -166,14 +97,7 @@ func opMulMod(c *state) {
 	b := c.pop()
 	z := c.top()
 
-	if z.Sign() == 0 {
-		// divison by zero
-		z.Set(zero)
-	} else {
-		a = a.Mul(a, b)
-		z = z.Mod(a, z)
-		toU256(z)
-	}
+	z.MulMod(a, b, z)
 }
 
 func opAnd(c *state) {
@@ -197,33 +121,23 @@ func opXor(c *state) {
 	b.Xor(a, b)
 }
 
-var opByteMask = big.NewInt(255)
-
 func opByte(c *state) {
 	x := c.pop()
 	y := c.top()
 
-	indx := x.Int64()
-	if indx > 31 {
-		y.Set(zero)
-	} else {
-		sh := (31 - indx) * 8
-		y.Rsh(y, uint(sh))
-		y.And(y, opByteMask)
-	}
+	y.Byte(x)
 }
 
 func opNot(c *state) {
 	a := c.top()
 
 	a.Not(a)
-	toU256(a)
 }
 
 func opIsZero(c *state) {
 	a := c.top()
 
-	if a.Sign() == 0 {
+	if a.IsZero() {
 		a.Set(one)
 	} else {
 		a.Set(zero)
@@ -234,7 +148,7 @@ func opEq(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if a.Cmp(b) == 0 {
+	if a.Eq(b) {
 		b.Set(one)
 	} else {
 		b.Set(zero)
@@ -245,7 +159,7 @@ func opLt(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if a.Cmp(b) < 0 {
+	if a.Lt(b) {
 		b.Set(one)
 	} else {
 		b.Set(zero)
@@ -256,7 +170,7 @@ func opGt(c *state) {
 	a := c.pop()
 	b := c.top()
 
-	if a.Cmp(b) > 0 {
+	if a.Gt(b) {
 		b.Set(one)
 	} else {
 		b.Set(zero)
@@ -264,10 +178,10 @@ func opGt(c *state) {
 }
 
 func opSlt(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
+	a := c.pop()
+	b := c.top()
 
-	if a.Cmp(b) < 0 {
+	if a.Slt(b) {
 		b.Set(one)
 	} else {
 		b.Set(zero)
@@ -275,10 +189,10 @@ func opSlt(c *state) {
 }
 
 func opSgt(c *state) {
-	a := to256(c.pop())
-	b := to256(c.top())
+	a := c.pop()
+	b := c.top()
 
-	if a.Cmp(b) > 0 {
+	if a.Sgt(b) {
 		b.Set(one)
 	} else {
 		b.Set(zero)
@@ -289,31 +203,10 @@ func opSignExtension(c *state) {
 	ext := c.pop()
 	x := c.top()
 
-	if ext.Cmp(wordSize) > 0 {
-		return
-	}
-	if x == nil {
-		return
-	}
-
-	bit := uint(ext.Uint64()*8 + 7)
-
-	mask := acquireBig().Set(one)
-	mask.Lsh(mask, bit)
-	mask.Sub(mask, one)
-
-	if x.Bit(int(bit)) > 0 {
-		mask.Not(mask)
-		x.Or(x, mask)
-	} else {
-		x.And(x, mask)
-	}
-
-	toU256(x)
-	releaseBig(mask)
+	x.ExtendSign(x, ext)
 }
 
-func equalOrOverflowsUint256(b *big.Int) bool {
+func equalOrOverflowsUint256(b *uint256.Int) bool {
 	return b.BitLen() > 8
 }
 
@@ -330,7 +223,6 @@ func opShl(c *state) {
 		value.Set(zero)
 	} else {
 		value.Lsh(value, uint(shift.Uint64()))
-		toU256(value)
 	}
 }
 
@@ -347,7 +239,6 @@ func opShr(c *state) {
 		value.Set(zero)
 	} else {
 		value.Rsh(value, uint(shift.Uint64()))
-		toU256(value)
 	}
 }
 
@@ -358,17 +249,12 @@ func opSar(c *state) {
 	}
 
 	shift := c.pop()
-	value := to256(c.top())
+	value := c.top()
 
 	if equalOrOverflowsUint256(shift) {
-		if value.Sign() >= 0 {
-			value.Set(zero)
-		} else {
-			value.Set(tt256m1)
-		}
+		value.SRsh(value, 256)
 	} else {
-		value.Rsh(value, uint(shift.Uint64()))
-		toU256(value)
+		value.SRsh(value, uint(shift.Uint64()))
 	}
 }
 
@@ -394,11 +280,6 @@ func opMload(c *state) {
 	c.push1().SetBytes(c.tmp)
 }
 
-var (
-	_W = bits.UintSize
-	_S = _W / 8
-)
-
 func opMStore(c *state) {
 	offset := c.pop()
 	val := c.pop()
@@ -408,25 +289,7 @@ func opMStore(c *state) {
 	}
 
 	o := offset.Uint64()
-	buf := c.memory[o : o+32]
-
-	i := 32
-
-	// convert big.int to bytes
-	// https://golang.org/src/math/big/nat.go#L1284
-	for _, d := range val.Bits() {
-		for j := 0; j < _S; j++ {
-			i--
-			buf[i] = byte(d)
-			d >>= 8
-		}
-	}
-
-	// fill the rest of the slot with zeros
-	for i > 0 {
-		i--
-		buf[i] = 0
-	}
+	val.PutBytes32(c.memory[o : o+32])
 }
 
 func opMStore8(c *state) {
@@ -439,13 +302,95 @@ func opMStore8(c *state) {
 	c.memory[offset.Uint64()] = byte(val.Uint64() & 0xff)
 }
 
+// eip-2929 access costs
+const (
+	coldAccountAccessCost uint64 = 2600
+	coldSloadCost         uint64 = 2100
+	warmStorageReadCost   uint64 = 100
+)
+
+// accessAccountCost returns the cost of accessing an account and marks it as warm (eip-2929)
+func (c *state) accessAccountCost(addr evmc.Address) uint64 {
+	if c.host.AccessAccount(addr) == evmc.ColdAccess {
+		return coldAccountAccessCost
+	}
+	return warmStorageReadCost
+}
+
+func opMCopy(c *state) {
+	if !c.isRevision(Cancun) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	dst := c.pop()
+	src := c.pop()
+	length := c.pop()
+
+	// expand the memory to cover both the source and the destination
+	offset := dst
+	if src.Cmp(dst) > 0 {
+		offset = src
+	}
+	if !c.checkMemory(offset, length) {
+		return
+	}
+
+	size := length.Uint64()
+	if !c.consumeGas(((size + 31) / 32) * copyGas) {
+		return
+	}
+
+	if size != 0 {
+		d, s := dst.Uint64(), src.Uint64()
+		copy(c.memory[d:d+size], c.memory[s:s+size])
+	}
+}
+
 // --- storage ---
 
+func opTload(c *state) {
+	if !c.isRevision(Cancun) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	loc := c.top()
+
+	val := c.host.GetTransientStorage(c.Address, loc.Bytes32())
+	loc.SetBytes(val[:])
+}
+
+func opTstore(c *state) {
+	if !c.isRevision(Cancun) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	if c.inStaticCall() {
+		c.exit(errWriteProtection)
+		return
+	}
+
+	key := c.popHash()
+	val := c.popHash()
+
+	c.host.SetTransientStorage(c.Address, key, val)
+}
+
 func opSload(c *state) {
 	loc := c.top()
+	key := evmc.Hash(loc.Bytes32())
 
 	var gas uint64
-	if c.isRevision(evmc.Istanbul) {
+	if c.isRevision(evmc.Berlin) {
+		// eip-2929
+		if c.host.AccessStorage(c.Address, key) == evmc.ColdAccess {
+			gas = coldSloadCost
+		} else {
+			gas = warmStorageReadCost
+		}
+	} else if c.isRevision(evmc.Istanbul) {
 		// eip-1884
 		gas = 800
 	} else if c.isRevision(evmc.TangerineWhistle) {
@@ -457,7 +402,7 @@ func opSload(c *state) {
 		return
 	}
 
-	val := c.host.GetStorage(c.Address, bigToHash(loc))
+	val := c.host.GetStorage(c.Address, key)
 	loc.SetBytes(val[:])
 }
 
@@ -477,38 +422,40 @@ func opSStore(c *state) {
 
 	legacyGasMetering := !c.isRevision(evmc.Istanbul) && (c.isRevision(evmc.Petersburg) || !c.isRevision(evmc.Constantinople))
 
-	status := c.host.SetStorage(c.Address, key, val)
 	cost := uint64(0)
+	if c.isRevision(evmc.Berlin) {
+		// eip-2929
+		if c.host.AccessStorage(c.Address, key) == evmc.ColdAccess {
+			cost = coldSloadCost
+		}
+	}
+
+	status := c.host.SetStorage(c.Address, key, val)
 
 	switch status {
-	case evmc.StorageUnchanged:
-		if c.isRevision(evmc.Istanbul) {
+	case evmc.StorageUnchanged, evmc.StorageModifiedAgain:
+		if c.isRevision(evmc.Berlin) {
+			// eip-2929
+			cost += warmStorageReadCost
+		} else if c.isRevision(evmc.Istanbul) {
 			// eip-2200
-			cost = 800
+			cost += 800
 		} else if legacyGasMetering {
-			cost = 5000
+			cost += 5000
 		} else {
-			cost = 200
+			cost += 200
 		}
 
-	case evmc.StorageModified:
-		cost = 5000
-
-	case evmc.StorageModifiedAgain:
-		if c.isRevision(evmc.Istanbul) {
-			// eip-2200
-			cost = 800
-		} else if legacyGasMetering {
-			cost = 5000
+	case evmc.StorageModified, evmc.StorageDeleted:
+		if c.isRevision(evmc.Berlin) {
+			// eip-2929
+			cost += 5000 - coldSloadCost
 		} else {
-			cost = 200
+			cost += 5000
 		}
 
 	case evmc.StorageAdded:
-		cost = 20000
-
-	case evmc.StorageDeleted:
-		cost = 5000
+		cost += 20000
 	}
 	if !c.consumeGas(cost) {
 		return
@@ -517,6 +464,14 @@ func opSStore(c *state) {
 
 const sha3WordGas uint64 = 6
 
+const (
+	// MaxInitCodeSize is the maximum size of the initcode of a contract creation (eip-3860)
+	MaxInitCodeSize = 2 * 24576
+
+	// initCodeWordGas is the cost per word of the initcode (eip-3860)
+	initCodeWordGas uint64 = 2
+)
+
 func opSha3(c *state) {
 	offset := c.pop()
 	length := c.pop()
@@ -549,7 +504,9 @@ func opBalance(c *state) {
 	addr, _ := c.popAddr()
 
 	var gas uint64
-	if c.isRevision(evmc.Istanbul) {
+	if c.isRevision(evmc.Berlin) {
+		gas = c.accessAccountCost(addr)
+	} else if c.isRevision(evmc.Istanbul) {
 		// eip-1884
 		gas = 700
 	} else if c.isRevision(evmc.TangerineWhistle) {
@@ -598,7 +555,7 @@ func opCaller(c *state) {
 func opCallValue(c *state) {
 	v := c.push1()
 	if value := c.Value; value != nil {
-		v.Set(value)
+		v.SetFromBig(value)
 	} else {
 		v.Set(zero)
 	}
@@ -633,7 +590,9 @@ func opExtCodeSize(c *state) {
 	addr, _ := c.popAddr()
 
 	var gas uint64
-	if c.isRevision(evmc.TangerineWhistle) {
+	if c.isRevision(evmc.Berlin) {
+		gas = c.accessAccountCost(addr)
+	} else if c.isRevision(evmc.TangerineWhistle) {
 		gas = 700
 	} else {
 		gas = 20
@@ -667,7 +626,9 @@ func opExtCodeHash(c *state) {
 	address, _ := c.popAddr()
 
 	var gas uint64
-	if c.isRevision(evmc.Istanbul) {
+	if c.isRevision(evmc.Berlin) {
+		gas = c.accessAccountCost(address)
+	} else if c.isRevision(evmc.Istanbul) {
 		gas = 700
 	} else {
 		gas = 400
@@ -694,7 +655,7 @@ func opGas(c *state) {
 	c.push1().SetUint64(c.gas)
 }
 
-func (c *state) setBytes(dst, input []byte, size uint64, dataOffset *big.Int) {
+func (c *state) setBytes(dst, input []byte, size uint64, dataOffset *uint256.Int) {
 	if !dataOffset.IsUint64() {
 		// overflow, copy 'size' 0 bytes to dst
 		for i := uint64(0); i < size; i++ {
@@ -736,7 +697,9 @@ func opExtCodeCopy(c *state) {
 	}
 
 	var gas uint64
-	if c.isRevision(evmc.TangerineWhistle) {
+	if c.isRevision(evmc.Berlin) {
+		gas = c.accessAccountCost(address)
+	} else if c.isRevision(evmc.TangerineWhistle) {
 		gas = 700
 	} else {
 		gas = 20
@@ -790,6 +753,10 @@ func opReturnDataCopy(c *state) {
 	}
 
 	end := length.Add(dataOffset, length)
+	if end.Lt(dataOffset) {
+		c.exit(errReturnDataOutOfBounds)
+		return
+	}
 	if !end.IsUint64() {
 		c.exit(errReturnDataOutOfBounds)
 		return
@@ -827,12 +794,12 @@ func opCodeCopy(c *state) {
 func opBlockHash(c *state) {
 	num := c.top()
 
-	if !num.IsInt64() {
+	if !num.IsUint64() || num.Uint64() > math.MaxInt64 {
 		num.Set(zero)
 		return
 	}
 
-	n := num.Int64()
+	n := int64(num.Uint64())
 	lastBlock := c.host.GetTxContext().Number
 
 	if lastBlock-257 < n && n < lastBlock {
@@ -849,11 +816,11 @@ func opCoinbase(c *state) {
 }
 
 func opTimestamp(c *state) {
-	c.push1().SetInt64(c.host.GetTxContext().Timestamp)
+	c.push1().SetUint64(uint64(c.host.GetTxContext().Timestamp))
 }
 
 func opNumber(c *state) {
-	c.push1().SetInt64(c.host.GetTxContext().Number)
+	c.push1().SetUint64(uint64(c.host.GetTxContext().Number))
 }
 
 func opDifficulty(c *state) {
@@ -862,7 +829,43 @@ func opDifficulty(c *state) {
 }
 
 func opGasLimit(c *state) {
-	c.push1().SetInt64(c.host.GetTxContext().GasLimit)
+	c.push1().SetUint64(uint64(c.host.GetTxContext().GasLimit))
+}
+
+func opBlobHash(c *state) {
+	if !c.isRevision(Cancun) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	index := c.top()
+
+	hashes := c.host.GetBlobTxContext().BlobHashes
+	if index.IsUint64() && index.Uint64() < uint64(len(hashes)) {
+		index.SetBytes(hashes[index.Uint64()][:])
+	} else {
+		index.Set(zero)
+	}
+}
+
+func opBlobBaseFee(c *state) {
+	if !c.isRevision(Cancun) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	blobBaseFee := c.host.GetBlobTxContext().BlobBaseFee
+	c.push1().SetBytes(blobBaseFee[:])
+}
+
+func opBaseFee(c *state) {
+	if !c.isRevision(evmc.London) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	baseFee := c.host.GetTxContext().BaseFee
+	c.push1().SetBytes(baseFee[:])
 }
 
 var zeroBalance = evmc.Hash{}
@@ -886,6 +889,10 @@ func opSelfDestruct(c *state) {
 			}
 		}
 	}
+	if c.isRevision(evmc.Berlin) && c.host.AccessAccount(address) == evmc.ColdAccess {
+		// eip-2929
+		gas += coldAccountAccessCost
+	}
 	if !c.consumeGas(gas) {
 		return
 	}
@@ -908,7 +915,7 @@ func opJumpi(c *state) {
 	dest := c.pop()
 	cond := c.pop()
 
-	if cond.Sign() != 0 {
+	if !cond.IsZero() {
 		if c.validJumpdest(dest) {
 			c.ip = int(dest.Uint64() - 1)
 		} else {
@@ -920,6 +927,15 @@ func opJumpi(c *state) {
 func opJumpDest(c *state) {
 }
 
+func opPush0(c *state) {
+	if !c.isRevision(evmc.Shanghai) {
+		c.exit(errOpCodeNotFound)
+		return
+	}
+
+	c.push1().Set(zero)
+}
+
 func opPush(n int) instruction {
 	return func(c *state) {
 		ins := c.code
@@ -975,7 +991,7 @@ func opLog(size int) instruction {
 
 		topics := make([]evmc.Hash, size)
 		for i := 0; i < size; i++ {
-			topics[i] = bigToHash(c.pop())
+			topics[i] = c.pop().Bytes32()
 		}
 
 		var ok bool
@@ -999,9 +1015,9 @@ func opStop(c *state) {
 	c.halt()
 }
 
-func (c *state) getBalance(addr evmc.Address) *big.Int {
+func (c *state) getBalance(addr evmc.Address) *uint256.Int {
 	raw := c.host.GetBalance(addr)
-	return new(big.Int).SetBytes(raw[:])
+	return new(uint256.Int).SetBytes(raw[:])
 }
 
 func opCreate(op OpCode) instruction {
@@ -1026,13 +1042,13 @@ func opCreate(op OpCode) instruction {
 		offset := c.pop()
 		length := c.pop()
 
-		var salt *big.Int
+		var salt *uint256.Int
 		if op == CREATE2 {
 			salt = c.pop()
 		}
 
 		// check if the value can be transfered
-		hasTransfer := value != nil && value.Sign() != 0
+		hasTransfer := value != nil && !value.IsZero()
 
 		// Both CREATE and CREATE2 use memory
 		var input []byte
@@ -1044,9 +1060,14 @@ func opCreate(op OpCode) instruction {
 			return
 		}
 
-		if hasTransfer {
-			if c.getBalance(c.Address).Cmp(value) < 0 {
-				c.push1().Set(zero)
+		if c.isRevision(evmc.Shanghai) {
+			// Limit and meter the initcode (eip-3860)
+			size := length.Uint64()
+			if size > MaxInitCodeSize {
+				c.exit(ErrMaxInitCodeSizeExceeded)
+				return
+			}
+			if !c.consumeGas(((size + 31) / 32) * initCodeWordGas) {
 				return
 			}
 		}
@@ -1060,6 +1081,13 @@ func opCreate(op OpCode) instruction {
 			}
 		}
 
+		if hasTransfer {
+			if c.getBalance(c.Address).Cmp(value) < 0 {
+				c.push1().Set(zero)
+				return
+			}
+		}
+
 		// Calculate and consume gas for the call
 		gas := c.gas
 
@@ -1072,6 +1100,7 @@ func opCreate(op OpCode) instruction {
 			c.push1().Set(zero)
 			return
 		}
+		c.captureState()
 
 		if c.Depth >= int(1024) {
 			c.push1().Set(zero)
@@ -1083,11 +1112,11 @@ func opCreate(op OpCode) instruction {
 
 		var saltHash evmc.Hash
 		if op == CREATE2 {
-			saltHash = bigToHash(salt)
+			saltHash = salt.Bytes32()
 		}
 		var valueHash evmc.Hash
 		if value != nil {
-			valueHash = bigToHash(value)
+			valueHash = value.Bytes32()
 		}
 		retValue, gasLeft, codeAddress, err := c.host.Call(callType, evmc.Address{}, c.Address, valueHash, input, int64(gas), c.Depth+1, false, saltHash, evmc.Address{})
 
@@ -1127,7 +1156,7 @@ func opCall(op OpCode) instruction {
 		c.resetReturnData()
 
 		if op == CALL && c.inStaticCall() {
-			if val := c.peekAt(3); val != nil && val.BitLen() > 0 {
+			if val := c.peekAt(3); val != nil && !val.IsZero() {
 				c.exit(errWriteProtection)
 				return
 			}
@@ -1148,7 +1177,7 @@ func opCall(op OpCode) instruction {
 		initialGas := c.pop()
 		addr, _ := c.popAddr()
 
-		var value *big.Int
+		var value *uint256.Int
 		if op == CALL || op == CALLCODE {
 			value = c.pop()
 		}
@@ -1172,14 +1201,16 @@ func opCall(op OpCode) instruction {
 		}
 
 		var gasCost uint64
-		if c.isRevision(evmc.TangerineWhistle) {
+		if c.isRevision(evmc.Berlin) {
+			gasCost = c.accessAccountCost(addr)
+		} else if c.isRevision(evmc.TangerineWhistle) {
 			gasCost = 700
 		} else {
 			gasCost = 40
 		}
 
 		// isTangerine := c.isRevision(evmc.SpuriousDragon)
-		transfersValue := (op == CALL || op == CALLCODE) && value != nil && value.Sign() != 0
+		transfersValue := (op == CALL || op == CALLCODE) && value != nil && !value.IsZero()
 
 		if op == CALL {
 			if (transfersValue || c.rev < evmc.SpuriousDragon) && !c.host.AccountExists(addr) {
@@ -1216,6 +1247,7 @@ func opCall(op OpCode) instruction {
 		if !c.consumeGas(gasCost) {
 			return
 		}
+		c.captureState()
 		if transfersValue {
 			gas += 2300
 		}
@@ -1232,7 +1264,7 @@ func opCall(op OpCode) instruction {
 		if op == CALLCODE || op == DELEGATECALL {
 			to = c.Address
 			if op == DELEGATECALL {
-				value = c.Value
+				value = new(uint256.Int).SetFromBig(c.Value)
 				caller = c.Caller
 			}
 		}
@@ -1256,7 +1288,7 @@ func opCall(op OpCode) instruction {
 
 		var valueHash evmc.Hash
 		if value != nil {
-			valueHash = bigToHash(value)
+			valueHash = value.Bytes32()
 		}
 		retValue, gasLeft, _, err := c.host.Call(callType, to, caller, valueHash, args, int64(gas), c.Depth+1, isStatic, [32]byte{}, codeAddress)
 
@@ -1299,22 +1331,3 @@ func opHalt(op OpCode) instruction {
 		}
 	}
 }
-
-var (
-	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)   // 2 ** 256
-	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1)) // 2 ** 256 - 1
-)
-
-func toU256(x *big.Int) *big.Int {
-	if x.Sign() < 0 || x.BitLen() > 256 {
-		x.And(x, tt256m1)
-	}
-	return x
-}
-
-func to256(x *big.Int) *big.Int {
-	if x.BitLen() > 255 {
-		x.Sub(x, tt256)
-	}
-	return x
-}
@@ -0,0 +1,60 @@
+package evm
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrExecutionAborted is returned when the execution is aborted from outside
+// either because its context is done or because it reached the step limit
+var ErrExecutionAborted = errors.New("execution aborted")
+
+// interruptCheckInterval is the number of steps between the checks of the
+// context, starting with the first one, since ctx.Err() is too expensive to
+// call for every opcode
+const interruptCheckInterval = 1024
+
+// Interrupt aborts an execution when its context is done or when the number
+// of executed opcodes reaches a limit. It is shared by all the call frames of
+// the execution and, once triggered, every frame aborts on its next step so
+// that the error is propagated up to the top level call.
+type Interrupt struct {
+	ctx     context.Context
+	limit   uint64
+	steps   uint64
+	aborted bool
+}
+
+// NewInterrupt creates an interrupt for the context with a limit of steps.
+// A zero limit does not limit the number of steps.
+func NewInterrupt(ctx context.Context, limit uint64) *Interrupt {
+	return &Interrupt{
+		ctx:   ctx,
+		limit: limit,
+	}
+}
+
+// Steps returns the number of opcodes executed
+func (i *Interrupt) Steps() uint64 {
+	return i.steps
+}
+
+// Aborted returns whether the execution was aborted
+func (i *Interrupt) Aborted() bool {
+	return i.aborted
+}
+
+// step counts one opcode and returns whether the execution has to be aborted
+func (i *Interrupt) step() bool {
+	if i.aborted {
+		return true
+	}
+	i.steps++
+
+	if i.limit != 0 && i.steps > i.limit {
+		i.aborted = true
+	} else if i.steps%interruptCheckInterval == 1 && i.ctx.Err() != nil {
+		i.aborted = true
+	}
+	return i.aborted
+}
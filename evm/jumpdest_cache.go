@@ -0,0 +1,92 @@
+package evm
+
+import (
+	"sync/atomic"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// DefaultJumpdestCacheSize is the number of bitmaps kept by the default cache
+const DefaultJumpdestCacheSize = 4096
+
+// DefaultJumpdestCache is a jumpdest cache that can be shared by all the
+// executions of the process
+var DefaultJumpdestCache = MustJumpdestCache(DefaultJumpdestCacheSize)
+
+// JumpdestCache is a concurrency-safe LRU cache of the jumpdest analysis
+// of the contracts keyed by their code hash. The cached bitmaps are shared
+// between the call frames and are never modified after they are created.
+type JumpdestCache struct {
+	// hits and misses are updated atomically and go first to keep them
+	// 64 bits aligned
+	hits   uint64
+	misses uint64
+
+	lru *lru.Cache
+}
+
+// NewJumpdestCache creates a jumpdest cache that holds up to size bitmaps
+func NewJumpdestCache(size int) (*JumpdestCache, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &JumpdestCache{lru: cache}, nil
+}
+
+// MustJumpdestCache creates a jumpdest cache and panics if the size is not valid
+func MustJumpdestCache(size int) *JumpdestCache {
+	cache, err := NewJumpdestCache(size)
+	if err != nil {
+		panic(err)
+	}
+	return cache
+}
+
+// get returns the bitmap of the code with the given hash and analyzes
+// the code if it is not cached
+func (j *JumpdestCache) get(hash evmc.Hash, code []byte) *bitmap {
+	if obj, ok := j.lru.Get(hash); ok {
+		atomic.AddUint64(&j.hits, 1)
+		return obj.(*bitmap)
+	}
+	atomic.AddUint64(&j.misses, 1)
+
+	b := new(bitmap)
+	b.setCode(code)
+	j.lru.Add(hash, b)
+	return b
+}
+
+// Purge removes all the bitmaps from the cache and resets the metrics
+func (j *JumpdestCache) Purge() {
+	j.lru.Purge()
+	atomic.StoreUint64(&j.hits, 0)
+	atomic.StoreUint64(&j.misses, 0)
+}
+
+// Metrics returns the usage metrics of the cache
+func (j *JumpdestCache) Metrics() JumpdestCacheMetrics {
+	return JumpdestCacheMetrics{
+		Hits:   atomic.LoadUint64(&j.hits),
+		Misses: atomic.LoadUint64(&j.misses),
+		Size:   j.lru.Len(),
+	}
+}
+
+// JumpdestCacheMetrics are the usage metrics of a jumpdest cache
+type JumpdestCacheMetrics struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// HitRate returns the ratio of lookups served from the cache
+func (m JumpdestCacheMetrics) HitRate() float64 {
+	total := m.Hits + m.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(m.Hits) / float64(total)
+}
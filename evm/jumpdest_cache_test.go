@@ -0,0 +1,58 @@
+package evm
+
+import (
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJumpdestCache(t *testing.T) {
+	// PUSH1 0x3 JUMP JUMPDEST STOP
+	code := []byte{0x60, 0x03, 0x56, 0x5B, 0x00}
+
+	cache, err := NewJumpdestCache(2)
+	assert.NoError(t, err)
+
+	b := cache.get(evmc.Hash{0x1}, code)
+	assert.True(t, b.isSet(3))
+	assert.False(t, b.isSet(1))
+
+	// the second lookup returns the same bitmap
+	assert.Same(t, b, cache.get(evmc.Hash{0x1}, code))
+
+	metrics := cache.Metrics()
+	assert.Equal(t, uint64(1), metrics.Hits)
+	assert.Equal(t, uint64(1), metrics.Misses)
+	assert.Equal(t, 0.5, metrics.HitRate())
+
+	// the least recently used bitmap is evicted
+	cache.get(evmc.Hash{0x2}, code)
+	cache.get(evmc.Hash{0x3}, code)
+	assert.Equal(t, 2, cache.Metrics().Size)
+	assert.NotSame(t, b, cache.get(evmc.Hash{0x1}, code))
+
+	cache.Purge()
+	assert.Equal(t, JumpdestCacheMetrics{}, cache.Metrics())
+	assert.Equal(t, float64(0), cache.Metrics().HitRate())
+
+	_, err = NewJumpdestCache(0)
+	assert.Error(t, err)
+}
+
+func TestJumpdestCache_ReleaseState(t *testing.T) {
+	// PUSH1 0x3 JUMP JUMPDEST STOP
+	code := []byte{0x60, 0x03, 0x56, 0x5B, 0x00}
+
+	cache := MustJumpdestCache(1)
+	b := cache.get(evmc.Hash{0x1}, code)
+
+	// releasing the state to the pool does not reset the shared bitmap
+	s := acquireState()
+	s.code = code
+	s.jumpdests = b
+	releaseState(s)
+
+	assert.Nil(t, s.jumpdests)
+	assert.True(t, b.isSet(3))
+}
@@ -161,6 +161,15 @@ const (
 	// SELFBALANCE returns the balance of the current account
 	SELFBALANCE = 0x47
 
+	// BASEFEE returns the base fee of the current block
+	BASEFEE = 0x48
+
+	// BLOBHASH returns the versioned hash of the ith blob of the transaction
+	BLOBHASH = 0x49
+
+	// BLOBBASEFEE returns the blob base fee of the current block
+	BLOBBASEFEE = 0x4A
+
 	// POP pops a (u)int256 off the stack and discards it
 	POP = 0x50
 
@@ -197,6 +206,18 @@ const (
 	// JUMPDEST corresponds to a possible jump destination
 	JUMPDEST = 0x5B
 
+	// TLOAD reads a (u)int256 from transient storage
+	TLOAD = 0x5C
+
+	// TSTORE writes a (u)int256 to transient storage
+	TSTORE = 0x5D
+
+	// MCOPY copies a memory area
+	MCOPY = 0x5E
+
+	// PUSH0 pushes a zero value onto the stack
+	PUSH0 = 0x5F
+
 	// PUSH1 pushes a 1-byte value onto the stack
 	PUSH1 = 0x60
 
@@ -320,6 +341,7 @@ var opCodeToString = map[OpCode]string{
 	MSIZE:          "MSIZE",
 	GAS:            "GAS",
 	JUMPDEST:       "JUMPDEST",
+	PUSH0:          "PUSH0",
 	CREATE:         "CREATE",
 	CALL:           "CALL",
 	RETURN:         "RETURN",
@@ -331,6 +353,12 @@ var opCodeToString = map[OpCode]string{
 	SELFDESTRUCT:   "SELFDESTRUCT",
 	CHAINID:        "CHAINID",
 	SELFBALANCE:    "SELFBALANCE",
+	BASEFEE:        "BASEFEE",
+	BLOBHASH:       "BLOBHASH",
+	BLOBBASEFEE:    "BLOBBASEFEE",
+	TLOAD:          "TLOAD",
+	TSTORE:         "TSTORE",
+	MCOPY:          "MCOPY",
 }
 
 func opCodesToString(from, to OpCode, str string) {
@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm/uint256"
 )
 
 var statePool = sync.Pool{
@@ -38,6 +39,10 @@ var (
 	errInvalidJump           = errors.New("invalid jump destination")
 	errOpCodeNotFound        = errors.New("opcode not found")
 	errReturnDataOutOfBounds = errors.New("return data out of bounds")
+
+	// ErrMaxInitCodeSizeExceeded is returned if the initcode of a contract
+	// creation is over MaxInitCodeSize (eip-3860)
+	ErrMaxInitCodeSizeExceeded = errors.New("max initcode size exceeded")
 )
 
 // Instructions is the code of instructions
@@ -47,7 +52,7 @@ type state struct {
 	code []byte
 	tmp  []byte
 
-	host evmc.HostContext
+	host Host
 
 	Address evmc.Address
 	Caller  evmc.Address
@@ -64,7 +69,7 @@ type state struct {
 	lastGasCost uint64
 
 	// stack
-	stack []*big.Int
+	stack []uint256.Int
 	sp    int
 
 	err  error
@@ -72,11 +77,19 @@ type state struct {
 
 	gas uint64
 
-	// bitvec bitvec
-	bitmap bitmap
+	// bitmap is the jumpdest analysis owned by the state. jumpdests
+	// points either to it or to a bitmap shared by the jumpdest cache
+	// that must not be modified.
+	bitmap    bitmap
+	jumpdests *bitmap
 
 	returnData []byte
 	ret        []byte
+
+	tracer Tracer
+	trace  traceStep
+
+	interrupt *Interrupt
 }
 
 func (c *state) isRevision(rev evmc.Revision) bool {
@@ -90,9 +103,13 @@ func (c *state) reset() {
 	c.lastGasCost = 0
 	c.stop = false
 	c.err = nil
+	c.tracer = nil
+	c.trace = traceStep{}
+	c.interrupt = nil
 
 	// reset bitmap
 	c.bitmap.reset()
+	c.jumpdests = nil
 
 	// reset memory
 	for i := range c.memory {
@@ -106,12 +123,12 @@ func (c *state) reset() {
 	c.memory = c.memory[:0]
 }
 
-func (c *state) validJumpdest(dest *big.Int) bool {
+func (c *state) validJumpdest(dest *uint256.Int) bool {
 	udest := dest.Uint64()
 	if dest.BitLen() >= 63 || udest >= uint64(len(c.code)) {
 		return false
 	}
-	return c.bitmap.isSet(uint(udest))
+	return c.jumpdests.isSet(uint(udest))
 }
 
 func (c *state) halt() {
@@ -126,25 +143,24 @@ func (c *state) exit(err error) {
 	c.err = err
 }
 
-func (c *state) push1() *big.Int {
-	if len(c.stack) > c.sp {
-		c.sp++
-		return c.stack[c.sp-1]
+func (c *state) push1() *uint256.Int {
+	if len(c.stack) == c.sp {
+		if c.stack == nil {
+			// one more slot for the push that overflows the stack
+			c.stack = make([]uint256.Int, 0, stackSize+1)
+		}
+		c.stack = append(c.stack, uint256.Int{})
 	}
-	v := big.NewInt(0)
-	c.stack = append(c.stack, v)
 	c.sp++
-	return v
+	return &c.stack[c.sp-1]
 }
 
 func (c *state) stackAtLeast(n int) bool {
 	return c.sp >= n
 }
 
-func (c *state) popHash() (hash evmc.Hash) {
-	buf := c.pop().Bytes()
-	copy(hash[:], leftPadBytes(buf, 32))
-	return
+func (c *state) popHash() evmc.Hash {
+	return c.pop().Bytes32()
 }
 
 func (c *state) popAddr() (evmc.Address, bool) {
@@ -154,28 +170,29 @@ func (c *state) popAddr() (evmc.Address, bool) {
 	}
 
 	var addr evmc.Address
-	copy(addr[:], leftPadBytes(b.Bytes(), 20))
+	buf := b.Bytes32()
+	copy(addr[:], buf[12:])
 	return addr, true
 }
 
-func (c *state) top() *big.Int {
+func (c *state) top() *uint256.Int {
 	if c.sp == 0 {
 		return nil
 	}
-	return c.stack[c.sp-1]
+	return &c.stack[c.sp-1]
 }
 
-func (c *state) pop() *big.Int {
+func (c *state) pop() *uint256.Int {
 	if c.sp == 0 {
 		return nil
 	}
-	o := c.stack[c.sp-1]
+	o := &c.stack[c.sp-1]
 	c.sp--
 	return o
 }
 
-func (c *state) peekAt(n int) *big.Int {
-	return c.stack[c.sp-n]
+func (c *state) peekAt(n int) *uint256.Int {
+	return &c.stack[c.sp-n]
 }
 
 func (c *state) swap(n int) {
@@ -206,31 +223,55 @@ func (c *state) Run() ([]byte, error) {
 			c.halt()
 			break
 		}
+		if c.interrupt != nil && c.interrupt.step() {
+			c.exit(ErrExecutionAborted)
+			break
+		}
 
 		op := OpCode(c.code[c.ip])
+		if c.tracer != nil {
+			c.traceStep(op)
+		}
 
 		inst := dispatchTable[op]
 		if inst.inst == nil {
 			c.exit(errOpCodeNotFound)
+			if c.tracer != nil {
+				c.captureFault(0)
+			}
 			break
 		}
 		// check if the depth of the stack is enough for the instruction
 		if c.sp < inst.stack {
 			c.exit(errStackUnderflow)
+			if c.tracer != nil {
+				c.captureFault(inst.gas)
+			}
 			break
 		}
 		// consume the gas of the instruction
 		if !c.consumeGas(inst.gas) {
 			c.exit(errOutOfGas)
+			if c.tracer != nil {
+				c.captureFault(inst.gas)
+			}
 			break
 		}
 
 		// execute the instruction
 		inst.inst(c)
+		if c.tracer != nil {
+			c.captureState()
+		}
 
 		// check if stack size exceeds the max size
 		if c.sp > stackSize {
 			c.exit(errStackOverflow)
+		}
+		if c.err != nil {
+			if c.tracer != nil {
+				c.captureFault(c.trace.gas - c.gas)
+			}
 			break
 		}
 		c.ip++
@@ -246,17 +287,12 @@ func (c *state) inStaticCall() bool {
 	return c.Static
 }
 
-func bigToHash(b *big.Int) (res evmc.Hash) {
-	copy(res[:], leftPadBytes(b.Bytes(), 32))
-	return
-}
-
 func (c *state) Len() int {
 	return len(c.memory)
 }
 
-func (c *state) checkMemory(offset, size *big.Int) bool {
-	if size.Sign() == 0 {
+func (c *state) checkMemory(offset, size *uint256.Int) bool {
+	if size.IsZero() {
 		return true
 	}
 
@@ -301,8 +337,8 @@ func extendByteSlice(b []byte, needLen int) []byte {
 	return b[:needLen]
 }
 
-func (c *state) get2(dst []byte, offset, length *big.Int) ([]byte, bool) {
-	if length.Sign() == 0 {
+func (c *state) get2(dst []byte, offset, length *uint256.Int) ([]byte, bool) {
+	if length.IsZero() {
 		return nil, true
 	}
 
@@ -329,13 +365,3 @@ func (c *state) Show() string {
 	}
 	return strings.Join(str, "\n")
 }
-
-func leftPadBytes(b []byte, size int) []byte {
-	if len(b) <= size {
-		// fill up to 32 bytes
-		b = append(make([]byte, size-len(b)), b...)
-	} else {
-		b = b[len(b)-size:]
-	}
-	return b
-}
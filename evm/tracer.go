@@ -0,0 +1,125 @@
+package evm
+
+import (
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+)
+
+// Tracer receives the events of an execution. The stack and the memory of
+// CaptureState are only set for a StepTracer.
+type Tracer interface {
+	// CaptureStart is called before the execution of the top level call
+	// with the host that runs it
+	CaptureStart(host Host, from, to evmc.Address, create bool, input []byte, gas uint64, value *big.Int)
+
+	// CaptureState is called for each executed opcode with the state before
+	// its execution and the total gas it consumed
+	CaptureState(pc uint64, op OpCode, gas, cost, refund uint64, stack []*big.Int, memory []byte, memSize int, depth int)
+
+	// CaptureFault is called after the CaptureState of an opcode that fails
+	CaptureFault(pc uint64, op OpCode, gas, cost uint64, depth int, err error)
+
+	// CaptureEnter is called before the execution of a sub call. Like in
+	// evmc, a STATICCALL is a Call that is static while its caller is not.
+	CaptureEnter(typ evmc.CallKind, from, to evmc.Address, input []byte, gas uint64, value *big.Int, static bool)
+
+	// CaptureExit is called after the execution of a sub call
+	CaptureExit(output []byte, gasUsed uint64, err error)
+
+	// CaptureEnd is called after the execution of the top level call
+	CaptureEnd(output []byte, gasUsed uint64, err error)
+
+	// CaptureSelfDestruct is called when the account sends its balance
+	// to the beneficiary with a SELFDESTRUCT
+	CaptureSelfDestruct(addr, beneficiary evmc.Address, balance *big.Int)
+}
+
+// TxTracer is a Tracer that also receives the gas used by the transaction
+type TxTracer interface {
+	Tracer
+
+	// CaptureTxEnd is called after the refund of the transaction with its
+	// gas used, including the intrinsic gas
+	CaptureTxEnd(gasUsed uint64)
+}
+
+// StepTracer is a Tracer that receives the stack and the memory of the
+// steps. Since they are copied before each opcode, only the ones asked by
+// the tracer are passed to CaptureState, as copies owned by the tracer.
+type StepTracer interface {
+	Tracer
+
+	// CaptureStack and CaptureMemory return whether the tracer receives
+	// the stack and the memory
+	CaptureStack() bool
+	CaptureMemory() bool
+}
+
+// traceStep is the state of the opcode being traced
+type traceStep struct {
+	pending bool
+	pc      uint64
+	op      OpCode
+	gas     uint64
+	refund  uint64
+	stack   []*big.Int
+	memory  []byte
+	memSize int
+
+	// captureStack and captureMemory are set when the tracer asks for
+	// the stack and the memory
+	captureStack  bool
+	captureMemory bool
+}
+
+// setTracer sets the tracer of the execution and the copies it asks for
+func (c *state) setTracer(tracer Tracer) {
+	c.tracer = tracer
+	if t, ok := tracer.(StepTracer); ok {
+		c.trace.captureStack = t.CaptureStack()
+		c.trace.captureMemory = t.CaptureMemory()
+	}
+}
+
+// traceStep snapshots the state before the execution of the opcode
+func (c *state) traceStep(op OpCode) {
+	c.trace.pending = true
+	c.trace.pc = uint64(c.ip)
+	c.trace.op = op
+	c.trace.gas = c.gas
+	c.trace.refund = c.host.GetRefund()
+	c.trace.memSize = len(c.memory)
+
+	c.trace.stack = nil
+	if c.trace.captureStack {
+		c.trace.stack = make([]*big.Int, c.sp)
+		for i := 0; i < c.sp; i++ {
+			c.trace.stack[i] = c.stack[i].ToBig()
+		}
+	}
+	c.trace.memory = nil
+	if c.trace.captureMemory {
+		c.trace.memory = append([]byte{}, c.memory...)
+	}
+}
+
+// captureFault reports the failure of the opcode, with its step if the
+// opcode fails before its execution
+func (c *state) captureFault(cost uint64) {
+	if c.trace.pending {
+		c.trace.pending = false
+		c.tracer.CaptureState(c.trace.pc, c.trace.op, c.trace.gas, cost, c.trace.refund, c.trace.stack, c.trace.memory, c.trace.memSize, c.Depth)
+	}
+	c.tracer.CaptureFault(c.trace.pc, c.trace.op, c.trace.gas, cost, c.Depth, c.err)
+}
+
+// captureState reports the pending step to the tracer. The calls report it
+// once their gas is charged so that the step comes before the sub call.
+func (c *state) captureState() {
+	if !c.trace.pending {
+		return
+	}
+	c.trace.pending = false
+	c.tracer.CaptureState(c.trace.pc, c.trace.op, c.trace.gas, c.trace.gas-c.gas, c.trace.refund, c.trace.stack, c.trace.memory, c.trace.memSize, c.Depth)
+}
@@ -0,0 +1,316 @@
+package uint256
+
+import "math/bits"
+
+// Add sets z to x + y modulo 2^256
+func (z *Int) Add(x, y *Int) *Int {
+	var carry uint64
+	z[0], carry = bits.Add64(x[0], y[0], 0)
+	z[1], carry = bits.Add64(x[1], y[1], carry)
+	z[2], carry = bits.Add64(x[2], y[2], carry)
+	z[3], _ = bits.Add64(x[3], y[3], carry)
+	return z
+}
+
+// Sub sets z to x - y modulo 2^256
+func (z *Int) Sub(x, y *Int) *Int {
+	var borrow uint64
+	z[0], borrow = bits.Sub64(x[0], y[0], 0)
+	z[1], borrow = bits.Sub64(x[1], y[1], borrow)
+	z[2], borrow = bits.Sub64(x[2], y[2], borrow)
+	z[3], _ = bits.Sub64(x[3], y[3], borrow)
+	return z
+}
+
+// Neg sets z to -x modulo 2^256
+func (z *Int) Neg(x *Int) *Int {
+	return z.Sub(new(Int), x)
+}
+
+// Abs sets z to the absolute value of x as a signed integer
+func (z *Int) Abs(x *Int) *Int {
+	if x[3]>>63 == 1 {
+		return z.Neg(x)
+	}
+	return z.Set(x)
+}
+
+// Mul sets z to x * y modulo 2^256
+func (z *Int) Mul(x, y *Int) *Int {
+	var res Int
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; i+j < 4; j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			lo, c := bits.Add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			res[i+j] = lo
+			carry = hi
+		}
+	}
+	*z = res
+	return z
+}
+
+// umul returns the full 512 bits product of x and y
+func umul(x, y *Int) (res [8]uint64) {
+	for i := 0; i < 4; i++ {
+		var carry uint64
+		for j := 0; j < 4; j++ {
+			hi, lo := bits.Mul64(x[i], y[j])
+			lo, c := bits.Add64(lo, res[i+j], 0)
+			hi += c
+			lo, c = bits.Add64(lo, carry, 0)
+			hi += c
+			res[i+j] = lo
+			carry = hi
+		}
+		res[i+4] = carry
+	}
+	return
+}
+
+// Div sets z to x / y, or 0 if y is 0
+func (z *Int) Div(x, y *Int) *Int {
+	if y.IsZero() || y.Gt(x) {
+		return z.Clear()
+	}
+	if x.Eq(y) {
+		return z.SetUint64(1)
+	}
+	if x.IsUint64() {
+		return z.SetUint64(x[0] / y[0])
+	}
+
+	var quot Int
+	udivrem(quot[:], x[:], y)
+	*z = quot
+	return z
+}
+
+// Mod sets z to x % y, or 0 if y is 0
+func (z *Int) Mod(x, y *Int) *Int {
+	if y.IsZero() || x.Eq(y) {
+		return z.Clear()
+	}
+	if x.Lt(y) {
+		return z.Set(x)
+	}
+	if x.IsUint64() {
+		return z.SetUint64(x[0] % y[0])
+	}
+
+	var quot Int
+	*z = udivrem(quot[:], x[:], y)
+	return z
+}
+
+// SDiv sets z to x / y as signed integers rounded to zero, or 0 if y is 0
+func (z *Int) SDiv(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := x.Sign()*y.Sign() < 0
+
+	var a, b Int
+	z.Div(a.Abs(x), b.Abs(y))
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// SMod sets z to x % y as signed integers with the sign of x, or 0 if y is 0
+func (z *Int) SMod(x, y *Int) *Int {
+	if y.IsZero() {
+		return z.Clear()
+	}
+	neg := x.Sign() < 0
+
+	var a, b Int
+	z.Mod(a.Abs(x), b.Abs(y))
+	if neg {
+		z.Neg(z)
+	}
+	return z
+}
+
+// AddMod sets z to (x + y) % m without overflow, or 0 if m is 0
+func (z *Int) AddMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+
+	var sum [5]uint64
+	var carry uint64
+	for i := 0; i < 4; i++ {
+		sum[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	sum[4] = carry
+
+	var quot [5]uint64
+	*z = udivrem(quot[:], sum[:], m)
+	return z
+}
+
+// MulMod sets z to (x * y) % m without overflow, or 0 if m is 0
+func (z *Int) MulMod(x, y, m *Int) *Int {
+	if m.IsZero() {
+		return z.Clear()
+	}
+
+	p := umul(x, y)
+
+	var quot [8]uint64
+	*z = udivrem(quot[:], p[:], m)
+	return z
+}
+
+// Exp sets z to base^exponent modulo 2^256
+func (z *Int) Exp(base, exponent *Int) *Int {
+	res := Int{1}
+	b := *base
+
+	n := exponent.BitLen()
+	for i := 0; i < n; i++ {
+		if (exponent[i/64]>>(i%64))&1 == 1 {
+			res.Mul(&res, &b)
+		}
+		b.Mul(&b, &b)
+	}
+	*z = res
+	return z
+}
+
+// udivrem divides u by d, writes the quotient in quot and returns the remainder.
+// quot must be at least as long as u and d must not be zero.
+func udivrem(quot, u []uint64, d *Int) (rem Int) {
+	dLen := 0
+	for i := 3; i >= 0; i-- {
+		if d[i] != 0 {
+			dLen = i + 1
+			break
+		}
+	}
+
+	uLen := 0
+	for i := len(u) - 1; i >= 0; i-- {
+		if u[i] != 0 {
+			uLen = i + 1
+			break
+		}
+	}
+	if uLen < dLen {
+		copy(rem[:], u)
+		return rem
+	}
+
+	// normalize the divisor so that its top bit is set
+	shift := uint(bits.LeadingZeros64(d[dLen-1]))
+
+	var dnStorage Int
+	dn := dnStorage[:dLen]
+	for i := dLen - 1; i > 0; i-- {
+		dn[i] = d[i]<<shift | d[i-1]>>(64-shift)
+	}
+	dn[0] = d[0] << shift
+
+	var unStorage [9]uint64
+	un := unStorage[:uLen+1]
+	un[uLen] = u[uLen-1] >> (64 - shift)
+	for i := uLen - 1; i > 0; i-- {
+		un[i] = u[i]<<shift | u[i-1]>>(64-shift)
+	}
+	un[0] = u[0] << shift
+
+	if dLen == 1 {
+		r := udivremBy1(quot, un, dn[0])
+		return *rem.SetUint64(r >> shift)
+	}
+
+	udivremKnuth(quot, un, dn)
+
+	for i := 0; i < dLen-1; i++ {
+		rem[i] = un[i]>>shift | un[i+1]<<(64-shift)
+	}
+	rem[dLen-1] = un[dLen-1] >> shift
+	return rem
+}
+
+// udivremBy1 divides the normalized u by a single limb d
+func udivremBy1(quot, u []uint64, d uint64) (rem uint64) {
+	rem = u[len(u)-1]
+	for j := len(u) - 2; j >= 0; j-- {
+		quot[j], rem = bits.Div64(rem, u[j], d)
+	}
+	return rem
+}
+
+// udivremKnuth is the algorithm D of Knuth for the normalized u and d.
+// The remainder is left in the lower limbs of u.
+func udivremKnuth(quot, u, d []uint64) {
+	dh := d[len(d)-1]
+	dl := d[len(d)-2]
+
+	for j := len(u) - len(d) - 1; j >= 0; j-- {
+		u2 := u[j+len(d)]
+		u1 := u[j+len(d)-1]
+		u0 := u[j+len(d)-2]
+
+		// estimate the quotient digit with the top limbs
+		var qhat, rhat uint64
+		refine := true
+		if u2 >= dh {
+			qhat = ^uint64(0)
+			var carry uint64
+			rhat, carry = bits.Add64(u1, dh, 0)
+			refine = carry == 0
+		} else {
+			qhat, rhat = bits.Div64(u2, u1, dh)
+		}
+		for refine {
+			ph, pl := bits.Mul64(qhat, dl)
+			if ph < rhat || (ph == rhat && pl <= u0) {
+				break
+			}
+			qhat--
+
+			var carry uint64
+			rhat, carry = bits.Add64(rhat, dh, 0)
+			refine = carry == 0
+		}
+
+		// multiply and subtract, the estimate is at most one too big
+		borrow := subMulTo(u[j:j+len(d)], d, qhat)
+		u[j+len(d)] = u2 - borrow
+		if u2 < borrow {
+			qhat--
+			u[j+len(d)] += addTo(u[j:j+len(d)], d)
+		}
+		quot[j] = qhat
+	}
+}
+
+// subMulTo computes x -= y * multiplier and returns the borrow
+func subMulTo(x, y []uint64, multiplier uint64) uint64 {
+	var borrow uint64
+	for i := 0; i < len(y); i++ {
+		s, carry1 := bits.Sub64(x[i], borrow, 0)
+		ph, pl := bits.Mul64(y[i], multiplier)
+		t, carry2 := bits.Sub64(s, pl, 0)
+		x[i] = t
+		borrow = ph + carry1 + carry2
+	}
+	return borrow
+}
+
+// addTo computes x += y and returns the carry
+func addTo(x, y []uint64) uint64 {
+	var carry uint64
+	for i := 0; i < len(y); i++ {
+		x[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	return carry
+}
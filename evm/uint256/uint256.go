@@ -0,0 +1,279 @@
+// Package uint256 implements the 256 bits unsigned integers of the evm
+// with four 64 bits limbs.
+package uint256
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+)
+
+// Int is a 256 bits unsigned integer in little endian order of limbs.
+// The signed operations use the two's complement representation.
+type Int [4]uint64
+
+// NewInt returns a new Int set to the value of x
+func NewInt(x uint64) *Int {
+	return &Int{x}
+}
+
+// Clear sets z to 0
+func (z *Int) Clear() *Int {
+	z[0], z[1], z[2], z[3] = 0, 0, 0, 0
+	return z
+}
+
+// Set sets z to x
+func (z *Int) Set(x *Int) *Int {
+	*z = *x
+	return z
+}
+
+// SetUint64 sets z to x
+func (z *Int) SetUint64(x uint64) *Int {
+	z[0], z[1], z[2], z[3] = x, 0, 0, 0
+	return z
+}
+
+// SetBytes sets z to the big endian value of buf. Only the
+// last 32 bytes are used if buf is bigger.
+func (z *Int) SetBytes(buf []byte) *Int {
+	if len(buf) > 32 {
+		buf = buf[len(buf)-32:]
+	}
+	z.Clear()
+
+	for i := 0; len(buf) > 0; i++ {
+		n := len(buf)
+		if n > 8 {
+			n = 8
+		}
+		var limb uint64
+		for _, b := range buf[len(buf)-n:] {
+			limb = limb<<8 | uint64(b)
+		}
+		z[i] = limb
+		buf = buf[:len(buf)-n]
+	}
+	return z
+}
+
+// SetFromBig sets z to x modulo 2^256
+func (z *Int) SetFromBig(x *big.Int) *Int {
+	z.SetBytes(x.Bytes())
+	if x.Sign() < 0 {
+		z.Neg(z)
+	}
+	return z
+}
+
+// ToBig returns z as a big.Int
+func (z *Int) ToBig() *big.Int {
+	buf := z.Bytes32()
+	return new(big.Int).SetBytes(buf[:])
+}
+
+// Bytes returns z as big endian bytes without leading zeros
+func (z *Int) Bytes() []byte {
+	buf := z.Bytes32()
+	return buf[32-(z.BitLen()+7)/8:]
+}
+
+// Bytes32 returns z as 32 big endian bytes
+func (z *Int) Bytes32() (res [32]byte) {
+	z.PutBytes32(res[:])
+	return
+}
+
+// PutBytes32 writes z as 32 big endian bytes in dst
+func (z *Int) PutBytes32(dst []byte) {
+	binary.BigEndian.PutUint64(dst[0:8], z[3])
+	binary.BigEndian.PutUint64(dst[8:16], z[2])
+	binary.BigEndian.PutUint64(dst[16:24], z[1])
+	binary.BigEndian.PutUint64(dst[24:32], z[0])
+}
+
+// Uint64 returns the lower 64 bits of z
+func (z *Int) Uint64() uint64 {
+	return z[0]
+}
+
+// IsUint64 returns whether z fits in 64 bits
+func (z *Int) IsUint64() bool {
+	return z[1]|z[2]|z[3] == 0
+}
+
+// IsZero returns whether z is 0
+func (z *Int) IsZero() bool {
+	return z[0]|z[1]|z[2]|z[3] == 0
+}
+
+// Sign returns the sign of z as a signed integer
+func (z *Int) Sign() int {
+	if z.IsZero() {
+		return 0
+	}
+	if z[3]>>63 == 1 {
+		return -1
+	}
+	return 1
+}
+
+// BitLen returns the number of bits required to represent z
+func (z *Int) BitLen() int {
+	for i := 3; i >= 0; i-- {
+		if z[i] != 0 {
+			return i*64 + bits.Len64(z[i])
+		}
+	}
+	return 0
+}
+
+// Cmp compares z and x and returns -1, 0 or 1
+func (z *Int) Cmp(x *Int) int {
+	for i := 3; i >= 0; i-- {
+		if z[i] < x[i] {
+			return -1
+		}
+		if z[i] > x[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+// Eq returns whether z == x
+func (z *Int) Eq(x *Int) bool {
+	return *z == *x
+}
+
+// Lt returns whether z < x
+func (z *Int) Lt(x *Int) bool {
+	return z.Cmp(x) < 0
+}
+
+// Gt returns whether z > x
+func (z *Int) Gt(x *Int) bool {
+	return z.Cmp(x) > 0
+}
+
+// Slt returns whether z < x as signed integers
+func (z *Int) Slt(x *Int) bool {
+	zNeg, xNeg := z[3]>>63 == 1, x[3]>>63 == 1
+	if zNeg != xNeg {
+		return zNeg
+	}
+	return z.Lt(x)
+}
+
+// Sgt returns whether z > x as signed integers
+func (z *Int) Sgt(x *Int) bool {
+	zNeg, xNeg := z[3]>>63 == 1, x[3]>>63 == 1
+	if zNeg != xNeg {
+		return xNeg
+	}
+	return z.Gt(x)
+}
+
+// And sets z to x & y
+func (z *Int) And(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]&y[0], x[1]&y[1], x[2]&y[2], x[3]&y[3]
+	return z
+}
+
+// Or sets z to x | y
+func (z *Int) Or(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]|y[0], x[1]|y[1], x[2]|y[2], x[3]|y[3]
+	return z
+}
+
+// Xor sets z to x ^ y
+func (z *Int) Xor(x, y *Int) *Int {
+	z[0], z[1], z[2], z[3] = x[0]^y[0], x[1]^y[1], x[2]^y[2], x[3]^y[3]
+	return z
+}
+
+// Not sets z to ^x
+func (z *Int) Not(x *Int) *Int {
+	z[0], z[1], z[2], z[3] = ^x[0], ^x[1], ^x[2], ^x[3]
+	return z
+}
+
+// Byte sets z to the n-th big endian byte of z, or 0 if n >= 32
+func (z *Int) Byte(n *Int) *Int {
+	if !n.IsUint64() || n[0] >= 32 {
+		return z.Clear()
+	}
+	limb := z[3-n[0]/8]
+	shift := 56 - (n[0]%8)*8
+	return z.SetUint64((limb >> shift) & 0xff)
+}
+
+// Lsh sets z to x << n
+func (z *Int) Lsh(x *Int, n uint) *Int {
+	if n >= 256 {
+		return z.Clear()
+	}
+	limbs, shift := n/64, n%64
+
+	var res Int
+	for i := 3; i >= int(limbs); i-- {
+		res[i] = x[i-int(limbs)] << shift
+		if shift != 0 && i-int(limbs)-1 >= 0 {
+			res[i] |= x[i-int(limbs)-1] >> (64 - shift)
+		}
+	}
+	*z = res
+	return z
+}
+
+// Rsh sets z to x >> n
+func (z *Int) Rsh(x *Int, n uint) *Int {
+	if n >= 256 {
+		return z.Clear()
+	}
+	limbs, shift := n/64, n%64
+
+	var res Int
+	for i := 0; i+int(limbs) < 4; i++ {
+		res[i] = x[i+int(limbs)] >> shift
+		if shift != 0 && i+int(limbs)+1 < 4 {
+			res[i] |= x[i+int(limbs)+1] << (64 - shift)
+		}
+	}
+	*z = res
+	return z
+}
+
+// SRsh sets z to the arithmetic shift x >> n
+func (z *Int) SRsh(x *Int, n uint) *Int {
+	if x[3]>>63 == 0 {
+		return z.Rsh(x, n)
+	}
+	if n >= 256 {
+		z[0], z[1], z[2], z[3] = ^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)
+		return z
+	}
+	// shift the negated value to fill the top bits with ones
+	var tmp Int
+	tmp.Not(x)
+	tmp.Rsh(&tmp, n)
+	return z.Not(&tmp)
+}
+
+// ExtendSign sets z to the sign extension of x from the byte n
+func (z *Int) ExtendSign(x, n *Int) *Int {
+	if !n.IsUint64() || n[0] >= 31 {
+		return z.Set(x)
+	}
+	bit := uint(n[0]*8 + 7)
+
+	var mask Int
+	mask.Lsh(NewInt(1), bit)
+	mask.Sub(&mask, NewInt(1))
+
+	if (x[bit/64]>>(bit%64))&1 == 1 {
+		return z.Or(x, mask.Not(&mask))
+	}
+	return z.And(x, &mask)
+}
@@ -0,0 +1,221 @@
+package uint256
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	tt256   = new(big.Int).Lsh(big.NewInt(1), 256)
+	tt255   = new(big.Int).Lsh(big.NewInt(1), 255)
+	tt256m1 = new(big.Int).Sub(tt256, big.NewInt(1))
+)
+
+// randInt returns a random value with a random number of limbs and
+// some limbs set to the edge values
+func randInt(r *rand.Rand) *big.Int {
+	var z Int
+	for i, n := 0, r.Intn(5); i < n; i++ {
+		switch r.Intn(4) {
+		case 0:
+			z[i] = ^uint64(0)
+		case 1:
+			z[i] = 1
+		default:
+			z[i] = r.Uint64()
+		}
+	}
+	return z.ToBig()
+}
+
+func toSigned(x *big.Int) *big.Int {
+	if x.Cmp(tt255) >= 0 {
+		return new(big.Int).Sub(x, tt256)
+	}
+	return new(big.Int).Set(x)
+}
+
+func toUnsigned(x *big.Int) *big.Int {
+	return new(big.Int).And(x, tt256m1)
+}
+
+func fromBig(x *big.Int) *Int {
+	return new(Int).SetFromBig(x)
+}
+
+func TestUint256_Binary(t *testing.T) {
+	cases := map[string]struct {
+		op  func(z, x, y *Int) *Int
+		ref func(x, y *big.Int) *big.Int
+	}{
+		"Add": {(*Int).Add, func(x, y *big.Int) *big.Int { return new(big.Int).Add(x, y) }},
+		"Sub": {(*Int).Sub, func(x, y *big.Int) *big.Int { return new(big.Int).Sub(x, y) }},
+		"Mul": {(*Int).Mul, func(x, y *big.Int) *big.Int { return new(big.Int).Mul(x, y) }},
+		"Div": {(*Int).Div, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Div(x, y)
+		}},
+		"Mod": {(*Int).Mod, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Mod(x, y)
+		}},
+		"SDiv": {(*Int).SDiv, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Quo(toSigned(x), toSigned(y))
+		}},
+		"SMod": {(*Int).SMod, func(x, y *big.Int) *big.Int {
+			if y.Sign() == 0 {
+				return new(big.Int)
+			}
+			return new(big.Int).Rem(toSigned(x), toSigned(y))
+		}},
+		"Exp": {(*Int).Exp, func(x, y *big.Int) *big.Int { return new(big.Int).Exp(x, y, tt256) }},
+		"And": {(*Int).And, func(x, y *big.Int) *big.Int { return new(big.Int).And(x, y) }},
+		"Or":  {(*Int).Or, func(x, y *big.Int) *big.Int { return new(big.Int).Or(x, y) }},
+		"Xor": {(*Int).Xor, func(x, y *big.Int) *big.Int { return new(big.Int).Xor(x, y) }},
+	}
+
+	r := rand.New(rand.NewSource(1))
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			for i := 0; i < 2000; i++ {
+				x, y := randInt(r), randInt(r)
+				expected := toUnsigned(c.ref(x, y))
+
+				found := c.op(new(Int), fromBig(x), fromBig(y))
+				if !assert.Equal(t, expected.String(), found.ToBig().String(), "%s %s", x, y) {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestUint256_Mod(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		x, y, m := randInt(r), randInt(r), randInt(r)
+
+		expectedAdd, expectedMul := new(big.Int), new(big.Int)
+		if m.Sign() != 0 {
+			expectedAdd.Mod(new(big.Int).Add(x, y), m)
+			expectedMul.Mod(new(big.Int).Mul(x, y), m)
+		}
+
+		assert.Equal(t, expectedAdd.String(), new(Int).AddMod(fromBig(x), fromBig(y), fromBig(m)).ToBig().String())
+		assert.Equal(t, expectedMul.String(), new(Int).MulMod(fromBig(x), fromBig(y), fromBig(m)).ToBig().String())
+	}
+}
+
+func TestUint256_Shift(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		x := randInt(r)
+		n := uint(r.Intn(300))
+
+		assert.Equal(t, toUnsigned(new(big.Int).Lsh(x, n)).String(), new(Int).Lsh(fromBig(x), n).ToBig().String())
+		assert.Equal(t, new(big.Int).Rsh(x, n).String(), new(Int).Rsh(fromBig(x), n).ToBig().String())
+		assert.Equal(t, toUnsigned(new(big.Int).Rsh(toSigned(x), n)).String(), new(Int).SRsh(fromBig(x), n).ToBig().String())
+	}
+}
+
+func TestUint256_Compare(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		x, y := randInt(r), randInt(r)
+		a, b := fromBig(x), fromBig(y)
+
+		assert.Equal(t, x.Cmp(y), a.Cmp(b))
+		assert.Equal(t, toSigned(x).Cmp(toSigned(y)) < 0, a.Slt(b))
+		assert.Equal(t, toSigned(x).Cmp(toSigned(y)) > 0, a.Sgt(b))
+		assert.Equal(t, x.BitLen(), a.BitLen())
+	}
+}
+
+func TestUint256_Bytes(t *testing.T) {
+	x := new(Int).SetBytes([]byte{0x1, 0x2, 0x3, 0x4, 0x5, 0x6, 0x7, 0x8, 0x9})
+	assert.Equal(t, Int{0x0203040506070809, 0x1}, *x)
+
+	buf := x.Bytes32()
+	assert.Equal(t, x, new(Int).SetBytes(buf[:]))
+
+	// only the last 32 bytes are used
+	long := append([]byte{0xff}, buf[:]...)
+	assert.Equal(t, x, new(Int).SetBytes(long))
+
+	for i := uint64(0); i < 32; i++ {
+		assert.Equal(t, uint64(buf[i]), new(Int).Set(x).Byte(NewInt(i)).Uint64())
+	}
+	assert.True(t, new(Int).Set(x).Byte(NewInt(32)).IsZero())
+}
+
+func TestUint256_ExtendSign(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		x := randInt(r)
+		n := uint64(r.Intn(33))
+
+		expected := new(big.Int).Set(x)
+		if n < 31 {
+			bit := uint(n*8 + 7)
+			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), bit), big.NewInt(1))
+			if x.Bit(int(bit)) == 1 {
+				expected.Or(x, new(big.Int).Xor(mask, tt256m1))
+			} else {
+				expected.And(x, mask)
+			}
+		}
+		assert.Equal(t, expected.String(), new(Int).ExtendSign(fromBig(x), NewInt(n)).ToBig().String())
+	}
+}
+
+func benchmarkBinary(b *testing.B, op func(z, x, y *Int) *Int, bigOp func(z, x, y *big.Int) *big.Int) {
+	x := &Int{0x1234567890abcdef, 0xfedcba0987654321, 0x1111111111111111, 0x0fffffffffffffff}
+	y := &Int{0xfedcba0987654321, 0x1234567890abcdef, 0x2222222222222222}
+
+	b.Run("uint256", func(b *testing.B) {
+		z := new(Int)
+		for i := 0; i < b.N; i++ {
+			op(z, x, y)
+		}
+	})
+	b.Run("big", func(b *testing.B) {
+		bx, by, z := x.ToBig(), y.ToBig(), new(big.Int)
+		for i := 0; i < b.N; i++ {
+			bigOp(z, bx, by)
+			z.And(z, tt256m1)
+		}
+	})
+}
+
+func BenchmarkAdd(b *testing.B) {
+	benchmarkBinary(b, (*Int).Add, (*big.Int).Add)
+}
+
+func BenchmarkMul(b *testing.B) {
+	benchmarkBinary(b, (*Int).Mul, (*big.Int).Mul)
+}
+
+func BenchmarkDiv(b *testing.B) {
+	benchmarkBinary(b, (*Int).Div, (*big.Int).Div)
+}
+
+func BenchmarkMulMod(b *testing.B) {
+	m := &Int{0xffffffffffffffff, 0, 0xfedcba0987654321}
+	bm := m.ToBig()
+
+	benchmarkBinary(b, func(z, x, y *Int) *Int {
+		return z.MulMod(x, y, m)
+	}, func(z, x, y *big.Int) *big.Int {
+		return z.Mod(z.Mul(x, y), bm)
+	})
+}
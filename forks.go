@@ -0,0 +1,146 @@
+package state
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
+)
+
+// forkRevisions are the revisions of the names of the forks in the ethereum
+// tests and the execution-spec-tests. Merge and Paris are London with the
+// ParisBlock at the genesis.
+var forkRevisions = map[string]evmc.Revision{
+	"Frontier":          evmc.Frontier,
+	"Homestead":         evmc.Homestead,
+	"EIP150":            evmc.TangerineWhistle,
+	"TangerineWhistle":  evmc.TangerineWhistle,
+	"EIP158":            evmc.SpuriousDragon,
+	"SpuriousDragon":    evmc.SpuriousDragon,
+	"Byzantium":         evmc.Byzantium,
+	"Constantinople":    evmc.Constantinople,
+	"ConstantinopleFix": evmc.Petersburg,
+	"Petersburg":        evmc.Petersburg,
+	"Istanbul":          evmc.Istanbul,
+	"Berlin":            evmc.Berlin,
+	"London":            evmc.London,
+	"Merge":             evmc.London,
+	"Paris":             evmc.London,
+	"Shanghai":          evmc.Shanghai,
+	"Cancun":            evm.Cancun,
+	"Prague":            evm.Prague,
+}
+
+// lookupForkName returns the revision of the fork and whether the chain is
+// merged in the fork
+func lookupForkName(name string) (evmc.Revision, bool, bool) {
+	rev, ok := forkRevisions[name]
+	if !ok {
+		return 0, false, false
+	}
+	merged := rev >= evmc.Shanghai || name == "Merge" || name == "Paris"
+	return rev, merged, true
+}
+
+// ForkChainConfig returns the schedule of a fork of the ethereum tests and
+// the execution-spec-tests, with the chain id 1. The name is either a fork,
+// which is active from the genesis, or a transition between two forks like
+// BerlinToLondonAt5 or ShanghaiToCancunAtTime15k, where the second fork is
+// active from the block or from the timestamp. The transitions of the merge
+// by total difficulty (ArrowGlacierToParisAtDiffC0000) and of the dao fork
+// are not supported.
+func ForkChainConfig(name string) (*ChainConfig, bool) {
+	if rev, merged, ok := lookupForkName(name); ok {
+		return forkSchedule(rev, rev, merged, false, 0), true
+	}
+
+	i := strings.LastIndex(name, "At")
+	if i == -1 {
+		return nil, false
+	}
+	forks, at := name[:i], name[i+len("At"):]
+
+	fromName, toName, ok := strings.Cut(forks, "To")
+	if !ok {
+		return nil, false
+	}
+	from, fromMerged, ok := lookupForkName(fromName)
+	if !ok {
+		return nil, false
+	}
+	to, toMerged, ok := lookupForkName(toName)
+	if !ok || to <= from {
+		return nil, false
+	}
+
+	// the forks up to Paris are activated by the block and the following
+	// ones by the timestamp
+	timestamp := strings.HasPrefix(at, "Time")
+	if timestamp != fromMerged || toMerged && !fromMerged {
+		return nil, false
+	}
+	at = strings.TrimPrefix(at, "Time")
+
+	scale := uint64(1)
+	if strings.HasSuffix(at, "k") {
+		at, scale = strings.TrimSuffix(at, "k"), 1000
+	}
+	num, err := strconv.ParseUint(at, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return forkSchedule(from, to, fromMerged, timestamp, num*scale), true
+}
+
+// forkSchedule returns the schedule with the forks up to from active from
+// the genesis and the following ones up to to active from the block, or
+// from the timestamp if timestamp is set
+func forkSchedule(from, to evmc.Revision, merged, timestamp bool, at uint64) *ChainConfig {
+	c := &ChainConfig{
+		ChainID: big.NewInt(1),
+	}
+	if merged {
+		c.ParisBlock = big.NewInt(0)
+	}
+	for rev := evmc.Homestead; rev <= to; rev++ {
+		num, time := big.NewInt(0), newUint64(0)
+		if rev > from {
+			if timestamp {
+				time = newUint64(at)
+			} else {
+				num = new(big.Int).SetUint64(at)
+			}
+		}
+
+		switch rev {
+		case evmc.Homestead:
+			c.HomesteadBlock = num
+		case evmc.TangerineWhistle:
+			c.EIP150Block = num
+		case evmc.SpuriousDragon:
+			c.EIP155Block = num
+			c.EIP158Block = num
+		case evmc.Byzantium:
+			c.ByzantiumBlock = num
+		case evmc.Constantinople:
+			c.ConstantinopleBlock = num
+		case evmc.Petersburg:
+			c.PetersburgBlock = num
+		case evmc.Istanbul:
+			c.IstanbulBlock = num
+		case evmc.Berlin:
+			c.BerlinBlock = num
+		case evmc.London:
+			c.LondonBlock = num
+		case evmc.Shanghai:
+			c.ShanghaiTime = time
+		case evm.Cancun:
+			c.CancunTime = time
+		case evm.Prague:
+			c.PragueTime = time
+		}
+	}
+	return c
+}
@@ -0,0 +1,57 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/umbracle/go-evm/evm"
+)
+
+func TestForkChainConfig(t *testing.T) {
+	cases := []struct {
+		name      string
+		number    uint64
+		timestamp uint64
+		rev       evmc.Revision
+		paris     bool
+	}{
+		{"Frontier", 10, 0, evmc.Frontier, false},
+		{"EIP158", 0, 0, evmc.SpuriousDragon, false},
+		{"ConstantinopleFix", 0, 0, evmc.Petersburg, false},
+		{"London", 0, 0, evmc.London, false},
+		{"Paris", 0, 0, evmc.London, true},
+		{"Cancun", 0, 0, evm.Cancun, true},
+		{"FrontierToHomesteadAt5", 4, 0, evmc.Frontier, false},
+		{"FrontierToHomesteadAt5", 5, 0, evmc.Homestead, false},
+		{"ByzantiumToConstantinopleFixAt5", 5, 0, evmc.Petersburg, false},
+		{"BerlinToLondonAt5", 4, 0, evmc.Berlin, false},
+		{"BerlinToLondonAt5", 5, 0, evmc.London, false},
+		{"ParisToShanghaiAtTime15k", 1, 14_999, evmc.London, true},
+		{"ParisToShanghaiAtTime15k", 1, 15_000, evmc.Shanghai, true},
+		{"ShanghaiToCancunAtTime15k", 1, 14_999, evmc.Shanghai, true},
+		{"ShanghaiToCancunAtTime15k", 1, 15_000, evm.Cancun, true},
+		{"CancunToPragueAtTime15k", 1, 15_000, evm.Prague, true},
+	}
+	for _, c := range cases {
+		config, ok := ForkChainConfig(c.name)
+		require.True(t, ok, c.name)
+		assert.Equal(t, c.rev, config.Revision(c.number, c.timestamp), c.name)
+		assert.Equal(t, c.paris, config.IsParis(c.number), c.name)
+	}
+
+	for _, name := range []string{
+		"Unknown",
+		"LondonToBerlinAt5",
+		"BerlinToLondonAtTime15k",
+		"ShanghaiToCancunAt5",
+		"LondonToShanghaiAtTime15k",
+		"BerlinToLondonAt",
+		"HomesteadToDaoAt5",
+		"ArrowGlacierToParisAtDiffC0000",
+	} {
+		_, ok := ForkChainConfig(name)
+		assert.False(t, ok, name)
+	}
+}
@@ -5,6 +5,8 @@ go 1.18
 require (
 	github.com/ethereum/evmc/v10 v10.0.0-alpha.3
 	github.com/hashicorp/go-immutable-radix v1.3.1
+	github.com/hashicorp/golang-lru v0.5.0
+	github.com/kilic/bls12-381 v0.1.0
 	github.com/stretchr/testify v1.7.1
 	github.com/umbracle/ethgo v0.1.0
 	github.com/umbracle/fastrlp v0.0.0-20211229195328-c1416904ae17
@@ -17,7 +19,6 @@ require (
 	github.com/btcsuite/btcutil v1.0.2 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/google/gofuzz v1.2.0 // indirect
-	github.com/hashicorp/golang-lru v0.5.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/tyler-smith/go-bip39 v1.1.0 // indirect
 	github.com/valyala/fastjson v1.4.1 // indirect
@@ -4,54 +4,82 @@ import (
 	"errors"
 
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
 	"github.com/umbracle/go-evm/precompiled"
 )
 
 var (
-	addr1 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
-	addr2 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2}
-	addr3 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3}
-	addr4 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4}
-	addr5 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5}
-	addr6 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6}
-	addr7 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7}
-	addr8 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8}
-	addr9 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9}
+	addr1  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
+	addr2  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2}
+	addr3  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3}
+	addr4  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 4}
+	addr5  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 5}
+	addr6  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 6}
+	addr7  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 7}
+	addr8  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 8}
+	addr9  = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 9}
+	addr10 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 10}
+	addr11 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 11}
+	addr12 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 12}
+	addr13 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 13}
+	addr14 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 14}
+	addr15 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 15}
+	addr16 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 16}
+	addr17 = evmc.Address{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 17}
 )
 
-var precompiledContracts map[evmc.Address]contract
+// Precompile is a native contract executed at a fixed address instead of
+// the evm bytecode
+type Precompile interface {
+	Gas(input []byte, rev evmc.Revision) uint64
+	Run(input []byte) ([]byte, error)
+}
+
+// precompile is a precompiled contract enabled from a given revision
+type precompile struct {
+	contract Precompile
+	rev      evmc.Revision
+}
 
-func register(addr evmc.Address, b contract) {
-	if len(precompiledContracts) == 0 {
-		precompiledContracts = map[evmc.Address]contract{}
+var defaultPrecompiles map[evmc.Address]*precompile
+
+func register(addr evmc.Address, b Precompile, rev evmc.Revision) {
+	if len(defaultPrecompiles) == 0 {
+		defaultPrecompiles = map[evmc.Address]*precompile{}
 	}
-	precompiledContracts[addr] = b
+	defaultPrecompiles[addr] = &precompile{contract: b, rev: rev}
 }
 
 func init() {
-	register(addr1, &precompiled.Ecrecover{})
-	register(addr2, &precompiled.Sha256h{})
-	register(addr3, &precompiled.Ripemd160h{})
-	register(addr4, &precompiled.Identity{})
+	register(addr1, &precompiled.Ecrecover{}, evmc.Frontier)
+	register(addr2, &precompiled.Sha256h{}, evmc.Frontier)
+	register(addr3, &precompiled.Ripemd160h{}, evmc.Frontier)
+	register(addr4, &precompiled.Identity{}, evmc.Frontier)
 
 	// Byzantium fork
-	register(addr5, &precompiled.ModExp{})
-	register(addr6, &precompiled.Bn256Add{})
-	register(addr7, &precompiled.Bn256Mul{})
-	register(addr8, &precompiled.Bn256Pairing{})
+	register(addr5, &precompiled.ModExp{}, evmc.Byzantium)
+	register(addr6, &precompiled.Bn256Add{}, evmc.Byzantium)
+	register(addr7, &precompiled.Bn256Mul{}, evmc.Byzantium)
+	register(addr8, &precompiled.Bn256Pairing{}, evmc.Byzantium)
 
 	// Istanbul fork
-	register(addr9, &precompiled.Blake2f{})
-}
+	register(addr9, &precompiled.Blake2f{}, evmc.Istanbul)
 
-type contract interface {
-	Gas(input []byte, rev evmc.Revision) uint64
-	Run(input []byte) ([]byte, error)
+	// Cancun fork
+	register(addr10, &precompiled.PointEvaluation{}, evm.Cancun)
+
+	// Prague fork
+	register(addr11, &precompiled.Bls12381G1Add{}, evm.Prague)
+	register(addr12, &precompiled.Bls12381G1MSM{}, evm.Prague)
+	register(addr13, &precompiled.Bls12381G2Add{}, evm.Prague)
+	register(addr14, &precompiled.Bls12381G2MSM{}, evm.Prague)
+	register(addr15, &precompiled.Bls12381Pairing{}, evm.Prague)
+	register(addr16, &precompiled.Bls12381MapG1{}, evm.Prague)
+	register(addr17, &precompiled.Bls12381MapG2{}, evm.Prague)
 }
 
 // runPrecompiled runs an execution
-func runPrecompiled(codeAddress evmc.Address, input []byte, gas uint64, rev evmc.Revision) ([]byte, int64, error) {
-	contract := precompiledContracts[codeAddress]
+func runPrecompiled(contract Precompile, input []byte, gas uint64, rev evmc.Revision) ([]byte, int64, error) {
 	gasCost := contract.Gas(input, rev)
 
 	// In the case of not enough gas for precompiled execution we return ErrOutOfGas
@@ -1,9 +1,12 @@
 package precompiled
 
 import (
+	"bytes"
 	"encoding/hex"
 	"testing"
+	"time"
 
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -29,6 +32,66 @@ func testPrecompiled(t *testing.T, p testContract, cases []precompiledTest) {
 	}
 }
 
+type testGasContract interface {
+	testContract
+	Gas(input []byte, rev evmc.Revision) uint64
+}
+
+// testPrecompiledFixture runs the ReadTestCase fixture, the cases without
+// an expected output are expected to fail
+func testPrecompiledFixture(t *testing.T, p testGasContract, rev evmc.Revision, path string) {
+	ReadTestCase(t, path, func(t *testing.T, c *TestCase) {
+		if gas := p.Gas(c.Input, rev); gas != c.Gas {
+			t.Fatalf("bad gas, expected %d but found %d", c.Gas, gas)
+		}
+
+		out, err := p.Run(c.Input)
+		if len(c.Expected) == 0 {
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(c.Expected, out) {
+			t.Fatal("bad")
+		}
+	})
+}
+
+// benchmarkPrecompiledFixture runs the cases of the ReadTestCase fixture
+// that succeed and reports the gas charged per second
+func benchmarkPrecompiledFixture(b *testing.B, p testGasContract, rev evmc.Revision, path string) {
+	for _, c := range readTestCases(b, path) {
+		if len(c.Expected) == 0 {
+			continue
+		}
+		c := c
+		b.Run(c.Name, func(b *testing.B) {
+			benchmarkPrecompiled(b, p, rev, c.Input)
+		})
+	}
+}
+
+func benchmarkPrecompiled(b *testing.B, p testGasContract, rev evmc.Revision, input []byte) {
+	gas := p.Gas(input, rev)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.Run(input); err != nil {
+			b.Fatal(err)
+		}
+	}
+	elapsed := time.Since(start)
+
+	b.ReportMetric(float64(gas)*float64(b.N)/elapsed.Seconds(), "gas/s")
+}
+
 func TestECRecover(t *testing.T) {
 	var tests = []precompiledTest{
 		{
@@ -0,0 +1,368 @@
+package precompiled
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// eip-2537 encodes every field element in 64 bytes, the top 16 bytes
+// must be zero
+const (
+	blsFpLen     = 64
+	blsG1Len     = 2 * blsFpLen
+	blsG2Len     = 4 * blsFpLen
+	blsScalarLen = 32
+)
+
+const (
+	blsG1MulGas = 12000
+	blsG2MulGas = 22500
+
+	// blsMSMMultiplier is the denominator of the msm discount tables
+	blsMSMMultiplier = 1000
+)
+
+// blsG1MSMDiscount is the discount table for G1 msm by number of pairs
+var blsG1MSMDiscount = []uint64{
+	1000, 949, 848, 797, 764, 750, 738, 728, 719, 712, 705, 698, 692, 687, 682, 677,
+	673, 669, 665, 661, 658, 654, 651, 648, 645, 642, 640, 637, 635, 632, 630, 627,
+	625, 623, 621, 619, 617, 615, 613, 611, 609, 608, 606, 604, 603, 601, 599, 598,
+	596, 595, 593, 592, 591, 589, 588, 586, 585, 584, 582, 581, 580, 579, 577, 576,
+	575, 574, 573, 572, 570, 569, 568, 567, 566, 565, 564, 563, 562, 561, 560, 559,
+	558, 557, 556, 555, 554, 553, 552, 551, 550, 549, 548, 547, 547, 546, 545, 544,
+	543, 542, 541, 540, 540, 539, 538, 537, 536, 536, 535, 534, 533, 532, 532, 531,
+	530, 529, 528, 528, 527, 526, 525, 525, 524, 523, 522, 522, 521, 520, 520, 519,
+}
+
+// blsG2MSMDiscount is the discount table for G2 msm by number of pairs
+var blsG2MSMDiscount = []uint64{
+	1000, 1000, 923, 884, 855, 832, 812, 796, 782, 770, 759, 749, 740, 732, 724, 717,
+	711, 704, 699, 693, 688, 683, 679, 674, 670, 666, 663, 659, 655, 652, 649, 646,
+	643, 640, 637, 634, 632, 629, 627, 624, 622, 620, 618, 615, 613, 611, 609, 607,
+	606, 604, 602, 600, 598, 597, 595, 593, 592, 590, 589, 587, 586, 584, 583, 582,
+	580, 579, 578, 576, 575, 574, 573, 571, 570, 569, 568, 567, 566, 565, 563, 562,
+	561, 560, 559, 558, 557, 556, 555, 554, 553, 552, 552, 551, 550, 549, 548, 547,
+	546, 545, 545, 544, 543, 542, 541, 541, 540, 539, 538, 537, 537, 536, 535, 535,
+	534, 533, 532, 532, 531, 530, 530, 529, 528, 528, 527, 526, 526, 525, 524, 524,
+}
+
+// msmGas returns the gas of a msm with k pairs
+func msmGas(k int, mulGas uint64, discountTable []uint64) uint64 {
+	if k == 0 {
+		return 0
+	}
+	discount := discountTable[len(discountTable)-1]
+	if k <= len(discountTable) {
+		discount = discountTable[k-1]
+	}
+	return uint64(k) * mulGas * discount / blsMSMMultiplier
+}
+
+// Bls12381G1Add is the eip-2537 G1ADD precompile
+type Bls12381G1Add struct {
+}
+
+func (b *Bls12381G1Add) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 375
+}
+
+func (b *Bls12381G1Add) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*blsG1Len {
+		return nil, fmt.Errorf("bad size")
+	}
+
+	// the subgroup check is not required for the addition
+	g := bls12381.NewG1()
+	p0, err := decodeBlsG1(g, input[:blsG1Len])
+	if err != nil {
+		return nil, err
+	}
+	p1, err := decodeBlsG1(g, input[blsG1Len:])
+	if err != nil {
+		return nil, err
+	}
+
+	r := g.Add(g.New(), p0, p1)
+	return encodeBlsG1(g, r), nil
+}
+
+// Bls12381G1MSM is the eip-2537 G1MSM precompile
+type Bls12381G1MSM struct {
+}
+
+func (b *Bls12381G1MSM) Gas(input []byte, rev evmc.Revision) uint64 {
+	return msmGas(len(input)/(blsG1Len+blsScalarLen), blsG1MulGas, blsG1MSMDiscount)
+}
+
+func (b *Bls12381G1MSM) Run(input []byte) ([]byte, error) {
+	pairLen := blsG1Len + blsScalarLen
+	if len(input) == 0 || len(input)%pairLen != 0 {
+		return nil, fmt.Errorf("bad size")
+	}
+
+	num := len(input) / pairLen
+	points := make([]*bls12381.PointG1, num)
+	scalars := make([]*big.Int, num)
+
+	g := bls12381.NewG1()
+	for i := 0; i < num; i++ {
+		buf := input[i*pairLen : (i+1)*pairLen]
+
+		p, err := decodeBlsG1(g, buf[:blsG1Len])
+		if err != nil {
+			return nil, err
+		}
+		if !g.InCorrectSubgroup(p) {
+			return nil, fmt.Errorf("g1 point is not in the correct subgroup")
+		}
+		points[i] = p
+		scalars[i] = new(big.Int).SetBytes(buf[blsG1Len:])
+	}
+
+	r := g.New()
+	if num == 1 {
+		// a single multiplication is faster with the endomorphism
+		g.MulScalarBig(r, points[0], scalars[0])
+	} else if _, err := g.MultiExpBig(r, points, scalars); err != nil {
+		return nil, err
+	}
+	return encodeBlsG1(g, r), nil
+}
+
+// Bls12381G2Add is the eip-2537 G2ADD precompile
+type Bls12381G2Add struct {
+}
+
+func (b *Bls12381G2Add) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 600
+}
+
+func (b *Bls12381G2Add) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*blsG2Len {
+		return nil, fmt.Errorf("bad size")
+	}
+
+	// the subgroup check is not required for the addition
+	g := bls12381.NewG2()
+	p0, err := decodeBlsG2(g, input[:blsG2Len])
+	if err != nil {
+		return nil, err
+	}
+	p1, err := decodeBlsG2(g, input[blsG2Len:])
+	if err != nil {
+		return nil, err
+	}
+
+	r := g.Add(g.New(), p0, p1)
+	return encodeBlsG2(g, r), nil
+}
+
+// Bls12381G2MSM is the eip-2537 G2MSM precompile
+type Bls12381G2MSM struct {
+}
+
+func (b *Bls12381G2MSM) Gas(input []byte, rev evmc.Revision) uint64 {
+	return msmGas(len(input)/(blsG2Len+blsScalarLen), blsG2MulGas, blsG2MSMDiscount)
+}
+
+func (b *Bls12381G2MSM) Run(input []byte) ([]byte, error) {
+	pairLen := blsG2Len + blsScalarLen
+	if len(input) == 0 || len(input)%pairLen != 0 {
+		return nil, fmt.Errorf("bad size")
+	}
+
+	num := len(input) / pairLen
+	points := make([]*bls12381.PointG2, num)
+	scalars := make([]*big.Int, num)
+
+	g := bls12381.NewG2()
+	for i := 0; i < num; i++ {
+		buf := input[i*pairLen : (i+1)*pairLen]
+
+		p, err := decodeBlsG2(g, buf[:blsG2Len])
+		if err != nil {
+			return nil, err
+		}
+		if !g.InCorrectSubgroup(p) {
+			return nil, fmt.Errorf("g2 point is not in the correct subgroup")
+		}
+		points[i] = p
+		scalars[i] = new(big.Int).SetBytes(buf[blsG2Len:])
+	}
+
+	r := g.New()
+	if num == 1 {
+		g.MulScalarBig(r, points[0], scalars[0])
+	} else if _, err := g.MultiExpBig(r, points, scalars); err != nil {
+		return nil, err
+	}
+	return encodeBlsG2(g, r), nil
+}
+
+// Bls12381Pairing is the eip-2537 PAIRING_CHECK precompile
+type Bls12381Pairing struct {
+}
+
+func (b *Bls12381Pairing) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 37700 + 32600*uint64(len(input)/(blsG1Len+blsG2Len))
+}
+
+func (b *Bls12381Pairing) Run(input []byte) ([]byte, error) {
+	pairLen := blsG1Len + blsG2Len
+	if len(input) == 0 || len(input)%pairLen != 0 {
+		return nil, fmt.Errorf("bad size")
+	}
+
+	// pairs with a point at infinity are skipped by the engine
+	engine := bls12381.NewEngine()
+	for ; len(input) != 0; input = input[pairLen:] {
+		a0, err := decodeBlsG1(engine.G1, input[:blsG1Len])
+		if err != nil {
+			return nil, err
+		}
+		if !engine.G1.InCorrectSubgroup(a0) {
+			return nil, fmt.Errorf("g1 point is not in the correct subgroup")
+		}
+
+		b0, err := decodeBlsG2(engine.G2, input[blsG1Len:pairLen])
+		if err != nil {
+			return nil, err
+		}
+		if !engine.G2.InCorrectSubgroup(b0) {
+			return nil, fmt.Errorf("g2 point is not in the correct subgroup")
+		}
+
+		engine.AddPair(a0, b0)
+	}
+
+	if engine.Check() {
+		return trueBytes, nil
+	}
+	return falseBytes, nil
+}
+
+// Bls12381MapG1 is the eip-2537 MAP_FP_TO_G1 precompile
+type Bls12381MapG1 struct {
+}
+
+func (b *Bls12381MapG1) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 5500
+}
+
+func (b *Bls12381MapG1) Run(input []byte) ([]byte, error) {
+	if len(input) != blsFpLen {
+		return nil, fmt.Errorf("bad size")
+	}
+	fp, err := decodeBlsFp(input)
+	if err != nil {
+		return nil, err
+	}
+
+	g := bls12381.NewG1()
+	p, err := g.MapToCurve(fp)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG1(g, p), nil
+}
+
+// Bls12381MapG2 is the eip-2537 MAP_FP2_TO_G2 precompile
+type Bls12381MapG2 struct {
+}
+
+func (b *Bls12381MapG2) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 23800
+}
+
+func (b *Bls12381MapG2) Run(input []byte) ([]byte, error) {
+	if len(input) != 2*blsFpLen {
+		return nil, fmt.Errorf("bad size")
+	}
+	fp2, err := decodeBlsFp2(input)
+	if err != nil {
+		return nil, err
+	}
+
+	g := bls12381.NewG2()
+	p, err := g.MapToCurve(fp2)
+	if err != nil {
+		return nil, err
+	}
+	return encodeBlsG2(g, p), nil
+}
+
+// decodeBlsFp removes the 16 bytes of zero padding of a field element
+func decodeBlsFp(buf []byte) ([]byte, error) {
+	for _, b := range buf[:blsFpLen-48] {
+		if b != 0 {
+			return nil, fmt.Errorf("invalid field element top bytes")
+		}
+	}
+	return buf[blsFpLen-48:], nil
+}
+
+func encodeBlsFp(dst, fp []byte) {
+	copy(dst[blsFpLen-48:blsFpLen], fp)
+}
+
+// decodeBlsFp2 decodes the padded c0 || c1 encoding of eip-2537 into
+// the c1 || c0 order of the zcash format used by the library
+func decodeBlsFp2(buf []byte) ([]byte, error) {
+	c0, err := decodeBlsFp(buf[:blsFpLen])
+	if err != nil {
+		return nil, err
+	}
+	c1, err := decodeBlsFp(buf[blsFpLen:])
+	if err != nil {
+		return nil, err
+	}
+	return append(append(make([]byte, 0, 96), c1...), c0...), nil
+}
+
+func encodeBlsFp2(dst, fp2 []byte) {
+	encodeBlsFp(dst[0:], fp2[48:96])
+	encodeBlsFp(dst[blsFpLen:], fp2[0:48])
+}
+
+func decodeBlsG1(g *bls12381.G1, buf []byte) (*bls12381.PointG1, error) {
+	x, err := decodeBlsFp(buf[:blsFpLen])
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBlsFp(buf[blsFpLen:])
+	if err != nil {
+		return nil, err
+	}
+	return g.FromBytes(append(append(make([]byte, 0, 96), x...), y...))
+}
+
+func encodeBlsG1(g *bls12381.G1, p *bls12381.PointG1) []byte {
+	buf := g.ToBytes(p)
+	out := make([]byte, blsG1Len)
+	encodeBlsFp(out[0:], buf[0:48])
+	encodeBlsFp(out[blsFpLen:], buf[48:96])
+	return out
+}
+
+func decodeBlsG2(g *bls12381.G2, buf []byte) (*bls12381.PointG2, error) {
+	x, err := decodeBlsFp2(buf[:2*blsFpLen])
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeBlsFp2(buf[2*blsFpLen:])
+	if err != nil {
+		return nil, err
+	}
+	return g.FromBytes(append(x, y...))
+}
+
+func encodeBlsG2(g *bls12381.G2, p *bls12381.PointG2) []byte {
+	buf := g.ToBytes(p)
+	out := make([]byte, blsG2Len)
+	encodeBlsFp2(out[0:], buf[0:96])
+	encodeBlsFp2(out[2*blsFpLen:], buf[96:192])
+	return out
+}
@@ -0,0 +1,95 @@
+package precompiled
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/umbracle/go-evm/evm"
+)
+
+func TestBls12381G1Add(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381G1Add{}, evm.Prague, "blsG1Add.json")
+}
+
+func TestBls12381G1MSM(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381G1MSM{}, evm.Prague, "blsG1MSM.json")
+}
+
+func TestBls12381G2Add(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381G2Add{}, evm.Prague, "blsG2Add.json")
+}
+
+func TestBls12381G2MSM(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381G2MSM{}, evm.Prague, "blsG2MSM.json")
+}
+
+func TestBls12381Pairing(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381Pairing{}, evm.Prague, "blsPairing.json")
+}
+
+func TestBls12381MapG1(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381MapG1{}, evm.Prague, "blsMapG1.json")
+}
+
+func TestBls12381MapG2(t *testing.T) {
+	testPrecompiledFixture(t, &Bls12381MapG2{}, evm.Prague, "blsMapG2.json")
+}
+
+func TestBls12381MSMGas(t *testing.T) {
+	// the discount is capped after the last entry of the tables
+	if gas := msmGas(200, blsG1MulGas, blsG1MSMDiscount); gas != 200*12000*519/1000 {
+		t.Fatalf("bad g1 gas %d", gas)
+	}
+	if gas := msmGas(200, blsG2MulGas, blsG2MSMDiscount); gas != 200*22500*524/1000 {
+		t.Fatalf("bad g2 gas %d", gas)
+	}
+}
+
+func BenchmarkBls12381G1Add(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381G1Add{}, evm.Prague, "blsG1Add.json")
+}
+
+func BenchmarkBls12381G1MSM(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381G1MSM{}, evm.Prague, "blsG1MSM.json")
+	benchmarkBls12381MSM(b, &Bls12381G1MSM{}, "blsG1MSM.json")
+}
+
+func BenchmarkBls12381G2Add(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381G2Add{}, evm.Prague, "blsG2Add.json")
+}
+
+func BenchmarkBls12381G2MSM(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381G2MSM{}, evm.Prague, "blsG2MSM.json")
+	benchmarkBls12381MSM(b, &Bls12381G2MSM{}, "blsG2MSM.json")
+}
+
+func BenchmarkBls12381Pairing(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381Pairing{}, evm.Prague, "blsPairing.json")
+}
+
+func BenchmarkBls12381MapG1(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381MapG1{}, evm.Prague, "blsMapG1.json")
+}
+
+func BenchmarkBls12381MapG2(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &Bls12381MapG2{}, evm.Prague, "blsMapG2.json")
+}
+
+// benchmarkBls12381MSM repeats the random scalar pair of the fixture to
+// measure the msm with the sizes where the gas discount applies
+func benchmarkBls12381MSM(b *testing.B, p testGasContract, path string) {
+	var pair []byte
+	for _, c := range readTestCases(b, path) {
+		if strings.HasSuffix(c.Name, "* random") {
+			pair = c.Input
+		}
+	}
+	for _, k := range []int{2, 8, 32, 128} {
+		input := bytes.Repeat(pair, k)
+		b.Run(fmt.Sprintf("%d pairs", k), func(b *testing.B) {
+			benchmarkPrecompiled(b, p, evm.Prague, input)
+		})
+	}
+}
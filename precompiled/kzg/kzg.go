@@ -0,0 +1,93 @@
+package kzg
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+// VersionedHashVersionKZG is the version byte of the versioned hashes
+// derived from kzg commitments
+const VersionedHashVersionKZG = 0x01
+
+var (
+	errInvalidFieldElement = errors.New("field element is not canonical")
+	errInvalidCommitment   = errors.New("invalid commitment")
+	errInvalidProof        = errors.New("invalid proof")
+)
+
+var (
+	// BLSModulus is the order of the BLS12-381 subgroups, the modulus
+	// of the scalar field the blob polynomials are defined over
+	BLSModulus = bls12381.NewG1().Q()
+
+	// tauG2 is the [tau]G2 point of the ceremony trusted setup, the only
+	// point of the setup required to verify opening proofs.
+	tauG2 *bls12381.PointG2
+
+	// negG2 is the negated generator of G2
+	negG2 *bls12381.PointG2
+)
+
+func init() {
+	g2 := bls12381.NewG2()
+
+	var err error
+	if tauG2, err = g2.FromCompressed(setupG2Tau); err != nil {
+		panic(err)
+	}
+	negG2 = g2.Neg(g2.New(), g2.One())
+}
+
+// Commitment is a compressed G1 point committing to a blob polynomial
+type Commitment [48]byte
+
+// Proof is a compressed G1 point for an opening of a commitment
+type Proof [48]byte
+
+// VersionedHash returns the versioned hash of a commitment as defined
+// in eip-4844
+func VersionedHash(c Commitment) [32]byte {
+	h := sha256.Sum256(c[:])
+	h[0] = VersionedHashVersionKZG
+	return h
+}
+
+// VerifyProof checks that the polynomial committed in c evaluates to y
+// at the point z. Both z and y are big endian encoded scalars that have
+// to be lower than the BLS modulus.
+func VerifyProof(c Commitment, z, y [32]byte, proof Proof) (bool, error) {
+	zVal := new(big.Int).SetBytes(z[:])
+	if zVal.Cmp(BLSModulus) >= 0 {
+		return false, errInvalidFieldElement
+	}
+	yVal := new(big.Int).SetBytes(y[:])
+	if yVal.Cmp(BLSModulus) >= 0 {
+		return false, errInvalidFieldElement
+	}
+
+	// the compressed decoding also checks the subgroup of the points
+	g1 := bls12381.NewG1()
+	commitment, err := g1.FromCompressed(c[:])
+	if err != nil {
+		return false, errInvalidCommitment
+	}
+	pi, err := g1.FromCompressed(proof[:])
+	if err != nil {
+		return false, errInvalidProof
+	}
+
+	// e(c - [y]G1, -G2) * e(proof, [tau]G2 - [z]G2) == 1 is rewritten as
+	// e(c - [y]G1 + [z]proof, -G2) * e(proof, [tau]G2) == 1 to replace
+	// the scalar multiplication in G2 by a cheaper one in G1.
+	p0 := g1.MulScalarBig(g1.New(), g1.One(), yVal)
+	g1.Sub(p0, commitment, p0)
+	g1.Add(p0, p0, g1.MulScalarBig(g1.New(), pi, zVal))
+
+	engine := bls12381.NewEngine()
+	engine.AddPair(p0, negG2)
+	engine.AddPair(pi, tauG2)
+	return engine.Check(), nil
+}
@@ -0,0 +1,59 @@
+package kzg
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+type proofTest struct {
+	commitment Commitment
+	z, y       [32]byte
+	proof      Proof
+}
+
+func decodeProofTest(t *testing.T, input string) *proofTest {
+	buf, err := hex.DecodeString(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &proofTest{}
+	copy(p.z[:], buf[0:32])
+	copy(p.y[:], buf[32:64])
+	copy(p.commitment[:], buf[64:112])
+	copy(p.proof[:], buf[112:160])
+	return p
+}
+
+func TestVerifyProof(t *testing.T) {
+	// z || y || commitment || proof
+	p := decodeProofTest(t, "564c0a11a0f704f4fc3e8acfe0f8245f0ad1347b378fbf96e206da11a5d3630624d25032e67a7e6a4910df5834b8fe70e6bcfeeac0352434196bdf4b2485d5a18f59a8d2a1a625a17f3fea0fe5eb8c896db3764f3185481bc22f91b4aaffcca25f26936857bc3a7c2539ea8ec3a952b7873033e038326e87ed3e1276fd140253fa08e9fc25fb2d9a98527fc22a2c9612fbeafdad446cbc7bcdbdcd780af2c16a")
+
+	ok, err := VerifyProof(p.commitment, p.z, p.y, p.proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected valid proof")
+	}
+
+	expected := "01e798154708fe7789429634053cbf9f99b619f9f084048927333fce637f549b"
+	if h := VersionedHash(p.commitment); hex.EncodeToString(h[:]) != expected {
+		t.Fatal("bad versioned hash")
+	}
+
+	// wrong evaluation
+	p.y[31]++
+	ok, err = VerifyProof(p.commitment, p.z, p.y, p.proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected invalid proof")
+	}
+
+	// non canonical field element
+	p.y[0] = 0xff
+	if _, err := VerifyProof(p.commitment, p.z, p.y, p.proof); err == nil {
+		t.Fatal("expected error")
+	}
+}
@@ -0,0 +1,7 @@
+package kzg
+
+import "encoding/hex"
+
+// setupG2Tau is the second g2 point of the monomial form of the
+// ethereum kzg ceremony trusted setup (trusted_setup.txt).
+var setupG2Tau, _ = hex.DecodeString("b5bfd7dd8cdeb128843bc287230af38926187075cbfbefa81009a2ce615ac53d2914e5870cb452d2afaaab24f3499f72185cbfee53492714734429b7b38608e23926c911cceceac9a36851477ba4c60b087041de621000edc98edada20c1def2")
@@ -14,6 +14,7 @@ type ModExp struct {
 
 var (
 	big1      = big.NewInt(1)
+	big7      = big.NewInt(7)
 	big4      = big.NewInt(4)
 	big8      = big.NewInt(8)
 	big16     = big.NewInt(16)
@@ -28,6 +29,9 @@ var (
 
 var (
 	divisor = big.NewInt(20)
+
+	divisorEIP2565 = big.NewInt(3)
+	minGasEIP2565  = big.NewInt(200)
 )
 
 func adjustedExponentLength(len, head *big.Int) *big.Int {
@@ -76,6 +80,14 @@ func multComplexity(x *big.Int) *big.Int {
 	return x
 }
 
+// multComplexityEIP2565 is the multiplication complexity after the eip-2565 repricing
+func multComplexityEIP2565(x *big.Int) *big.Int {
+	// ceil(x / 8) ** 2
+	x.Add(x, big7)
+	x.Rsh(x, 3)
+	return x.Mul(x, x)
+}
+
 func (m *ModExp) Gas(input []byte, rev evmc.Revision) uint64 {
 	// fmt.Println("-- calc gas --")
 
@@ -114,7 +126,11 @@ func (m *ModExp) Gas(input []byte, rev evmc.Revision) uint64 {
 	} else {
 		gasCost.Set(baseLen)
 	}
-	gasCost = multComplexity(gasCost)
+	if rev >= evmc.Berlin {
+		gasCost = multComplexityEIP2565(gasCost)
+	} else {
+		gasCost = multComplexity(gasCost)
+	}
 
 	// a = a * max(ADJUSTED_EXPONENT_LENGTH, 1)
 	adjExpLen := adjustedExponentLength(expLen, expHead)
@@ -124,8 +140,16 @@ func (m *ModExp) Gas(input []byte, rev evmc.Revision) uint64 {
 		gasCost.Mul(gasCost, big1)
 	}
 
-	// a = a / div
-	gasCost.Div(gasCost, divisor)
+	if rev >= evmc.Berlin {
+		// a = max(200, a / 3)
+		gasCost.Div(gasCost, divisorEIP2565)
+		if gasCost.Cmp(minGasEIP2565) < 0 {
+			gasCost.Set(minGasEIP2565)
+		}
+	} else {
+		// a = a / div
+		gasCost.Div(gasCost, divisor)
+	}
 
 	// cap to the max uint64
 	if !gasCost.IsUint64() {
@@ -1,7 +1,11 @@
 package precompiled
 
 import (
+	"encoding/hex"
 	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
 )
 
 var modExpTests = []precompiledTest{
@@ -89,3 +93,38 @@ var modExpTests = []precompiledTest{
 func TestModExp(t *testing.T) {
 	testPrecompiled(t, &ModExp{}, modExpTests)
 }
+
+func TestModExpGas(t *testing.T) {
+	// expected gas before and after the eip-2565 repricing
+	expected := map[string][2]uint64{
+		"nagydani-1-square":     {204, 200},
+		"nagydani-1-qube":       {204, 200},
+		"nagydani-1-pow0x10001": {3276, 341},
+		"nagydani-2-square":     {665, 200},
+		"nagydani-2-qube":       {665, 200},
+		"nagydani-2-pow0x10001": {10649, 1365},
+		"nagydani-3-square":     {1894, 341},
+		"nagydani-3-qube":       {1894, 341},
+		"nagydani-3-pow0x10001": {30310, 5461},
+		"nagydani-4-square":     {5580, 1365},
+		"nagydani-4-qube":       {5580, 1365},
+		"nagydani-4-pow0x10001": {89292, 21845},
+		"nagydani-5-square":     {17868, 5461},
+		"nagydani-5-qube":       {17868, 5461},
+		"nagydani-5-pow0x10001": {285900, 87381},
+	}
+
+	m := &ModExp{}
+	for _, c := range modExpTests {
+		gas, ok := expected[c.Name]
+		if !ok {
+			continue
+		}
+		t.Run(c.Name, func(t *testing.T) {
+			input, _ := hex.DecodeString(c.Input)
+
+			assert.Equal(t, gas[0], m.Gas(input, evmc.Istanbul))
+			assert.Equal(t, gas[1], m.Gas(input, evmc.Berlin))
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package precompiled
+
+import (
+	"fmt"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/precompiled/kzg"
+)
+
+// fieldElementsPerBlob is the number of field elements in a blob
+const fieldElementsPerBlob = 4096
+
+// pointEvaluationOutput is FIELD_ELEMENTS_PER_BLOB || BLS_MODULUS
+var pointEvaluationOutput []byte
+
+func init() {
+	pointEvaluationOutput = make([]byte, 64)
+	pointEvaluationOutput[30] = fieldElementsPerBlob >> 8
+	kzg.BLSModulus.FillBytes(pointEvaluationOutput[32:])
+}
+
+// PointEvaluation is the eip-4844 kzg point evaluation precompile
+type PointEvaluation struct {
+}
+
+func (p *PointEvaluation) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 50000
+}
+
+func (p *PointEvaluation) Run(input []byte) ([]byte, error) {
+	// versioned_hash (32) || z (32) || y (32) || commitment (48) || proof (48)
+	if len(input) != 192 {
+		return nil, fmt.Errorf("bad length")
+	}
+
+	var (
+		z, y       [32]byte
+		commitment kzg.Commitment
+		proof      kzg.Proof
+	)
+	copy(z[:], input[32:64])
+	copy(y[:], input[64:96])
+	copy(commitment[:], input[96:144])
+	copy(proof[:], input[144:192])
+
+	if versionedHash := kzg.VersionedHash(commitment); string(versionedHash[:]) != string(input[:32]) {
+		return nil, fmt.Errorf("mismatched versioned hash")
+	}
+
+	ok, err := kzg.VerifyProof(commitment, z, y, proof)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("invalid proof")
+	}
+	return pointEvaluationOutput, nil
+}
@@ -0,0 +1,15 @@
+package precompiled
+
+import (
+	"testing"
+
+	"github.com/umbracle/go-evm/evm"
+)
+
+func TestPointEvaluation(t *testing.T) {
+	testPrecompiledFixture(t, &PointEvaluation{}, evm.Cancun, "pointEvaluation.json")
+}
+
+func BenchmarkPointEvaluation(b *testing.B) {
+	benchmarkPrecompiledFixture(b, &PointEvaluation{}, evm.Cancun, "pointEvaluation.json")
+}
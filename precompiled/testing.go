@@ -16,9 +16,18 @@ type TestCase struct {
 }
 
 func ReadTestCase(t *testing.T, path string, f func(t *testing.T, c *TestCase)) {
+	for _, c := range readTestCases(t, path) {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			f(t, c)
+		})
+	}
+}
+
+func readTestCases(tb testing.TB, path string) []*TestCase {
 	data, err := ioutil.ReadFile(filepath.Join("./fixtures", path))
 	if err != nil {
-		t.Fatal(err)
+		tb.Fatal(err)
 	}
 
 	type testCase struct {
@@ -29,21 +38,20 @@ func ReadTestCase(t *testing.T, path string, f func(t *testing.T, c *TestCase))
 	}
 	var cases []*testCase
 	if err := json.Unmarshal(data, &cases); err != nil {
-		t.Fatal(err)
+		tb.Fatal(err)
 	}
 
+	res := make([]*TestCase, 0, len(cases))
 	for _, i := range cases {
 		input, _ := hex.DecodeString(i.Input)
 		expected, _ := hex.DecodeString(i.Expected)
 
-		c := &TestCase{
+		res = append(res, &TestCase{
 			Name:     i.Name,
 			Gas:      i.Gas,
 			Input:    input,
 			Expected: expected,
-		}
-		t.Run(i.Name, func(t *testing.T) {
-			f(t, c)
 		})
 	}
+	return res
 }
@@ -0,0 +1,152 @@
+package state
+
+import (
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/fastrlp"
+)
+
+const (
+	// ReceiptFailed is the status of a receipt of a failed transaction
+	ReceiptFailed uint64 = 0
+
+	// ReceiptSuccess is the status of a receipt of a successful transaction
+	ReceiptSuccess uint64 = 1
+)
+
+// BloomByteLength is the length of the logs bloom filter
+const BloomByteLength = 256
+
+// Bloom is the 2048 bits filter of the addresses and topics of the logs
+// of a receipt or a block
+type Bloom [BloomByteLength]byte
+
+// Add sets the three bits of the value, each one is selected by the low
+// 11 bits of a pair of bytes of the hash of the value
+func (b *Bloom) Add(val []byte) {
+	hash := ethgo.Keccak256(val)
+	for i := 0; i < 6; i += 2 {
+		bit := (uint(hash[i])<<8 | uint(hash[i+1])) & 2047
+		b[BloomByteLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// AddLog adds the address and the topics of the log
+func (b *Bloom) AddLog(log *Log) {
+	b.Add(log.Address[:])
+	for _, topic := range log.Topics {
+		b.Add(topic[:])
+	}
+}
+
+// Or merges the other filter, it is used to build the bloom of a block
+func (b *Bloom) Or(other *Bloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+// Test returns whether the value may be in the filter
+func (b *Bloom) Test(val []byte) bool {
+	var other Bloom
+	other.Add(val)
+	for i := range other {
+		if b[i]&other[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateBloom returns the bloom filter of the logs
+func CreateBloom(logs []*Log) Bloom {
+	var b Bloom
+	for _, log := range logs {
+		b.AddLog(log)
+	}
+	return b
+}
+
+// Receipt is the result of a transaction written in the transition
+type Receipt struct {
+	// Type is the type of the transaction (eip-2718)
+	Type uint8
+
+	Status uint64
+
+	// PostState is the state root after the transaction, it is encoded
+	// instead of the status in the receipts before byzantium (eip-658)
+	PostState []byte
+
+	CumulativeGasUsed uint64
+	GasUsed           uint64
+	Bloom             Bloom
+	Logs              []*Log
+
+	TxHash  evmc.Hash
+	TxIndex uint64
+
+	// ContractAddress is the address of the contract created by the
+	// transaction or nil if it is a call
+	ContractAddress *evmc.Address
+}
+
+// Success returns whether the transaction was successful
+func (r *Receipt) Success() bool {
+	return r.Status == ReceiptSuccess
+}
+
+// MarshalRLP returns the consensus encoding of the receipt (status or post
+// state, cumulative gas, bloom and logs). The receipts of the typed
+// transactions are prefixed with their type (eip-2718).
+func (r *Receipt) MarshalRLP() []byte {
+	a := &fastrlp.Arena{}
+
+	v := r.MarshalRLPWith(a)
+	if r.Type == 0 {
+		return v.MarshalTo(nil)
+	}
+	return v.MarshalTo([]byte{r.Type})
+}
+
+// MarshalRLPWith returns the rlp list of the fields of the receipt without
+// the type prefix
+func (r *Receipt) MarshalRLPWith(a *fastrlp.Arena) *fastrlp.Value {
+	v := a.NewArray()
+	if len(r.PostState) != 0 {
+		v.Set(a.NewCopyBytes(r.PostState))
+	} else {
+		v.Set(a.NewUint(r.Status))
+	}
+	v.Set(a.NewUint(r.CumulativeGasUsed))
+	v.Set(a.NewCopyBytes(r.Bloom[:]))
+	v.Set(marshalLogs(a, r.Logs))
+	return v
+}
+
+func marshalLogs(a *fastrlp.Arena, logs []*Log) *fastrlp.Value {
+	if len(logs) == 0 {
+		return a.NewNullArray()
+	}
+
+	vals := a.NewArray()
+	for _, log := range logs {
+		v := a.NewArray()
+		v.Set(a.NewCopyBytes(log.Address[:]))
+
+		topics := a.NewArray()
+		for _, topic := range log.Topics {
+			topics.Set(a.NewCopyBytes(topic[:]))
+		}
+		v.Set(topics)
+		v.Set(a.NewCopyBytes(log.Data))
+		vals.Set(v)
+	}
+	return vals
+}
+
+// LogsHash returns the hash of the rlp list of the logs
+func LogsHash(logs []*Log) evmc.Hash {
+	a := &fastrlp.Arena{}
+	return evmc.Hash(ethgo.BytesToHash(ethgo.Keccak256(marshalLogs(a, logs).MarshalTo(nil))))
+}
@@ -0,0 +1,109 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/umbracle/fastrlp"
+)
+
+func TestBloom(t *testing.T) {
+	log := &Log{
+		Address: evmc.Address{0x1},
+		Topics:  []evmc.Hash{{0x2}},
+	}
+	bloom := CreateBloom([]*Log{log})
+
+	assert.True(t, bloom.Test(log.Address[:]))
+	assert.True(t, bloom.Test(log.Topics[0][:]))
+	assert.False(t, bloom.Test([]byte{0x3}))
+
+	var block Bloom
+	block.Or(&bloom)
+	assert.Equal(t, bloom, block)
+}
+
+func TestTransition_Receipts(t *testing.T) {
+	// LOG1(0, 0, 0xaa)
+	code := []byte{0x60, 0xaa, 0x60, 0x00, 0x60, 0x00, 0xa1, 0x00}
+
+	transition := newTestTransition(t, code)
+
+	msg := newTestMessage(100000)
+	msg.Hash = evmc.Hash{0x1}
+	output, err := transition.Write(msg)
+	require.NoError(t, err)
+
+	r1 := output.Receipt
+	assert.True(t, r1.Success())
+	assert.Equal(t, uint64(0), r1.TxIndex)
+	assert.Equal(t, msg.Gas-output.GasLeft, r1.GasUsed)
+	assert.Equal(t, r1.GasUsed, r1.CumulativeGasUsed)
+	assert.Nil(t, r1.ContractAddress)
+	assert.True(t, r1.Bloom.Test(testContract[:]))
+
+	msg = newTestMessage(100000)
+	msg.Nonce = 1
+	msg.Hash = evmc.Hash{0x2}
+	msg.Type = 2
+	output, err = transition.Write(msg)
+	require.NoError(t, err)
+
+	r2 := output.Receipt
+	assert.Equal(t, uint64(1), r2.TxIndex)
+	assert.Equal(t, r1.GasUsed+r2.GasUsed, r2.CumulativeGasUsed)
+
+	// the logs are indexed across the transition
+	require.Len(t, r2.Logs, 1)
+	assert.Equal(t, uint64(1), r2.Logs[0].Index)
+	assert.Equal(t, uint64(1), r2.Logs[0].TxIndex)
+	assert.Equal(t, evmc.Hash{0x2}, r2.Logs[0].TxHash)
+
+	// the transaction runs out of gas
+	msg = newTestMessage(21010)
+	msg.Nonce = 2
+	output, err = transition.Write(msg)
+	require.NoError(t, err)
+
+	r3 := output.Receipt
+	assert.False(t, r3.Success())
+	assert.Equal(t, uint64(21010), r3.GasUsed)
+	assert.Empty(t, r3.Logs)
+
+	assert.Equal(t, []*Receipt{r1, r2, r3}, transition.Receipts())
+	assert.Equal(t, r3.CumulativeGasUsed, transition.GasUsed())
+
+	// the receipt of the typed transaction is prefixed with the type
+	raw := r2.MarshalRLP()
+	assert.Equal(t, byte(2), raw[0])
+
+	p := &fastrlp.Parser{}
+	v, err := p.Parse(raw[1:])
+	require.NoError(t, err)
+	elems, err := v.GetElems()
+	require.NoError(t, err)
+	require.Len(t, elems, 4)
+
+	status, _ := elems[0].GetUint64()
+	assert.Equal(t, ReceiptSuccess, status)
+	cumulativeGasUsed, _ := elems[1].GetUint64()
+	assert.Equal(t, r2.CumulativeGasUsed, cumulativeGasUsed)
+	bloom, _ := elems[2].Bytes()
+	assert.Equal(t, r2.Bloom[:], bloom)
+	logs, _ := elems[3].GetElems()
+	assert.Len(t, logs, 1)
+
+	// the legacy receipts are not prefixed
+	assert.Equal(t, r1.MarshalRLPWith(&fastrlp.Arena{}).MarshalTo(nil), r1.MarshalRLP())
+
+	// the post state replaces the status before byzantium
+	r1.PostState = EmptyRootHash[:]
+	v, err = p.Parse(r1.MarshalRLP())
+	require.NoError(t, err)
+	elems, err = v.GetElems()
+	require.NoError(t, err)
+	root, _ := elems[0].Bytes()
+	assert.Equal(t, EmptyRootHash[:], root)
+}
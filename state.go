@@ -74,12 +74,21 @@ type Output struct {
 	GasLeft         uint64
 	ContractAddress evmc.Address
 	ReturnValue     []byte
+
+	// Receipt is the receipt of the transaction, it is only set by Write
+	Receipt *Receipt
 }
 
 type Log struct {
 	Address evmc.Address
 	Topics  []evmc.Hash
 	Data    []byte
+
+	// TxHash, TxIndex and Index are the hash and the position of the
+	// transaction and the position of the log in the transition
+	TxHash  evmc.Hash
+	TxIndex uint64
+	Index   uint64
 }
 
 // Account is an object we can retrieve from the state
@@ -142,19 +151,103 @@ var (
 )
 
 type Message struct {
-	Nonce    uint64
-	GasPrice *big.Int
-	Gas      uint64
-	To       *evmc.Address
-	Value    *big.Int
-	Input    []byte
-	From     evmc.Address
+	// Type is the type of the transaction (eip-2718), it is only used
+	// in the receipt
+	Type uint8
+
+	// Hash is the hash of the transaction, it is only used in the receipt
+	Hash evmc.Hash
+
+	Nonce      uint64
+	GasPrice   *big.Int
+	GasFeeCap  *big.Int
+	GasTipCap  *big.Int
+	Gas        uint64
+	To         *evmc.Address
+	Value      *big.Int
+	Input      []byte
+	From       evmc.Address
+	AccessList AccessList
+
+	// BlobGasFeeCap and BlobHashes are the max fee per blob gas and the
+	// versioned hashes of the blobs of a blob transaction (eip-4844)
+	BlobGasFeeCap *big.Int
+	BlobHashes    []evmc.Hash
+
+	// AuthorizationList is the list of the authorizations of a set code
+	// transaction (eip-7702). They are not supported by the transition yet.
+	AuthorizationList []SetCodeAuthorization
 }
 
 func (t *Message) IsContractCreation() bool {
 	return t.To == nil
 }
 
+// FeeCap returns the max fee per gas of the message. Messages without
+// a fee cap (legacy) use the gas price instead.
+func (t *Message) FeeCap() *big.Int {
+	if t.GasFeeCap != nil {
+		return t.GasFeeCap
+	}
+	return t.GasPrice
+}
+
+// TipCap returns the max priority fee per gas of the message. Messages without
+// a tip cap (legacy) use the gas price instead.
+func (t *Message) TipCap() *big.Int {
+	if t.GasTipCap != nil {
+		return t.GasTipCap
+	}
+	return t.GasPrice
+}
+
+// EffectiveGasPrice returns the price per unit of gas paid by the message (eip-1559).
+// If the base fee is nil the message pays its gas price.
+func (t *Message) EffectiveGasPrice(baseFee *big.Int) *big.Int {
+	if baseFee == nil {
+		if t.GasPrice != nil {
+			return new(big.Int).Set(t.GasPrice)
+		}
+		return new(big.Int).Set(t.FeeCap())
+	}
+
+	// min(tip cap, fee cap - base fee) + base fee
+	tip := new(big.Int).Sub(t.FeeCap(), baseFee)
+	if tipCap := t.TipCap(); tip.Cmp(tipCap) > 0 {
+		tip.Set(tipCap)
+	}
+	return tip.Add(tip, baseFee)
+}
+
+// AccessTuple is an address and the storage keys it accesses (eip-2930)
+type AccessTuple struct {
+	Address     evmc.Address
+	StorageKeys []evmc.Hash
+}
+
+// AccessList is the list of accounts and storage slots pre-warmed by a transaction
+type AccessList []AccessTuple
+
+// StorageKeys returns the total number of storage keys in the access list
+func (a AccessList) StorageKeys() int {
+	num := 0
+	for _, tuple := range a {
+		num += len(tuple.StorageKeys)
+	}
+	return num
+}
+
+// SetCodeAuthorization is the signed authorization of an account to
+// delegate its code to another address (eip-7702)
+type SetCodeAuthorization struct {
+	ChainID *big.Int
+	Address evmc.Address
+	Nonce   uint64
+	V       uint8
+	R       *big.Int
+	S       *big.Int
+}
+
 // Contract is the instance being called
 type Contract struct {
 	Type        evmc.CallKind
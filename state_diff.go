@@ -0,0 +1,199 @@
+package state
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	iradix "github.com/hashicorp/go-immutable-radix"
+)
+
+// AccountState is the state of an account in a state diff
+type AccountState struct {
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+	Storage map[evmc.Hash]evmc.Hash
+}
+
+// AccountDiff is the state of a touched account before and after the
+// execution. A nil state means that the account does not exist.
+type AccountDiff struct {
+	Pre  *AccountState
+	Post *AccountState
+}
+
+// StateDiff is the list of accounts touched by the execution
+type StateDiff map[evmc.Address]*AccountDiff
+
+// trackedSnapshot is a snapshot that records the accounts and
+// the storage slots read by the txn
+type trackedSnapshot struct {
+	Snapshot
+
+	// pre is the radix tree of the txn at the start of the transaction
+	pre *iradix.Tree
+
+	accounts map[evmc.Address]struct{}
+	storage  map[evmc.Address]map[evmc.Hash]struct{}
+}
+
+func (t *trackedSnapshot) GetAccount(addr evmc.Address) (*Account, error) {
+	t.accounts[addr] = struct{}{}
+	return t.Snapshot.GetAccount(addr)
+}
+
+func (t *trackedSnapshot) GetStorage(addr evmc.Address, root evmc.Hash, key evmc.Hash) evmc.Hash {
+	t.trackSlot(addr, key)
+	return t.Snapshot.GetStorage(addr, root, key)
+}
+
+func (t *trackedSnapshot) trackSlot(addr evmc.Address, key evmc.Hash) {
+	slots, ok := t.storage[addr]
+	if !ok {
+		slots = map[evmc.Hash]struct{}{}
+		t.storage[addr] = slots
+	}
+	slots[key] = struct{}{}
+}
+
+// TrackState starts to record the accounts and the storage slots touched
+// by the txn.
+func (txn *Txn) TrackState() {
+	if _, ok := txn.snapshot.(*trackedSnapshot); ok {
+		return
+	}
+	txn.snapshot = &trackedSnapshot{
+		Snapshot: txn.snapshot,
+		pre:      txn.txn.CommitOnly(),
+		accounts: map[evmc.Address]struct{}{},
+		storage:  map[evmc.Address]map[evmc.Hash]struct{}{},
+	}
+}
+
+// checkpointState sets the current state as the pre state of the
+// state diff and resets the touched accounts. It is called at the
+// start of every transaction.
+func (txn *Txn) checkpointState() {
+	tracked, ok := txn.snapshot.(*trackedSnapshot)
+	if !ok {
+		return
+	}
+	tracked.pre = txn.txn.CommitOnly()
+	tracked.accounts = map[evmc.Address]struct{}{}
+	tracked.storage = map[evmc.Address]map[evmc.Hash]struct{}{}
+}
+
+// trackAccount records an account read from the radix tree
+func (txn *Txn) trackAccount(addr evmc.Address) {
+	if tracked, ok := txn.snapshot.(*trackedSnapshot); ok {
+		tracked.accounts[addr] = struct{}{}
+	}
+}
+
+// trackSlot records a storage slot read from the radix tree
+func (txn *Txn) trackSlot(addr evmc.Address, key evmc.Hash) {
+	if tracked, ok := txn.snapshot.(*trackedSnapshot); ok {
+		tracked.trackSlot(addr, key)
+	}
+}
+
+// StateDiff returns the pre state and the current state of every account
+// touched by the last transaction, or since TrackState was called if no
+// transaction was written. With diffOnly the unchanged accounts and storage
+// slots are removed.
+func (txn *Txn) StateDiff(diffOnly bool) StateDiff {
+	tracked, ok := txn.snapshot.(*trackedSnapshot)
+	if !ok {
+		return nil
+	}
+
+	// pre is a read only view of the state at the checkpoint
+	pre := &Txn{
+		snapshot: tracked.Snapshot,
+		txn:      tracked.pre.Txn(),
+		rev:      txn.rev,
+	}
+
+	// the accounts and slots modified without a read are only in the
+	// radix tree. The objects are copied on write, then the objects
+	// that are also in the checkpoint did not change.
+	txn.txn.Root().Walk(func(k []byte, v interface{}) bool {
+		obj, ok := v.(*stateObject)
+		if !ok {
+			return false
+		}
+		if preObj, ok := tracked.pre.Get(k); ok && preObj == obj {
+			return false
+		}
+		var addr evmc.Address
+		copy(addr[:], k)
+
+		tracked.accounts[addr] = struct{}{}
+		if obj.Txn != nil {
+			obj.Txn.Root().Walk(func(k []byte, v interface{}) bool {
+				var val evmc.Hash
+				if v != nil {
+					val = bytesToHash(v.([]byte))
+				}
+				if key := bytesToHash(k); pre.GetState(addr, key) != val {
+					tracked.trackSlot(addr, key)
+				}
+				return false
+			})
+		}
+		return false
+	})
+
+	diff := StateDiff{}
+	for addr := range tracked.accounts {
+		slots := tracked.storage[addr]
+
+		item := &AccountDiff{
+			Pre:  pre.accountState(addr, slots),
+			Post: txn.accountState(addr, slots),
+		}
+
+		if diffOnly && !item.prune() {
+			continue
+		}
+		diff[addr] = item
+	}
+	return diff
+}
+
+// accountState returns the state of the account with the given storage
+// slots or nil if the account does not exist
+func (txn *Txn) accountState(addr evmc.Address, slots map[evmc.Hash]struct{}) *AccountState {
+	if _, ok := txn.getStateObject(addr); !ok {
+		return nil
+	}
+	state := &AccountState{
+		Balance: new(big.Int).Set(txn.GetBalance(addr)),
+		Nonce:   txn.GetNonce(addr),
+		Code:    txn.GetCode(addr),
+		Storage: map[evmc.Hash]evmc.Hash{},
+	}
+	for key := range slots {
+		state.Storage[key] = txn.GetState(addr, key)
+	}
+	return state
+}
+
+// prune removes the storage slots that did not change and
+// returns whether the account changed
+func (a *AccountDiff) prune() bool {
+	if a.Pre == nil || a.Post == nil {
+		return a.Pre != a.Post
+	}
+	for key, val := range a.Pre.Storage {
+		if a.Post.Storage[key] == val {
+			delete(a.Pre.Storage, key)
+			delete(a.Post.Storage, key)
+		}
+	}
+	if len(a.Pre.Storage) != 0 {
+		return true
+	}
+	return a.Pre.Balance.Cmp(a.Post.Balance) != 0 || a.Pre.Nonce != a.Post.Nonce || !bytes.Equal(a.Pre.Code, a.Post.Code)
+}
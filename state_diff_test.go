@@ -0,0 +1,117 @@
+package state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxn_StateDiff(t *testing.T) {
+	// PUSH1 0x1 SLOAD POP PUSH1 0x1 PUSH1 0x2 SSTORE STOP
+	code := []byte{0x60, 0x01, 0x54, 0x50, 0x60, 0x01, 0x60, 0x02, 0x55, 0x00}
+
+	coinbase := evmc.Address{0x3}
+
+	slot1 := bytesToHash([]byte{0x1})
+	slot2 := bytesToHash([]byte{0x2})
+
+	run := func(diffOnly bool) StateDiff {
+		state := newTestState()
+		state.accounts[testSender] = &Account{Balance: big.NewInt(1000000000), CodeHash: EmptyCodeHash[:]}
+		state.setCode(testContract, code)
+		state.setStorage(testContract, slot1, bytesToHash([]byte{0x5}))
+
+		transition := NewTransition(WithState(state), WithContext(TxContext{Coinbase: coinbase}))
+		transition.Txn().TrackState()
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+
+		return transition.Txn().StateDiff(diffOnly)
+	}
+
+	t.Run("Full", func(t *testing.T) {
+		diff := run(false)
+
+		contract := diff[testContract]
+		assert.Equal(t, map[evmc.Hash]evmc.Hash{
+			slot1: bytesToHash([]byte{0x5}),
+			slot2: {},
+		}, contract.Pre.Storage)
+		assert.Equal(t, map[evmc.Hash]evmc.Hash{
+			slot1: bytesToHash([]byte{0x5}),
+			slot2: bytesToHash([]byte{0x1}),
+		}, contract.Post.Storage)
+		assert.Equal(t, code, contract.Pre.Code)
+		assert.Equal(t, code, contract.Post.Code)
+	})
+
+	t.Run("DiffOnly", func(t *testing.T) {
+		diff := run(true)
+		assert.Len(t, diff, 3)
+
+		// only the modified slot is reported
+		contract := diff[testContract]
+		assert.Equal(t, map[evmc.Hash]evmc.Hash{slot2: {}}, contract.Pre.Storage)
+		assert.Equal(t, map[evmc.Hash]evmc.Hash{slot2: bytesToHash([]byte{0x1})}, contract.Post.Storage)
+
+		sender := diff[testSender]
+		assert.Equal(t, uint64(0), sender.Pre.Nonce)
+		assert.Equal(t, uint64(1), sender.Post.Nonce)
+		assert.Equal(t, big.NewInt(1000000000), sender.Pre.Balance)
+
+		// the coinbase did not exist before the transaction
+		assert.Nil(t, diff[coinbase].Pre)
+		assert.NotNil(t, diff[coinbase].Post)
+	})
+}
+
+func TestTxn_StateDiff_PerTransaction(t *testing.T) {
+	// PUSH1 0x1 SLOAD PUSH1 0x1 ADD PUSH1 0x1 SSTORE STOP
+	code := []byte{0x60, 0x01, 0x54, 0x60, 0x01, 0x01, 0x60, 0x01, 0x55, 0x00}
+
+	coinbase := evmc.Address{0x3}
+	slot1 := bytesToHash([]byte{0x1})
+
+	state := newTestState()
+	state.accounts[testSender] = &Account{Balance: big.NewInt(1000000000), CodeHash: EmptyCodeHash[:]}
+	state.setCode(testContract, code)
+
+	transition := NewTransition(WithState(state), WithContext(TxContext{Coinbase: coinbase}))
+	transition.Txn().TrackState()
+
+	output, err := transition.Write(newTestMessage(100000))
+	assert.NoError(t, err)
+	assert.True(t, output.Success)
+
+	diff := transition.Txn().StateDiff(true)
+	assert.Equal(t, map[evmc.Hash]evmc.Hash{slot1: {}}, diff[testContract].Pre.Storage)
+	assert.Equal(t, map[evmc.Hash]evmc.Hash{slot1: bytesToHash([]byte{0x1})}, diff[testContract].Post.Storage)
+	assert.Nil(t, diff[coinbase].Pre)
+
+	msg := newTestMessage(100000)
+	msg.Nonce = 1
+
+	output, err = transition.Write(msg)
+	assert.NoError(t, err)
+	assert.True(t, output.Success)
+
+	// the pre state of the second transaction is the post state of the first one
+	diff = transition.Txn().StateDiff(true)
+	assert.Len(t, diff, 3)
+	assert.Equal(t, map[evmc.Hash]evmc.Hash{slot1: bytesToHash([]byte{0x1})}, diff[testContract].Pre.Storage)
+	assert.Equal(t, map[evmc.Hash]evmc.Hash{slot1: bytesToHash([]byte{0x2})}, diff[testContract].Post.Storage)
+
+	sender := diff[testSender]
+	assert.Equal(t, uint64(1), sender.Pre.Nonce)
+	assert.Equal(t, uint64(2), sender.Post.Nonce)
+
+	assert.NotNil(t, diff[coinbase].Pre)
+
+	// the accounts untouched by the second transaction are not reported
+	full := transition.Txn().StateDiff(false)
+	assert.Len(t, full, 3)
+}
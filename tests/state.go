@@ -0,0 +1,290 @@
+package tests
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/evm"
+	"github.com/umbracle/go-evm/trie"
+)
+
+// StateCase is a test of the GeneralStateTests. Each file has a map of
+// them indexed by the name of the test.
+type StateCase struct {
+	Env         *env                        `json:"env"`
+	Pre         map[argAddr]*GenesisAccount `json:"pre"`
+	Post        map[string]postState        `json:"post"`
+	Transaction *stTransaction              `json:"transaction"`
+}
+
+// StateSubtest is one of the post states of a test
+type StateSubtest struct {
+	Fork  string
+	Index int
+}
+
+// Subtests returns the post states of the test sorted by fork and index
+func (c *StateCase) Subtests() []StateSubtest {
+	subtests := []StateSubtest{}
+	for fork, post := range c.Post {
+		for index := range post {
+			subtests = append(subtests, StateSubtest{Fork: fork, Index: index})
+		}
+	}
+	sort.Slice(subtests, func(i, j int) bool {
+		if subtests[i].Fork != subtests[j].Fork {
+			return subtests[i].Fork < subtests[j].Fork
+		}
+		return subtests[i].Index < subtests[j].Index
+	})
+	return subtests
+}
+
+// StateResult is the result of running a subtest
+type StateResult struct {
+	Name  string
+	Fork  string
+	Index int
+	Pass  bool
+
+	Root         evmc.Hash
+	ExpectedRoot evmc.Hash
+	Logs         evmc.Hash
+	ExpectedLogs evmc.Hash
+
+	// Error is the error of the transaction or the reason of the failure
+	Error string
+}
+
+type stateResultJSON struct {
+	Name         string `json:"name"`
+	Fork         string `json:"fork"`
+	Index        int    `json:"index"`
+	Pass         bool   `json:"pass"`
+	Root         string `json:"stateRoot"`
+	ExpectedRoot string `json:"expectedStateRoot,omitempty"`
+	Logs         string `json:"logsHash"`
+	ExpectedLogs string `json:"expectedLogsHash,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. The expected
+// hashes are only included if they do not match.
+func (r *StateResult) MarshalJSON() ([]byte, error) {
+	obj := &stateResultJSON{
+		Name:  r.Name,
+		Fork:  r.Fork,
+		Index: r.Index,
+		Pass:  r.Pass,
+		Root:  "0x" + hex.EncodeToString(r.Root[:]),
+		Logs:  "0x" + hex.EncodeToString(r.Logs[:]),
+		Error: r.Error,
+	}
+	if r.Root != r.ExpectedRoot {
+		obj.ExpectedRoot = "0x" + hex.EncodeToString(r.ExpectedRoot[:])
+	}
+	if r.Logs != r.ExpectedLogs {
+		obj.ExpectedLogs = "0x" + hex.EncodeToString(r.ExpectedLogs[:])
+	}
+	return json.Marshal(obj)
+}
+
+// Run runs a subtest of the test. The tracer is optional.
+func (c *StateCase) Run(name string, subtest StateSubtest, tracer evm.Tracer) *StateResult {
+	res := &StateResult{
+		Name:  name,
+		Fork:  subtest.Fork,
+		Index: subtest.Index,
+	}
+
+	post, ok := c.Post[subtest.Fork]
+	if !ok || subtest.Index < 0 || subtest.Index >= len(post) {
+		res.Error = fmt.Sprintf("subtest %s %d not found", subtest.Fork, subtest.Index)
+		return res
+	}
+	p := post[subtest.Index]
+	res.ExpectedRoot = evmc.Hash(p.Root)
+	res.ExpectedLogs = evmc.Hash(p.Logs)
+
+	// find the fork
+	chainConfig, ok := state.ForkChainConfig(subtest.Fork)
+	if !ok {
+		res.Error = fmt.Sprintf("config %s not found", subtest.Fork)
+		return res
+	}
+
+	msg, err := c.Transaction.At(p.Indexes)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	wr := newWrapper(c.Pre)
+
+	opts := []state.ConfigOption{
+		state.WithChainConfig(chainConfig),
+		state.WithContext(c.Env.ToEnv()),
+		state.WithState(wr),
+	}
+	if tracer != nil {
+		opts = append(opts, state.WithTracer(tracer))
+	}
+	transition := state.NewTransition(opts...)
+
+	var logs []*state.Log
+
+	result, err := transition.Write(msg)
+	if err != nil {
+		// an invalid transaction does not change the state
+		res.Error = err.Error()
+	} else {
+		logs = result.Logs
+	}
+
+	objs := transition.Commit()
+	res.Root = computeRoot(c.Pre, objs)
+	res.Logs = state.LogsHash(logs)
+
+	switch {
+	case err != nil && p.ExpectException == "":
+		// the error is already set
+	case err == nil && p.ExpectException != "":
+		res.Error = fmt.Sprintf("expected exception %s", p.ExpectException)
+	case res.Root != res.ExpectedRoot:
+		res.Error = "root mismatch"
+	case res.Logs != res.ExpectedLogs:
+		res.Error = "logs mismatch"
+	default:
+		res.Pass = true
+	}
+	return res
+}
+
+type wrapper struct {
+	cc map[argAddr]*GenesisAccount
+}
+
+func newWrapper(cc map[argAddr]*GenesisAccount) *wrapper {
+	w := &wrapper{
+		cc: cc,
+	}
+	return w
+}
+
+func (w *wrapper) GetStorage(addr evmc.Address, root evmc.Hash, key evmc.Hash) evmc.Hash {
+	if root == state.EmptyRootHash {
+		return evmc.Hash{}
+	}
+	acct, ok := w.cc[argAddr(addr)]
+	if !ok {
+		return evmc.Hash{}
+	}
+	val, ok := acct.Storage[argHash(key)]
+	if !ok {
+		return evmc.Hash{}
+	}
+	return evmc.Hash(val)
+}
+
+func (w *wrapper) GetAccount(addr evmc.Address) (*state.Account, error) {
+	acct, ok := w.cc[argAddr(addr)]
+	if !ok {
+		return nil, nil
+	}
+	if acct == nil {
+		return nil, nil
+	}
+	newAcct := &state.Account{
+		Balance:  acct.Balance.Big(),
+		Nonce:    acct.Nonce.Uint64(),
+		CodeHash: ethgo.Keccak256(acct.Code),
+		Root:     evmc.Hash{},
+		Code:     acct.Code,
+	}
+	return newAcct, nil
+}
+
+var zeroHash = argHash{}
+
+func computeRoot(pre map[argAddr]*GenesisAccount, post []*state.Object) evmc.Hash {
+
+	resMap := map[evmc.Address]*state.Object{}
+
+	// add pre data
+	for addr, data := range pre {
+		obj := &state.Object{
+			Address:  evmc.Address(addr),
+			Nonce:    data.Nonce.Uint64(),
+			Balance:  data.Balance.Big(),
+			Root:     state.EmptyRootHash,
+			CodeHash: state.EmptyCodeHash,
+			Storage:  []*state.StorageObject{},
+		}
+		if len(data.Code) != 0 {
+			obj.Code = data.Code
+			copy(obj.CodeHash[:], ethgo.Keccak256(data.Code))
+		}
+		for k, v := range data.Storage {
+			key := append([]byte{}, k[:]...)
+			val := append([]byte{}, v[:]...)
+
+			entry := &state.StorageObject{
+				Key: key,
+			}
+			if v == zeroHash {
+				entry.Deleted = true
+			} else {
+				entry.Val = val
+			}
+			obj.Storage = append(obj.Storage, entry)
+		}
+		resMap[evmc.Address(addr)] = obj
+
+	}
+
+	// merge post data
+	for _, raw := range post {
+		var obj *state.Object
+		var ok bool
+
+		if obj, ok = resMap[raw.Address]; !ok {
+			obj = &state.Object{
+				Address: raw.Address,
+				Root:    state.EmptyRootHash,
+				Storage: []*state.StorageObject{},
+
+				// in this case the object already set the hash
+				Code:     raw.Code,
+				CodeHash: raw.CodeHash,
+			}
+			resMap[raw.Address] = obj
+		}
+
+		obj.Nonce = raw.Nonce
+		obj.Balance = raw.Balance
+		obj.Deleted = raw.Deleted
+		obj.CodeHash = raw.CodeHash
+		obj.DirtyCode = raw.DirtyCode
+
+		if obj.DirtyCode {
+			// if the storage is set, all the state is gone
+			obj.Storage = []*state.StorageObject{}
+		}
+
+		// we just override the values since trie will handle it
+		obj.Storage = append(obj.Storage, raw.Storage...)
+	}
+
+	// convert to array
+	objs := []*state.Object{}
+	for _, obj := range resMap {
+		objs = append(objs, obj)
+	}
+
+	return trie.StateRoot(objs)
+}
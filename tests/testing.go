@@ -9,7 +9,6 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
-	"testing"
 
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 	"github.com/umbracle/ethgo/wallet"
@@ -26,15 +25,17 @@ type env struct {
 	GasLimit   argUint64 `json:"currentGasLimit"`
 	Number     argUint64 `json:"currentNumber"`
 	Timestamp  argUint64 `json:"currentTimestamp"`
+	BaseFee    argHash   `json:"currentBaseFee"`
 }
 
-func (e *env) ToEnv(t *testing.T) state.TxContext {
+func (e *env) ToEnv() state.TxContext {
 	return state.TxContext{
 		Coinbase:   evmc.Address(e.Coinbase),
 		Difficulty: evmc.Hash(e.Difficulty),
 		GasLimit:   int64(e.GasLimit.Uint64()),
 		Number:     int64(e.Number.Uint64()),
 		Timestamp:  int64(e.Timestamp.Uint64()),
+		BaseFee:    evmc.Hash(e.BaseFee),
 	}
 }
 
@@ -45,21 +46,30 @@ type indexes struct {
 }
 
 type postEntry struct {
-	Root    argHash `json:"hash"`
-	Logs    argHash `json:"logs"`
-	Indexes indexes `json:"indexes"`
+	Root            argHash `json:"hash"`
+	Logs            argHash `json:"logs"`
+	Indexes         indexes `json:"indexes"`
+	ExpectException string  `json:"expectException"`
 }
 
 type postState []postEntry
 
+type stAccessTuple struct {
+	Address     argAddr   `json:"address"`
+	StorageKeys []argHash `json:"storageKeys"`
+}
+
 type stTransaction struct {
-	Data      []argBytes  `json:"data"`
-	GasLimit  []argUint64 `json:"gasLimit"`
-	Value     []argBig    `json:"value"`
-	GasPrice  argBig      `json:"gasPrice"`
-	Nonce     argUint64   `json:"nonce"`
-	SecretKey argBytes    `json:"secretKey"`
-	To        string      `json:"to"`
+	Data        []argBytes        `json:"data"`
+	AccessLists [][]stAccessTuple `json:"accessLists"`
+	GasLimit    []argUint64       `json:"gasLimit"`
+	Value       []argBig          `json:"value"`
+	GasPrice    argBig            `json:"gasPrice"`
+	GasFeeCap   *argBig           `json:"maxFeePerGas"`
+	GasTipCap   *argBig           `json:"maxPriorityFeePerGas"`
+	Nonce       argUint64         `json:"nonce"`
+	SecretKey   argBytes          `json:"secretKey"`
+	To          string            `json:"to"`
 }
 
 func (t *stTransaction) At(i indexes) (*state.Message, error) {
@@ -80,6 +90,23 @@ func (t *stTransaction) At(i indexes) (*state.Message, error) {
 		GasPrice: t.GasPrice.Big(),
 		Input:    t.Data[i.Data],
 	}
+	if t.GasFeeCap != nil {
+		msg.GasFeeCap = t.GasFeeCap.Big()
+	}
+	if t.GasTipCap != nil {
+		msg.GasTipCap = t.GasTipCap.Big()
+	}
+	if i.Data < len(t.AccessLists) {
+		for _, tuple := range t.AccessLists[i.Data] {
+			entry := state.AccessTuple{
+				Address: evmc.Address(tuple.Address),
+			}
+			for _, key := range tuple.StorageKeys {
+				entry.StorageKeys = append(entry.StorageKeys, evmc.Hash(key))
+			}
+			msg.AccessList = append(msg.AccessList, entry)
+		}
+	}
 	if t.To != "" {
 		buf, err := hex.DecodeString(strings.TrimPrefix(t.To, "0x"))
 		if err != nil {
@@ -103,61 +130,6 @@ func (t *stTransaction) At(i indexes) (*state.Message, error) {
 	return msg, nil
 }
 
-// forks
-
-type blockB func(i int) evmc.Revision
-
-var Forks2 = map[string]blockB{
-	"Frontier": func(i int) evmc.Revision {
-		return evmc.Frontier
-	},
-	"Homestead": func(i int) evmc.Revision {
-		return evmc.Homestead
-	},
-	"EIP150": func(i int) evmc.Revision {
-		return evmc.TangerineWhistle
-	},
-	"EIP158": func(i int) evmc.Revision {
-		return evmc.SpuriousDragon
-	},
-	"Byzantium": func(i int) evmc.Revision {
-		return evmc.Byzantium
-	},
-	"Constantinople": func(i int) evmc.Revision {
-		return evmc.Constantinople
-	},
-	"ConstantinopleFix": func(i int) evmc.Revision {
-		return evmc.Petersburg
-	},
-	"Istanbul": func(i int) evmc.Revision {
-		return evmc.Istanbul
-	},
-	"FrontierToHomesteadAt5": func(i int) evmc.Revision {
-		if i < 5 {
-			return evmc.Frontier
-		}
-		return evmc.Homestead
-	},
-	"HomesteadToEIP150At5": func(i int) evmc.Revision {
-		if i < 5 {
-			return evmc.Homestead
-		}
-		return evmc.TangerineWhistle
-	},
-	"EIP158ToByzantiumAt5": func(i int) evmc.Revision {
-		if i < 5 {
-			return evmc.SpuriousDragon
-		}
-		return evmc.Byzantium
-	},
-	"ByzantiumToConstantinopleAt5": func(i int) evmc.Revision {
-		if i < 5 {
-			return evmc.Byzantium
-		}
-		return evmc.Constantinople
-	},
-}
-
 func contains(l []string, name string) bool {
 	for _, i := range l {
 		if strings.Contains(name, i) {
@@ -0,0 +1,261 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
+)
+
+// CallFrame is a call of the execution with its nested calls
+type CallFrame struct {
+	Type         string
+	From         evmc.Address
+	To           evmc.Address
+	Value        *big.Int
+	Gas          uint64
+	GasUsed      uint64
+	Input        []byte
+	Output       []byte
+	Error        string
+	RevertReason string
+	Precompile   bool
+	Cheatcode    bool
+	Calls        []*CallFrame
+}
+
+type callFrameJSON struct {
+	Type         string       `json:"type"`
+	From         string       `json:"from"`
+	To           string       `json:"to"`
+	Value        string       `json:"value,omitempty"`
+	Gas          string       `json:"gas"`
+	GasUsed      string       `json:"gasUsed"`
+	Input        string       `json:"input"`
+	Output       string       `json:"output,omitempty"`
+	Error        string       `json:"error,omitempty"`
+	RevertReason string       `json:"revertReason,omitempty"`
+	Precompile   bool         `json:"precompile,omitempty"`
+	Cheatcode    bool         `json:"cheatcode,omitempty"`
+	Calls        []*CallFrame `json:"calls,omitempty"`
+}
+
+// MarshalJSON implements the json.Marshaler interface with the format
+// of the geth callTracer
+func (c *CallFrame) MarshalJSON() ([]byte, error) {
+	obj := &callFrameJSON{
+		Type:         c.Type,
+		From:         encodeAddress(c.From),
+		To:           encodeAddress(c.To),
+		Gas:          encodeUint64(c.Gas),
+		GasUsed:      encodeUint64(c.GasUsed),
+		Input:        "0x" + hex.EncodeToString(c.Input),
+		Error:        c.Error,
+		RevertReason: c.RevertReason,
+		Precompile:   c.Precompile,
+		Cheatcode:    c.Cheatcode,
+		Calls:        c.Calls,
+	}
+	if c.Value != nil {
+		obj.Value = "0x" + c.Value.Text(16)
+	}
+	if len(c.Output) != 0 {
+		obj.Output = "0x" + hex.EncodeToString(c.Output)
+	}
+	return json.Marshal(obj)
+}
+
+// callTracerHost is the part of the host used to mark the frames
+// of precompiles and cheatcodes
+type callTracerHost interface {
+	IsPrecompiled(addr evmc.Address) bool
+	IsCheatcode(addr evmc.Address) bool
+}
+
+// CallTracer records the tree of calls of the execution in the format
+// of the geth callTracer
+type CallTracer struct {
+	host callTracerHost
+
+	// stack is the list of open frames, the first one is the root
+	stack []*CallFrame
+	root  *CallFrame
+
+	// static is whether each open frame is static
+	static []bool
+}
+
+// NewCallTracer creates a call tracer
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+func (c *CallTracer) CaptureStart(host evm.Host, from, to evmc.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	c.host, _ = host.(callTracerHost)
+
+	typ := evmc.Call
+	if create {
+		typ = evmc.Create
+	}
+	c.enter(typ, from, to, input, gas, value, false)
+}
+
+func (c *CallTracer) CaptureState(pc uint64, op evm.OpCode, gas, cost, refund uint64, stack []*big.Int, memory []byte, memSize int, depth int) {
+}
+
+func (c *CallTracer) CaptureFault(pc uint64, op evm.OpCode, gas, cost uint64, depth int, err error) {
+}
+
+func (c *CallTracer) CaptureEnter(typ evmc.CallKind, from, to evmc.Address, input []byte, gas uint64, value *big.Int, static bool) {
+	c.enter(typ, from, to, input, gas, value, static)
+}
+
+func (c *CallTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	c.exit(output, gasUsed, err)
+}
+
+func (c *CallTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	c.exit(output, gasUsed, err)
+}
+
+func (c *CallTracer) CaptureSelfDestruct(addr, beneficiary evmc.Address, balance *big.Int) {
+	if len(c.stack) == 0 {
+		return
+	}
+	parent := c.stack[len(c.stack)-1]
+	parent.Calls = append(parent.Calls, &CallFrame{
+		Type:  "SELFDESTRUCT",
+		From:  addr,
+		To:    beneficiary,
+		Value: new(big.Int).Set(balance),
+		Input: []byte{},
+	})
+}
+
+// Result returns the root frame of the execution. The gas of the root frame
+// does not include the intrinsic gas of the transaction.
+func (c *CallTracer) Result() *CallFrame {
+	return c.root
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (c *CallTracer) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.root)
+}
+
+func (c *CallTracer) enter(typ evmc.CallKind, from, to evmc.Address, input []byte, gas uint64, value *big.Int, static bool) {
+	frame := &CallFrame{
+		Type:  callKindToString(typ),
+		From:  from,
+		To:    to,
+		Gas:   gas,
+		Input: append([]byte{}, input...),
+	}
+	// a static call from a frame that is not static is a STATICCALL. The
+	// STATICCALLs of a static frame cannot be told apart from its CALLs.
+	if typ == evmc.Call && static && !c.inStatic() {
+		frame.Type = "STATICCALL"
+		value = nil
+	}
+	if value != nil {
+		frame.Value = new(big.Int).Set(value)
+	}
+	if c.host != nil && typ != evmc.Create && typ != evmc.Create2 {
+		// the cheatcodes run before the precompiles
+		if c.host.IsCheatcode(to) {
+			frame.Cheatcode = true
+		} else if c.host.IsPrecompiled(to) {
+			frame.Precompile = true
+		}
+	}
+
+	if len(c.stack) == 0 {
+		c.root = frame
+	} else {
+		parent := c.stack[len(c.stack)-1]
+		parent.Calls = append(parent.Calls, frame)
+	}
+	c.stack = append(c.stack, frame)
+	c.static = append(c.static, static)
+}
+
+// inStatic returns whether the current frame is static
+func (c *CallTracer) inStatic() bool {
+	return len(c.static) != 0 && c.static[len(c.static)-1]
+}
+
+func (c *CallTracer) exit(output []byte, gasUsed uint64, err error) {
+	if len(c.stack) == 0 {
+		return
+	}
+	frame := c.stack[len(c.stack)-1]
+	c.stack = c.stack[:len(c.stack)-1]
+	c.static = c.static[:len(c.static)-1]
+
+	frame.GasUsed = gasUsed
+	frame.Output = append([]byte{}, output...)
+	if err != nil {
+		frame.Error = err.Error()
+		if err == evm.ErrExecutionReverted {
+			frame.RevertReason = unpackRevertReason(output)
+		} else {
+			frame.Output = nil
+		}
+	}
+}
+
+func callKindToString(typ evmc.CallKind) string {
+	switch typ {
+	case evmc.Call:
+		return "CALL"
+	case evmc.DelegateCall:
+		return "DELEGATECALL"
+	case evmc.CallCode:
+		return "CALLCODE"
+	case evmc.Create:
+		return "CREATE"
+	case evmc.Create2:
+		return "CREATE2"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// revertSelector is the selector of Error(string)
+var revertSelector = []byte{0x08, 0xc3, 0x79, 0xa0}
+
+// unpackRevertReason decodes the string of a revert with Error(string)
+func unpackRevertReason(output []byte) string {
+	if len(output) < 4+64 || !bytes.Equal(output[:4], revertSelector) {
+		return ""
+	}
+	data := output[4:]
+
+	offset, ok := decodeAbiUint(data[:32])
+	if !ok || offset+32 > uint64(len(data)) {
+		return ""
+	}
+	size, ok := decodeAbiUint(data[offset : offset+32])
+	if !ok || offset+32+size > uint64(len(data)) {
+		return ""
+	}
+	return string(data[offset+32 : offset+32+size])
+}
+
+// decodeAbiUint decodes an abi word that fits in 32 bits
+func decodeAbiUint(word []byte) (uint64, bool) {
+	for _, b := range word[:28] {
+		if b != 0 {
+			return 0, false
+		}
+	}
+	return uint64(binary.BigEndian.Uint32(word[28:])), true
+}
+
+func encodeAddress(addr evmc.Address) string {
+	return "0x" + hex.EncodeToString(addr[:])
+}
@@ -0,0 +1,88 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	state "github.com/umbracle/go-evm"
+)
+
+type testCheatcode struct {
+	addr evmc.Address
+}
+
+func (c *testCheatcode) CanRun(addr evmc.Address) bool {
+	return addr == c.addr
+}
+
+func (c *testCheatcode) Run(addr evmc.Address, input []byte) {
+}
+
+func TestCallTracer(t *testing.T) {
+	callee := evmc.Address{19: 0xaa}
+	beneficiary := evmc.Address{19: 0xbb}
+	cheatcode := evmc.Address{19: 0xcc}
+
+	code := []byte{
+		// CALL(0xffff, 0x4, 0, 0, 0, 0, 0) POP
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x04, 0x61, 0xff, 0xff, 0xf1, 0x50,
+		// CALL(0xffff, 0xcc, 0, 0, 0, 0, 0) POP
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0xcc, 0x61, 0xff, 0xff, 0xf1, 0x50,
+		// STATICCALL(0xffff, 0xaa, 0, 0, 0, 0) POP
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0xaa, 0x61, 0xff, 0xff, 0xfa, 0x50,
+		// SELFDESTRUCT(0xbb)
+		0x60, 0xbb, 0xff,
+	}
+
+	// revert with Error("no")
+	revertCode, _ := hex.DecodeString("7f08c379a0000000000000000000000000000000000000000000000000000000006000527f00000020000000000000000000000000000000000000000000000000000000006020527f000000026e6f00000000000000000000000000000000000000000000000000006040527f000000000000000000000000000000000000000000000000000000000000000060605260646000fd")
+
+	// the callee calls the identity precompile before the revert
+	revertCode = append([]byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x04, 0x61, 0xff, 0xff, 0xf1, 0x50}, revertCode...)
+
+	tracer := NewCallTracer()
+	runTestTracerWithCode(t, map[evmc.Address][]byte{testContract: code, callee: revertCode}, tracer, state.WithCheatcode(&testCheatcode{addr: cheatcode}))
+
+	root := tracer.Result()
+	assert.Equal(t, "CALL", root.Type)
+	assert.Equal(t, testSender, root.From)
+	assert.Equal(t, testContract, root.To)
+	assert.Empty(t, root.Error)
+	assert.Len(t, root.Calls, 4)
+
+	precompile := root.Calls[0]
+	assert.Equal(t, "CALL", precompile.Type)
+	assert.Equal(t, evmc.Address{19: 0x4}, precompile.To)
+	assert.True(t, precompile.Precompile)
+	assert.Equal(t, uint64(15), precompile.GasUsed)
+
+	cheat := root.Calls[1]
+	assert.True(t, cheat.Cheatcode)
+	assert.False(t, cheat.Precompile)
+	assert.Equal(t, uint64(0), cheat.GasUsed)
+
+	static := root.Calls[2]
+	assert.Equal(t, "STATICCALL", static.Type)
+	assert.Equal(t, testContract, static.From)
+	assert.Equal(t, callee, static.To)
+	assert.Nil(t, static.Value)
+	assert.Equal(t, "execution was reverted", static.Error)
+	assert.Equal(t, "no", static.RevertReason)
+	assert.Len(t, static.Output, 100)
+
+	// the calls of a static frame are static
+	assert.Len(t, static.Calls, 1)
+	assert.Equal(t, "CALL", static.Calls[0].Type)
+
+	selfdestruct := root.Calls[3]
+	assert.Equal(t, "SELFDESTRUCT", selfdestruct.Type)
+	assert.Equal(t, testContract, selfdestruct.From)
+	assert.Equal(t, beneficiary, selfdestruct.To)
+
+	data, err := json.Marshal(selfdestruct)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"type":"SELFDESTRUCT","from":"0x0200000000000000000000000000000000000000","to":"0x00000000000000000000000000000000000000bb","value":"0x0","gas":"0x0","gasUsed":"0x0","input":"0x"}`, string(data))
+}
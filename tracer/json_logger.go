@@ -0,0 +1,131 @@
+package tracer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
+)
+
+// JSONLogger streams every step of the execution as a line of json
+// in the eip-3155 format
+type JSONLogger struct {
+	cfg Config
+	enc *json.Encoder
+
+	// pending is the last step, it is written once it is known
+	// whether the opcode failed
+	pending *jsonLog
+}
+
+// NewJSONLogger creates a json logger that writes to w. The storage
+// is not part of the eip-3155 format and it is never captured.
+func NewJSONLogger(cfg *Config, w io.Writer) *JSONLogger {
+	l := &JSONLogger{
+		enc: json.NewEncoder(w),
+	}
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	return l
+}
+
+type jsonLog struct {
+	Pc      uint64   `json:"pc"`
+	Op      byte     `json:"op"`
+	Gas     string   `json:"gas"`
+	GasCost string   `json:"gasCost"`
+	Memory  string   `json:"memory,omitempty"`
+	MemSize int      `json:"memSize"`
+	Stack   []string `json:"stack"`
+	Depth   int      `json:"depth"`
+	Refund  uint64   `json:"refund"`
+	OpName  string   `json:"opName"`
+	Error   string   `json:"error,omitempty"`
+}
+
+type jsonEndLog struct {
+	Output  string `json:"output"`
+	GasUsed string `json:"gasUsed"`
+	Error   string `json:"error,omitempty"`
+}
+
+func (l *JSONLogger) CaptureStart(host evm.Host, from, to evmc.Address, create bool, input []byte, gas uint64, value *big.Int) {
+}
+
+func (l *JSONLogger) CaptureState(pc uint64, op evm.OpCode, gas, cost, refund uint64, stack []*big.Int, memory []byte, memSize int, depth int) {
+	l.flush()
+
+	log := &jsonLog{
+		Pc:      pc,
+		Op:      byte(op),
+		Gas:     encodeUint64(gas),
+		GasCost: encodeUint64(cost),
+		MemSize: memSize,
+		Depth:   depth + 1,
+		Refund:  refund,
+		OpName:  op.String(),
+	}
+	if !l.cfg.DisableStack {
+		log.Stack = formatStack(stack)
+	}
+	if !l.cfg.DisableMemory {
+		log.Memory = "0x" + hex.EncodeToString(memory)
+	}
+	l.pending = log
+}
+
+func (l *JSONLogger) CaptureStack() bool {
+	return !l.cfg.DisableStack
+}
+
+func (l *JSONLogger) CaptureMemory() bool {
+	return !l.cfg.DisableMemory
+}
+
+func (l *JSONLogger) CaptureFault(pc uint64, op evm.OpCode, gas, cost uint64, depth int, err error) {
+	if l.pending != nil && err != evm.ErrExecutionReverted {
+		l.pending.Error = err.Error()
+	}
+	l.flush()
+}
+
+func (l *JSONLogger) CaptureEnter(typ evmc.CallKind, from, to evmc.Address, input []byte, gas uint64, value *big.Int, static bool) {
+	l.flush()
+}
+
+func (l *JSONLogger) CaptureExit(output []byte, gasUsed uint64, err error) {
+	l.flush()
+}
+
+func (l *JSONLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	l.flush()
+
+	log := &jsonEndLog{
+		Output:  hex.EncodeToString(output),
+		GasUsed: encodeUint64(gasUsed),
+	}
+	if err != nil {
+		log.Error = err.Error()
+	}
+	l.enc.Encode(log)
+}
+
+func (l *JSONLogger) CaptureSelfDestruct(addr, beneficiary evmc.Address, balance *big.Int) {
+}
+
+func (l *JSONLogger) flush() {
+	if l.pending == nil {
+		return
+	}
+	l.enc.Encode(l.pending)
+	l.pending = nil
+}
+
+func encodeUint64(i uint64) string {
+	return "0x" + strconv.FormatUint(i, 16)
+}
@@ -0,0 +1,243 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/go-evm/evm"
+)
+
+// Config are the capture options of the loggers
+type Config struct {
+	DisableMemory  bool
+	DisableStack   bool
+	DisableStorage bool
+}
+
+// StructLog is a step of the execution
+type StructLog struct {
+	Pc      uint64
+	Op      evm.OpCode
+	Gas     uint64
+	GasCost uint64
+	Depth   int
+	Stack   []*big.Int
+	Memory  []byte
+	Storage map[evmc.Hash]evmc.Hash
+	Refund  uint64
+	Err     error
+}
+
+// StructLogger records every step of the execution in the format
+// of the geth struct logger used by debug_traceTransaction
+type StructLogger struct {
+	cfg  Config
+	host evm.Host
+
+	// contracts is the address whose storage is accessed at each depth
+	contracts []evmc.Address
+	storage   map[evmc.Address]map[evmc.Hash]evmc.Hash
+
+	logs    []*StructLog
+	output  []byte
+	gasUsed uint64
+	err     error
+}
+
+// NewStructLogger creates a struct logger, a nil config captures everything
+func NewStructLogger(cfg *Config) *StructLogger {
+	l := &StructLogger{
+		storage: map[evmc.Address]map[evmc.Hash]evmc.Hash{},
+	}
+	if cfg != nil {
+		l.cfg = *cfg
+	}
+	return l
+}
+
+func (l *StructLogger) CaptureStart(host evm.Host, from, to evmc.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	l.host = host
+	l.contracts = append(l.contracts, to)
+}
+
+func (l *StructLogger) CaptureState(pc uint64, op evm.OpCode, gas, cost, refund uint64, stack []*big.Int, memory []byte, memSize int, depth int) {
+	log := &StructLog{
+		Pc:      pc,
+		Op:      op,
+		Gas:     gas,
+		GasCost: cost,
+		Depth:   depth + 1,
+		Refund:  refund,
+	}
+	if !l.cfg.DisableStack {
+		log.Stack = stack
+	}
+	if !l.cfg.DisableMemory {
+		log.Memory = memory
+	}
+	if !l.cfg.DisableStorage && (op == evm.SLOAD || op == evm.SSTORE) && len(stack) >= 1 {
+		addr := l.contracts[len(l.contracts)-1]
+		storage, ok := l.storage[addr]
+		if !ok {
+			storage = map[evmc.Hash]evmc.Hash{}
+			l.storage[addr] = storage
+		}
+
+		key := bigToHash(stack[len(stack)-1])
+		if op == evm.SLOAD {
+			storage[key] = l.host.GetStorage(addr, key)
+		} else if len(stack) >= 2 {
+			storage[key] = bigToHash(stack[len(stack)-2])
+		}
+
+		log.Storage = make(map[evmc.Hash]evmc.Hash, len(storage))
+		for k, v := range storage {
+			log.Storage[k] = v
+		}
+	}
+	l.logs = append(l.logs, log)
+}
+
+// CaptureStack returns whether the stack is captured, the storage is
+// captured from the keys and the values in the stack
+func (l *StructLogger) CaptureStack() bool {
+	return !l.cfg.DisableStack || !l.cfg.DisableStorage
+}
+
+func (l *StructLogger) CaptureMemory() bool {
+	return !l.cfg.DisableMemory
+}
+
+func (l *StructLogger) CaptureFault(pc uint64, op evm.OpCode, gas, cost uint64, depth int, err error) {
+	// like geth, a revert is only reported in the result
+	if err == evm.ErrExecutionReverted {
+		return
+	}
+	for i := len(l.logs) - 1; i >= 0; i-- {
+		if l.logs[i].Depth == depth+1 {
+			l.logs[i].Err = err
+			return
+		}
+	}
+}
+
+func (l *StructLogger) CaptureEnter(typ evmc.CallKind, from, to evmc.Address, input []byte, gas uint64, value *big.Int, static bool) {
+	// delegatecall and callcode run with the storage of the caller
+	if typ == evmc.DelegateCall || typ == evmc.CallCode {
+		to = l.contracts[len(l.contracts)-1]
+	}
+	l.contracts = append(l.contracts, to)
+}
+
+func (l *StructLogger) CaptureExit(output []byte, gasUsed uint64, err error) {
+	l.contracts = l.contracts[:len(l.contracts)-1]
+}
+
+func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	l.output = append([]byte{}, output...)
+	l.gasUsed = gasUsed
+	l.err = err
+}
+
+func (l *StructLogger) CaptureTxEnd(gasUsed uint64) {
+	l.gasUsed = gasUsed
+}
+
+func (l *StructLogger) CaptureSelfDestruct(addr, beneficiary evmc.Address, balance *big.Int) {
+}
+
+// StructLogs returns the recorded steps
+func (l *StructLogger) StructLogs() []*StructLog {
+	return l.logs
+}
+
+// ExecutionResult is the debug_traceTransaction result of the struct logger
+type ExecutionResult struct {
+	Gas         uint64         `json:"gas"`
+	Failed      bool           `json:"failed"`
+	ReturnValue string         `json:"returnValue"`
+	StructLogs  []StructLogRes `json:"structLogs"`
+}
+
+// StructLogRes is the json format of a step
+type StructLogRes struct {
+	Pc            uint64             `json:"pc"`
+	Op            string             `json:"op"`
+	Gas           uint64             `json:"gas"`
+	GasCost       uint64             `json:"gasCost"`
+	Depth         int                `json:"depth"`
+	Error         string             `json:"error,omitempty"`
+	Stack         *[]string          `json:"stack,omitempty"`
+	Memory        *[]string          `json:"memory,omitempty"`
+	Storage       *map[string]string `json:"storage,omitempty"`
+	RefundCounter uint64             `json:"refund,omitempty"`
+}
+
+// Result returns the trace of the execution. Like geth, the gas is the gas
+// used by the transaction, or only by the execution if it is not written
+// as a transaction.
+func (l *StructLogger) Result() *ExecutionResult {
+	res := &ExecutionResult{
+		Gas:        l.gasUsed,
+		Failed:     l.err != nil,
+		StructLogs: make([]StructLogRes, len(l.logs)),
+	}
+	// the output is only returned on success or revert
+	if l.err == nil || l.err == evm.ErrExecutionReverted {
+		res.ReturnValue = fmt.Sprintf("%x", l.output)
+	}
+
+	for i, log := range l.logs {
+		item := StructLogRes{
+			Pc:            log.Pc,
+			Op:            log.Op.String(),
+			Gas:           log.Gas,
+			GasCost:       log.GasCost,
+			Depth:         log.Depth,
+			RefundCounter: log.Refund,
+		}
+		if log.Err != nil {
+			item.Error = log.Err.Error()
+		}
+		if log.Stack != nil {
+			stack := formatStack(log.Stack)
+			item.Stack = &stack
+		}
+		if log.Memory != nil {
+			memory := make([]string, 0, len(log.Memory)/32)
+			for i := 0; i+32 <= len(log.Memory); i += 32 {
+				memory = append(memory, fmt.Sprintf("%x", log.Memory[i:i+32]))
+			}
+			item.Memory = &memory
+		}
+		if log.Storage != nil {
+			storage := make(map[string]string, len(log.Storage))
+			for k, v := range log.Storage {
+				storage[fmt.Sprintf("%x", k)] = fmt.Sprintf("%x", v)
+			}
+			item.Storage = &storage
+		}
+		res.StructLogs[i] = item
+	}
+	return res
+}
+
+// MarshalJSON implements the json.Marshaler interface
+func (l *StructLogger) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.Result())
+}
+
+func formatStack(stack []*big.Int) []string {
+	res := make([]string, len(stack))
+	for i, v := range stack {
+		res[i] = "0x" + v.Text(16)
+	}
+	return res
+}
+
+func bigToHash(b *big.Int) (res evmc.Hash) {
+	b.FillBytes(res[:])
+	return
+}
@@ -0,0 +1,134 @@
+package tracer
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/evm"
+)
+
+var (
+	testSender   = evmc.Address{0x1}
+	testContract = evmc.Address{0x2}
+)
+
+// PUSH1 0x2a PUSH1 0x1 SSTORE PUSH1 0x1 SLOAD PUSH1 0x0 MSTORE STOP
+var testCode = []byte{0x60, 0x2a, 0x60, 0x01, 0x55, 0x60, 0x01, 0x54, 0x60, 0x00, 0x52, 0x00}
+
+func runTestTracer(t *testing.T, code []byte, tracer evm.Tracer) {
+	runTestTracerWithCode(t, map[evmc.Address][]byte{testContract: code}, tracer)
+}
+
+func runTestTracerWithCode(t *testing.T, code map[evmc.Address][]byte, tracer evm.Tracer, opts ...state.ConfigOption) {
+	opts = append([]state.ConfigOption{state.WithRevision(evmc.Berlin), state.WithTracer(tracer)}, opts...)
+
+	transition := state.NewTransition(opts...)
+	transition.Txn().AddBalance(testSender, big.NewInt(1000000000))
+	for addr, code := range code {
+		transition.Txn().SetCode(addr, code)
+	}
+
+	to := testContract
+	_, err := transition.Write(&state.Message{
+		From:     testSender,
+		To:       &to,
+		Gas:      100000,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	})
+	assert.NoError(t, err)
+}
+
+func TestStructLogger(t *testing.T) {
+	logger := NewStructLogger(nil)
+	runTestTracer(t, testCode, logger)
+
+	res := logger.Result()
+	assert.False(t, res.Failed)
+	assert.Len(t, res.StructLogs, 8)
+
+	// the gas used includes the intrinsic gas of the transaction
+	assert.Equal(t, uint64(21000+22218), res.Gas)
+
+	sstore := res.StructLogs[2]
+	assert.Equal(t, "SSTORE", sstore.Op)
+	assert.Equal(t, uint64(22100), sstore.GasCost)
+	assert.Equal(t, 1, sstore.Depth)
+	assert.Equal(t, []string{"0x2a", "0x1"}, *sstore.Stack)
+	assert.Equal(t, map[string]string{
+		"0000000000000000000000000000000000000000000000000000000000000001": "000000000000000000000000000000000000000000000000000000000000002a",
+	}, *sstore.Storage)
+
+	sload := res.StructLogs[4]
+	assert.Equal(t, "SLOAD", sload.Op)
+	assert.Equal(t, *sstore.Storage, *sload.Storage)
+
+	mstore := res.StructLogs[6]
+	assert.Equal(t, "MSTORE", mstore.Op)
+	assert.Equal(t, []string{}, *mstore.Memory)
+	assert.Nil(t, mstore.Storage)
+
+	stop := res.StructLogs[7]
+	assert.Equal(t, []string{"000000000000000000000000000000000000000000000000000000000000002a"}, *stop.Memory)
+
+	data, err := json.Marshal(logger)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), `{"gas":43218,"failed":false,"returnValue":"","structLogs":[{"pc":0,"op":"PUSH1","gas":79000,"gasCost":3,"depth":1,"stack":[],"memory":[]}`))
+
+	t.Run("Disable", func(t *testing.T) {
+		logger := NewStructLogger(&Config{DisableMemory: true, DisableStack: true, DisableStorage: true})
+		runTestTracer(t, testCode, logger)
+
+		for _, log := range logger.Result().StructLogs {
+			assert.Nil(t, log.Stack)
+			assert.Nil(t, log.Memory)
+			assert.Nil(t, log.Storage)
+		}
+
+		// the stack is still copied to capture the storage
+		logger = NewStructLogger(&Config{DisableStack: true})
+		runTestTracer(t, testCode, logger)
+
+		sstore := logger.StructLogs()[2]
+		assert.Nil(t, sstore.Stack)
+		assert.Equal(t, map[evmc.Hash]evmc.Hash{{31: 0x1}: {31: 0x2a}}, sstore.Storage)
+	})
+
+	t.Run("Fault", func(t *testing.T) {
+		// PUSH1 0x1 ADD
+		logger := NewStructLogger(nil)
+		runTestTracer(t, []byte{0x60, 0x01, 0x01}, logger)
+
+		res := logger.Result()
+		assert.True(t, res.Failed)
+		assert.Len(t, res.StructLogs, 2)
+		assert.Equal(t, "stack underflow", res.StructLogs[1].Error)
+	})
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	runTestTracer(t, testCode, NewJSONLogger(nil, &buf))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 9)
+
+	assert.Equal(t, `{"pc":0,"op":96,"gas":"0x13498","gasCost":"0x3","memory":"0x","memSize":0,"stack":[],"depth":1,"refund":0,"opName":"PUSH1"}`, lines[0])
+	assert.Equal(t, `{"pc":4,"op":85,"gas":"0x13492","gasCost":"0x5654","memory":"0x","memSize":0,"stack":["0x2a","0x1"],"depth":1,"refund":0,"opName":"SSTORE"}`, lines[2])
+	assert.Equal(t, `{"output":"","gasUsed":"0x56ca"}`, lines[8])
+
+	t.Run("Disable", func(t *testing.T) {
+		var buf bytes.Buffer
+		runTestTracer(t, testCode, NewJSONLogger(&Config{DisableMemory: true, DisableStack: true}, &buf))
+
+		// the size of the memory is reported without its copy
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		assert.Equal(t, `{"pc":11,"op":0,"gas":"0xddce","gasCost":"0x0","memSize":32,"stack":null,"depth":1,"refund":0,"opName":"STOP"}`, lines[7])
+	})
+}
@@ -0,0 +1,407 @@
+package transaction
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/fastrlp"
+	state "github.com/umbracle/go-evm"
+)
+
+// MarshalRLP returns the encoding of the transaction. The typed transactions
+// are prefixed with their type (eip-2718).
+func (t *Transaction) MarshalRLP() []byte {
+	a := &fastrlp.Arena{}
+
+	v := t.MarshalRLPWith(a)
+	if t.Type == LegacyTxType {
+		return v.MarshalTo(nil)
+	}
+	return v.MarshalTo([]byte{byte(t.Type)})
+}
+
+// MarshalRLPWith returns the rlp list of the fields of the transaction with
+// the signature, without the type prefix
+func (t *Transaction) MarshalRLPWith(a *fastrlp.Arena) *fastrlp.Value {
+	v := t.marshalPayload(a)
+	v.Set(a.NewBigInt(bigOrZero(t.V)))
+	v.Set(a.NewBigInt(bigOrZero(t.R)))
+	v.Set(a.NewBigInt(bigOrZero(t.S)))
+	return v
+}
+
+// marshalPayload returns the rlp list of the fields of the transaction
+// without the signature
+func (t *Transaction) marshalPayload(a *fastrlp.Arena) *fastrlp.Value {
+	v := a.NewArray()
+	if t.Type != LegacyTxType {
+		v.Set(a.NewBigInt(bigOrZero(t.ChainID)))
+	}
+	v.Set(a.NewUint(t.Nonce))
+
+	switch t.Type {
+	case LegacyTxType, AccessListTxType:
+		v.Set(a.NewBigInt(bigOrZero(t.GasPrice)))
+	default:
+		v.Set(a.NewBigInt(bigOrZero(t.GasTipCap)))
+		v.Set(a.NewBigInt(bigOrZero(t.GasFeeCap)))
+	}
+	v.Set(a.NewUint(t.Gas))
+
+	if t.To == nil {
+		v.Set(a.NewNull())
+	} else {
+		v.Set(a.NewCopyBytes(t.To[:]))
+	}
+	v.Set(a.NewBigInt(bigOrZero(t.Value)))
+	v.Set(a.NewCopyBytes(t.Input))
+
+	if t.Type == LegacyTxType {
+		return v
+	}
+	v.Set(marshalAccessList(a, t.AccessList))
+
+	switch t.Type {
+	case BlobTxType:
+		v.Set(a.NewBigInt(bigOrZero(t.BlobGasFeeCap)))
+
+		hashes := a.NewArray()
+		for _, hash := range t.BlobHashes {
+			hashes.Set(a.NewCopyBytes(hash[:]))
+		}
+		v.Set(hashes)
+
+	case SetCodeTxType:
+		list := a.NewArray()
+		for _, auth := range t.AuthorizationList {
+			entry := marshalAuthorizationPayload(a, &auth)
+			entry.Set(a.NewUint(uint64(auth.V)))
+			entry.Set(a.NewBigInt(bigOrZero(auth.R)))
+			entry.Set(a.NewBigInt(bigOrZero(auth.S)))
+			list.Set(entry)
+		}
+		v.Set(list)
+	}
+	return v
+}
+
+func marshalAccessList(a *fastrlp.Arena, accessList state.AccessList) *fastrlp.Value {
+	list := a.NewArray()
+	for _, tuple := range accessList {
+		entry := a.NewArray()
+		entry.Set(a.NewCopyBytes(tuple.Address[:]))
+
+		keys := a.NewArray()
+		for _, key := range tuple.StorageKeys {
+			keys.Set(a.NewCopyBytes(key[:]))
+		}
+		entry.Set(keys)
+		list.Set(entry)
+	}
+	return list
+}
+
+// marshalAuthorizationPayload returns the fields of the authorization
+// without the signature
+func marshalAuthorizationPayload(a *fastrlp.Arena, auth *state.SetCodeAuthorization) *fastrlp.Value {
+	v := a.NewArray()
+	v.Set(a.NewBigInt(bigOrZero(auth.ChainID)))
+	v.Set(a.NewCopyBytes(auth.Address[:]))
+	v.Set(a.NewUint(auth.Nonce))
+	return v
+}
+
+// UnmarshalRLP decodes a transaction in the encoding of MarshalRLP. The blob
+// transactions can also be in the network encoding with the blobs, which are
+// discarded.
+func (t *Transaction) UnmarshalRLP(buf []byte) error {
+	if len(buf) == 0 {
+		return fmt.Errorf("empty transaction")
+	}
+
+	typ := LegacyTxType
+	if buf[0] <= 0x7f {
+		// the first byte of a rlp list is at least 0xc0
+		typ = Type(buf[0])
+		buf = buf[1:]
+	}
+
+	p := &fastrlp.Parser{}
+	v, err := p.Parse(buf)
+	if err != nil {
+		return err
+	}
+	if typ == LegacyTxType && v.Type() != fastrlp.TypeArray {
+		return fmt.Errorf("legacy transaction is not a list")
+	}
+	return t.UnmarshalRLPFrom(typ, v)
+}
+
+// UnmarshalRLPFrom decodes the rlp list of the fields of a transaction of
+// the given type
+func (t *Transaction) UnmarshalRLPFrom(typ Type, v *fastrlp.Value) error {
+	elems, err := v.GetElems()
+	if err != nil {
+		return err
+	}
+
+	var num int
+	switch typ {
+	case LegacyTxType:
+		num = 9
+	case AccessListTxType:
+		num = 11
+	case DynamicFeeTxType:
+		num = 12
+	case BlobTxType:
+		if len(elems) != 0 && elems[0].Type() == fastrlp.TypeArray {
+			// network encoding, [tx, (version), blobs, commitments, proofs]
+			if elems, err = elems[0].GetElems(); err != nil {
+				return err
+			}
+		}
+		num = 14
+	case SetCodeTxType:
+		num = 13
+	default:
+		return ErrTxTypeNotSupported
+	}
+	if len(elems) != num {
+		return fmt.Errorf("incorrect number of fields for a %s transaction, expected %d but found %d", typ, num, len(elems))
+	}
+
+	*t = Transaction{Type: typ}
+	d := &decoder{elems: elems}
+
+	if typ != LegacyTxType {
+		t.ChainID = d.bigInt()
+	}
+	t.Nonce = d.uint64()
+	switch typ {
+	case LegacyTxType, AccessListTxType:
+		t.GasPrice = d.bigInt()
+	default:
+		t.GasTipCap = d.bigInt()
+		t.GasFeeCap = d.bigInt()
+	}
+	t.Gas = d.uint64()
+	t.To = d.to(typ == BlobTxType || typ == SetCodeTxType)
+	t.Value = d.bigInt()
+	t.Input = d.bytes()
+
+	if typ != LegacyTxType {
+		t.AccessList = d.accessList()
+	}
+	switch typ {
+	case BlobTxType:
+		t.BlobGasFeeCap = d.bigInt()
+		t.BlobHashes = d.hashes()
+	case SetCodeTxType:
+		t.AuthorizationList = d.authorizationList()
+	}
+
+	t.V = d.bigInt()
+	t.R = d.bigInt()
+	t.S = d.bigInt()
+
+	if d.err != nil {
+		return d.err
+	}
+	if typ == LegacyTxType {
+		// an invalid v is rejected when the sender is recovered
+		t.ChainID, _ = LegacyChainID(t.V)
+	}
+	return nil
+}
+
+// decoder decodes a list of fields in order and keeps the first error
+type decoder struct {
+	elems []*fastrlp.Value
+	err   error
+}
+
+func (d *decoder) next() *fastrlp.Value {
+	v := d.elems[0]
+	d.elems = d.elems[1:]
+	return v
+}
+
+func (d *decoder) setErr(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+func (d *decoder) bigInt() *big.Int {
+	b := new(big.Int)
+	if err := d.next().GetBigInt(b); err != nil {
+		d.setErr(err)
+	}
+	return b
+}
+
+func (d *decoder) uint64() uint64 {
+	i, err := d.next().GetUint64()
+	if err != nil {
+		d.setErr(err)
+	}
+	return i
+}
+
+func (d *decoder) bytes() []byte {
+	buf, err := d.next().GetBytes(nil)
+	if err != nil {
+		d.setErr(err)
+	}
+	return buf
+}
+
+func (d *decoder) to(required bool) *evmc.Address {
+	buf := d.bytes()
+	switch {
+	case len(buf) == 0 && !required:
+		return nil
+	case len(buf) == 20:
+		addr := evmc.Address{}
+		copy(addr[:], buf)
+		return &addr
+	default:
+		d.setErr(fmt.Errorf("invalid recipient of %d bytes", len(buf)))
+		return nil
+	}
+}
+
+func (d *decoder) list() []*fastrlp.Value {
+	elems, err := d.next().GetElems()
+	if err != nil {
+		d.setErr(err)
+	}
+	return elems
+}
+
+func (d *decoder) hashes() []evmc.Hash {
+	hashes := []evmc.Hash{}
+	for _, elem := range d.list() {
+		var hash evmc.Hash
+		if err := elem.GetHash(hash[:]); err != nil {
+			d.setErr(err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
+
+func (d *decoder) accessList() state.AccessList {
+	accessList := state.AccessList{}
+	for _, elem := range d.list() {
+		entry := &decoder{}
+		if entry.elems, entry.err = elem.GetElems(); entry.err == nil && len(entry.elems) != 2 {
+			entry.err = fmt.Errorf("invalid access list entry")
+		}
+		if entry.err != nil {
+			d.setErr(entry.err)
+			continue
+		}
+
+		var tuple state.AccessTuple
+		if err := entry.next().GetAddr(tuple.Address[:]); err != nil {
+			d.setErr(err)
+		}
+		tuple.StorageKeys = entry.hashes()
+
+		d.setErr(entry.err)
+		accessList = append(accessList, tuple)
+	}
+	return accessList
+}
+
+func (d *decoder) authorizationList() []state.SetCodeAuthorization {
+	list := []state.SetCodeAuthorization{}
+	for _, elem := range d.list() {
+		entry := &decoder{}
+		if entry.elems, entry.err = elem.GetElems(); entry.err == nil && len(entry.elems) != 6 {
+			entry.err = fmt.Errorf("invalid authorization")
+		}
+		if entry.err != nil {
+			d.setErr(entry.err)
+			continue
+		}
+
+		var auth state.SetCodeAuthorization
+		auth.ChainID = entry.bigInt()
+		if err := entry.next().GetAddr(auth.Address[:]); err != nil {
+			entry.setErr(err)
+		}
+		auth.Nonce = entry.uint64()
+		if v := entry.uint64(); v > 0xff {
+			entry.setErr(fmt.Errorf("invalid authorization y parity %d", v))
+		} else {
+			auth.V = uint8(v)
+		}
+		auth.R = entry.bigInt()
+		auth.S = entry.bigInt()
+
+		d.setErr(entry.err)
+		list = append(list, auth)
+	}
+	return list
+}
+
+// DecodeList decodes the rlp list of the transactions of a block body, where
+// the typed transactions are byte strings
+func DecodeList(buf []byte) ([]*Transaction, error) {
+	p := &fastrlp.Parser{}
+	v, err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+	elems, err := v.GetElems()
+	if err != nil {
+		return nil, err
+	}
+
+	txs := make([]*Transaction, 0, len(elems))
+	for i, elem := range elems {
+		tx := new(Transaction)
+		if elem.Type() == fastrlp.TypeArray {
+			err = tx.UnmarshalRLPFrom(LegacyTxType, elem)
+		} else {
+			var raw []byte
+			if raw, err = elem.GetBytes(nil); err == nil {
+				if len(raw) != 0 && raw[0] > 0x7f {
+					err = fmt.Errorf("typed transaction expected")
+				} else {
+					err = tx.UnmarshalRLP(raw)
+				}
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode transaction %d: %v", i, err)
+		}
+		txs = append(txs, tx)
+	}
+	return txs, nil
+}
+
+// EncodeList encodes the transactions as the rlp list of a block body
+func EncodeList(txs []*Transaction) []byte {
+	a := &fastrlp.Arena{}
+
+	v := a.NewArray()
+	for _, tx := range txs {
+		if tx.Type == LegacyTxType {
+			v.Set(tx.MarshalRLPWith(a))
+		} else {
+			v.Set(a.NewCopyBytes(tx.MarshalRLP()))
+		}
+	}
+	return v.MarshalTo(nil)
+}
+
+func bigOrZero(b *big.Int) *big.Int {
+	if b == nil {
+		return new(big.Int)
+	}
+	return b
+}
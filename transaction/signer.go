@@ -0,0 +1,171 @@
+package transaction
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/ethgo/wallet"
+	"github.com/umbracle/fastrlp"
+	state "github.com/umbracle/go-evm"
+)
+
+// setCodeAuthorizationMagic is the prefix of the signing hash of the
+// authorizations of a set code transaction (eip-7702)
+const setCodeAuthorizationMagic = 0x05
+
+// ErrInvalidSignature is returned when the sender of a transaction cannot be recovered
+var ErrInvalidSignature = errors.New("invalid signature")
+
+var (
+	secp256k1N, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+
+	// secp256k1HalfN is the highest s value since homestead (eip-2)
+	secp256k1HalfN = new(big.Int).Rsh(secp256k1N, 1)
+)
+
+// LegacyChainID returns the chain id of the v value of a replay protected
+// legacy transaction (eip-155) or nil if it is not protected. The v values
+// below 35 other than 27 and 28 are invalid.
+func LegacyChainID(v *big.Int) (*big.Int, error) {
+	if v.BitLen() <= 8 {
+		if n := v.Uint64(); n == 27 || n == 28 {
+			return nil, nil
+		} else if n < 35 {
+			return nil, ErrInvalidSignature
+		}
+	}
+	chainID := new(big.Int).Sub(v, big.NewInt(35))
+	return chainID.Rsh(chainID, 1), nil
+}
+
+// SigningHash returns the hash signed by the sender of the transaction. The
+// legacy transactions without chain id use the homestead hash.
+func (t *Transaction) SigningHash() evmc.Hash {
+	a := &fastrlp.Arena{}
+
+	v := t.marshalPayload(a)
+	if t.Type == LegacyTxType {
+		if t.ChainID != nil {
+			v.Set(a.NewBigInt(t.ChainID))
+			v.Set(a.NewUint(0))
+			v.Set(a.NewUint(0))
+		}
+		return keccak(v.MarshalTo(nil))
+	}
+	return keccak(v.MarshalTo([]byte{byte(t.Type)}))
+}
+
+// Sign signs the transaction with the key for its chain id. A legacy
+// transaction without chain id is not replay protected.
+func (t *Transaction) Sign(key *ecdsa.PrivateKey) error {
+	hash := t.SigningHash()
+
+	sig, err := wallet.NewKey(key).Sign(hash[:])
+	if err != nil {
+		return err
+	}
+
+	t.R = new(big.Int).SetBytes(sig[:32])
+	t.S = new(big.Int).SetBytes(sig[32:64])
+	t.V = new(big.Int).SetUint64(uint64(sig[64]))
+
+	if t.Type == LegacyTxType {
+		if t.ChainID != nil {
+			// v = recovery id + chain id * 2 + 35 (eip-155)
+			t.V.Add(t.V, new(big.Int).Lsh(t.ChainID, 1))
+			t.V.Add(t.V, big.NewInt(35))
+		} else {
+			t.V.Add(t.V, big.NewInt(27))
+		}
+	}
+	return nil
+}
+
+// Sender recovers the address that signed the transaction. Since homestead
+// the signatures with a high s value are invalid (eip-2).
+func (t *Transaction) Sender(homestead bool) (evmc.Address, error) {
+	if t.V == nil || t.R == nil || t.S == nil {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+
+	recID := new(big.Int).Set(t.V)
+	if t.Type == LegacyTxType {
+		chainID, err := LegacyChainID(t.V)
+		if err != nil {
+			return evmc.Address{}, err
+		}
+		if chainID != nil {
+			if t.ChainID == nil || t.ChainID.Cmp(chainID) != 0 {
+				return evmc.Address{}, ErrInvalidSignature
+			}
+			recID.Sub(recID, new(big.Int).Lsh(chainID, 1))
+			recID.Sub(recID, big.NewInt(35))
+		} else {
+			recID.Sub(recID, big.NewInt(27))
+		}
+	}
+	if !recID.IsUint64() || recID.Uint64() > 1 {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+	return recoverAddress(t.SigningHash(), byte(recID.Uint64()), t.R, t.S, homestead)
+}
+
+// Authority recovers the address that signed an authorization of a set code
+// transaction (eip-7702)
+func Authority(auth *state.SetCodeAuthorization) (evmc.Address, error) {
+	if auth.V > 1 || auth.R == nil || auth.S == nil {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+
+	return recoverAddress(authorizationHash(auth), auth.V, auth.R, auth.S, true)
+}
+
+// SignAuthorization signs the authorization with the key
+func SignAuthorization(auth *state.SetCodeAuthorization, key *ecdsa.PrivateKey) error {
+	hash := authorizationHash(auth)
+
+	sig, err := wallet.NewKey(key).Sign(hash[:])
+	if err != nil {
+		return err
+	}
+	auth.R = new(big.Int).SetBytes(sig[:32])
+	auth.S = new(big.Int).SetBytes(sig[32:64])
+	auth.V = sig[64]
+	return nil
+}
+
+// authorizationHash returns the hash signed by the authority (eip-7702)
+func authorizationHash(auth *state.SetCodeAuthorization) evmc.Hash {
+	a := &fastrlp.Arena{}
+	return keccak(marshalAuthorizationPayload(a, auth).MarshalTo([]byte{setCodeAuthorizationMagic}))
+}
+
+func recoverAddress(hash evmc.Hash, recID byte, r, s *big.Int, homestead bool) (evmc.Address, error) {
+	if r.Sign() <= 0 || r.Cmp(secp256k1N) >= 0 {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+	if s.Sign() <= 0 || s.Cmp(secp256k1N) >= 0 {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+	if homestead && s.Cmp(secp256k1HalfN) > 0 {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+
+	sig := make([]byte, 65)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:64])
+	sig[64] = recID
+
+	addr, err := wallet.Ecrecover(hash[:], sig)
+	if err != nil {
+		return evmc.Address{}, ErrInvalidSignature
+	}
+	return evmc.Address(addr), nil
+}
+
+func keccak(buf []byte) evmc.Hash {
+	return evmc.Hash(ethgo.BytesToHash(ethgo.Keccak256(buf)))
+}
@@ -0,0 +1,156 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	state "github.com/umbracle/go-evm"
+)
+
+// Type is the type of the envelope of a transaction (eip-2718)
+type Type uint8
+
+const (
+	// LegacyTxType is the type of the transactions before eip-2718
+	LegacyTxType Type = 0x0
+
+	// AccessListTxType is the type of the access list transactions (eip-2930)
+	AccessListTxType Type = 0x1
+
+	// DynamicFeeTxType is the type of the dynamic fee transactions (eip-1559)
+	DynamicFeeTxType Type = 0x2
+
+	// BlobTxType is the type of the blob transactions (eip-4844)
+	BlobTxType Type = 0x3
+
+	// SetCodeTxType is the type of the set code transactions (eip-7702)
+	SetCodeTxType Type = 0x4
+)
+
+func (t Type) String() string {
+	switch t {
+	case LegacyTxType:
+		return "legacy"
+	case AccessListTxType:
+		return "access list"
+	case DynamicFeeTxType:
+		return "dynamic fee"
+	case BlobTxType:
+		return "blob"
+	case SetCodeTxType:
+		return "set code"
+	default:
+		return fmt.Sprintf("unknown (%d)", uint8(t))
+	}
+}
+
+// ErrTxTypeNotSupported is returned when decoding an unknown transaction type
+var ErrTxTypeNotSupported = errors.New("transaction type not supported")
+
+// ErrFeeNotSet is returned when a fee field of the transaction type is nil
+var ErrFeeNotSet = errors.New("fee not set")
+
+// Transaction is a signed transaction of any type. The fields that are not
+// part of the type are ignored.
+type Transaction struct {
+	Type Type
+
+	// ChainID is the chain of the transaction, it is nil for the legacy
+	// transactions without replay protection (eip-155)
+	ChainID *big.Int
+
+	Nonce uint64
+
+	// GasPrice is the gas price of the legacy and access list transactions
+	GasPrice *big.Int
+
+	// GasTipCap and GasFeeCap are the max priority fee and the max fee per
+	// gas of the other transactions (eip-1559)
+	GasTipCap *big.Int
+	GasFeeCap *big.Int
+
+	Gas   uint64
+	To    *evmc.Address
+	Value *big.Int
+	Input []byte
+
+	AccessList state.AccessList
+
+	// BlobGasFeeCap and BlobHashes are only set in the blob transactions
+	BlobGasFeeCap *big.Int
+	BlobHashes    []evmc.Hash
+
+	// AuthorizationList is only set in the set code transactions
+	AuthorizationList []state.SetCodeAuthorization
+
+	// V, R and S are the signature. V is the recovery id (y parity) in the
+	// typed transactions and includes the chain id in the legacy ones.
+	V *big.Int
+	R *big.Int
+	S *big.Int
+}
+
+// Hash returns the hash of the transaction
+func (t *Transaction) Hash() evmc.Hash {
+	return evmc.Hash(ethgo.BytesToHash(ethgo.Keccak256(t.MarshalRLP())))
+}
+
+// IsContractCreation returns whether the transaction creates a contract
+func (t *Transaction) IsContractCreation() bool {
+	return t.To == nil
+}
+
+// ToMessage recovers the sender of the transaction and returns the
+// message that is applied by the transition
+func (t *Transaction) ToMessage(homestead bool) (*state.Message, error) {
+	switch t.Type {
+	case LegacyTxType, AccessListTxType:
+		if t.GasPrice == nil {
+			return nil, ErrFeeNotSet
+		}
+	default:
+		if t.GasFeeCap == nil || t.GasTipCap == nil {
+			return nil, ErrFeeNotSet
+		}
+	}
+	if t.Type == BlobTxType && t.BlobGasFeeCap == nil {
+		return nil, ErrFeeNotSet
+	}
+
+	from, err := t.Sender(homestead)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &state.Message{
+		Type:              uint8(t.Type),
+		Hash:              t.Hash(),
+		From:              from,
+		To:                t.To,
+		Nonce:             t.Nonce,
+		Gas:               t.Gas,
+		Value:             new(big.Int),
+		Input:             t.Input,
+		AccessList:        t.AccessList,
+		BlobHashes:        t.BlobHashes,
+		AuthorizationList: t.AuthorizationList,
+	}
+	if t.Value != nil {
+		msg.Value.Set(t.Value)
+	}
+
+	switch t.Type {
+	case LegacyTxType, AccessListTxType:
+		msg.GasPrice = new(big.Int).Set(t.GasPrice)
+	default:
+		msg.GasFeeCap = new(big.Int).Set(t.GasFeeCap)
+		msg.GasTipCap = new(big.Int).Set(t.GasTipCap)
+	}
+	if t.Type == BlobTxType {
+		msg.BlobGasFeeCap = new(big.Int).Set(t.BlobGasFeeCap)
+	}
+	return msg, nil
+}
@@ -0,0 +1,424 @@
+package transaction
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/umbracle/ethgo/wallet"
+	"github.com/umbracle/fastrlp"
+	state "github.com/umbracle/go-evm"
+	"github.com/umbracle/go-evm/evm"
+)
+
+func mustDecodeHex(t *testing.T, str string) []byte {
+	buf, err := hex.DecodeString(strings.TrimPrefix(str, "0x"))
+	require.NoError(t, err)
+	return buf
+}
+
+func TestTransaction_EIP155(t *testing.T) {
+	// the example of eip-155
+	raw := mustDecodeHex(t, "0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+
+	tx := new(Transaction)
+	require.NoError(t, tx.UnmarshalRLP(raw))
+
+	assert.Equal(t, LegacyTxType, tx.Type)
+	assert.Equal(t, big.NewInt(1), tx.ChainID)
+	assert.Equal(t, uint64(9), tx.Nonce)
+	assert.Equal(t, uint64(21000), tx.Gas)
+	hash := tx.SigningHash()
+	assert.Equal(t, "daf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53", hex.EncodeToString(hash[:]))
+
+	sender, err := tx.Sender(true)
+	require.NoError(t, err)
+	assert.Equal(t, "9d8a62f656a8d1615c1294fd71e9cfb3e4855a4f", hex.EncodeToString(sender[:]))
+
+	assert.Equal(t, raw, tx.MarshalRLP())
+
+	// the signature is deterministic (rfc6979)
+	key, err := wallet.ParsePrivateKey(mustDecodeHex(t, "0x4646464646464646464646464646464646464646464646464646464646464646"))
+	require.NoError(t, err)
+
+	tx2 := *tx
+	tx2.V, tx2.R, tx2.S = nil, nil, nil
+	require.NoError(t, tx2.Sign(key))
+	assert.Equal(t, raw, tx2.MarshalRLP())
+}
+
+func TestTransaction_Vectors(t *testing.T) {
+	decode := func(t *testing.T, str string) *Transaction {
+		raw := mustDecodeHex(t, str)
+
+		tx := new(Transaction)
+		require.NoError(t, tx.UnmarshalRLP(raw))
+		assert.Equal(t, raw, tx.MarshalRLP())
+		return tx
+	}
+
+	t.Run("Frontier", func(t *testing.T) {
+		// the signature of the rightvrsTx of geth has a high s value
+		tx := decode(t, "0xf86103018207d094b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a8255441ca098ff921201554726367d2be8c804a7ff89ccf285ebc57dff8ae4c44b9c19ac4aa08887321be575c8095f789dd4c743dfe42c1820f9231f98a962b210e3ac2452a3")
+		hash := tx.SigningHash()
+		assert.Equal(t, "fe7a79529ed5f7c3375d06b26b186a8644e0e16c373d7a12be41c62d6042b77a", hex.EncodeToString(hash[:]))
+
+		_, err := tx.Sender(false)
+		assert.NoError(t, err)
+
+		_, err = tx.Sender(true)
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("Homestead", func(t *testing.T) {
+		// the contract creation of the TestRecipientEmpty of geth
+		tx := decode(t, "0xf8498080808080011ca09b16de9d5bdee2cf56c28d16275a4da68cd30273e2525f3959f5d62557489921a0372ebd8fb3345f7db7b5a86d42e24d36e983e259b0664ceb8c227ec9af572f3d")
+
+		sender, err := tx.Sender(true)
+		require.NoError(t, err)
+		assert.Equal(t, "a94f5374fce5edbc8e2a8697c15331677e6ebf0b", hex.EncodeToString(sender[:]))
+
+		// the same transaction with a v of 1
+		tx = decode(t, "0xf84980808080800101a09b16de9d5bdee2cf56c28d16275a4da68cd30273e2525f3959f5d62557489921a0372ebd8fb3345f7db7b5a86d42e24d36e983e259b0664ceb8c227ec9af572f3d")
+		_, err = tx.Sender(true)
+		assert.Equal(t, ErrInvalidSignature, err)
+	})
+
+	t.Run("AccessList", func(t *testing.T) {
+		// the signedEip2718Tx of geth
+		tx := decode(t, "0x01f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521")
+		assert.Equal(t, AccessListTxType, tx.Type)
+
+		hash := tx.SigningHash()
+		assert.Equal(t, "49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3", hex.EncodeToString(hash[:]))
+	})
+
+	t.Run("Typed", func(t *testing.T) {
+		// the key of the a94f5374fce5edbc8e2a8697c15331677e6ebf0b test account
+		key, err := wallet.ParsePrivateKey(mustDecodeHex(t, "0x45a915e4d060149eb4365960e6a7a45f334393093061116b197e3240065ff2d8"))
+		require.NoError(t, err)
+
+		for _, tx := range testTransactions()[1:] {
+			require.NoError(t, tx.Sign(key))
+
+			sender, err := decode(t, hex.EncodeToString(tx.MarshalRLP())).Sender(true)
+			require.NoError(t, err)
+			assert.Equal(t, "a94f5374fce5edbc8e2a8697c15331677e6ebf0b", hex.EncodeToString(sender[:]), tx.Type.String())
+		}
+	})
+}
+
+func testTransactions() []*Transaction {
+	to := evmc.Address{0x1}
+	accessList := state.AccessList{
+		{Address: evmc.Address{0x2}, StorageKeys: []evmc.Hash{{0x3}, {0x4}}},
+	}
+
+	return []*Transaction{
+		{
+			Type:     LegacyTxType,
+			Nonce:    1,
+			GasPrice: big.NewInt(10),
+			Gas:      21000,
+			Value:    big.NewInt(5),
+		},
+		{
+			Type:       AccessListTxType,
+			ChainID:    big.NewInt(1),
+			GasPrice:   big.NewInt(10),
+			Gas:        50000,
+			To:         &to,
+			Value:      big.NewInt(0),
+			Input:      []byte{0x1, 0x2},
+			AccessList: accessList,
+		},
+		{
+			Type:       DynamicFeeTxType,
+			ChainID:    big.NewInt(5),
+			GasTipCap:  big.NewInt(1),
+			GasFeeCap:  big.NewInt(100),
+			Gas:        50000,
+			Value:      big.NewInt(0),
+			Input:      []byte{0x60, 0x00},
+			AccessList: state.AccessList{},
+		},
+		{
+			Type:          BlobTxType,
+			ChainID:       big.NewInt(1),
+			GasTipCap:     big.NewInt(1),
+			GasFeeCap:     big.NewInt(100),
+			Gas:           50000,
+			To:            &to,
+			Value:         big.NewInt(0),
+			AccessList:    accessList,
+			BlobGasFeeCap: big.NewInt(7),
+			BlobHashes:    []evmc.Hash{{0x1, 0x2}, {0x1, 0x3}},
+		},
+		{
+			Type:       SetCodeTxType,
+			ChainID:    big.NewInt(1),
+			GasTipCap:  big.NewInt(1),
+			GasFeeCap:  big.NewInt(100),
+			Gas:        50000,
+			To:         &to,
+			Value:      big.NewInt(0),
+			AccessList: state.AccessList{},
+			AuthorizationList: []state.SetCodeAuthorization{
+				{ChainID: big.NewInt(1), Address: evmc.Address{0x5}, Nonce: 2, R: big.NewInt(1), S: big.NewInt(2)},
+			},
+		},
+	}
+}
+
+func TestTransaction_Types(t *testing.T) {
+	key, err := wallet.GenerateKey()
+	require.NoError(t, err)
+	priv, err := wallet.ParsePrivateKey(mustMarshalKey(t, key))
+	require.NoError(t, err)
+
+	for _, tx := range testTransactions() {
+		t.Run(tx.Type.String(), func(t *testing.T) {
+			require.NoError(t, tx.Sign(priv))
+
+			raw := tx.MarshalRLP()
+			if tx.Type != LegacyTxType {
+				assert.Equal(t, byte(tx.Type), raw[0])
+			}
+
+			tx2 := new(Transaction)
+			require.NoError(t, tx2.UnmarshalRLP(raw))
+			assert.Equal(t, tx, tx2)
+			assert.Equal(t, tx.Hash(), tx2.Hash())
+
+			sender, err := tx2.Sender(true)
+			require.NoError(t, err)
+			assert.Equal(t, evmc.Address(key.Address()), sender)
+
+			msg, err := tx2.ToMessage(true)
+			require.NoError(t, err)
+			assert.Equal(t, sender, msg.From)
+			assert.Equal(t, tx.Gas, msg.Gas)
+			assert.Equal(t, tx.BlobHashes, msg.BlobHashes)
+			assert.Equal(t, tx.AuthorizationList, msg.AuthorizationList)
+
+			// a different signature recovers a different sender
+			tx2.S = new(big.Int).Add(tx2.S, big.NewInt(1))
+			if sender2, err := tx2.Sender(true); err == nil {
+				assert.NotEqual(t, sender, sender2)
+			}
+		})
+	}
+}
+
+func TestTransaction_List(t *testing.T) {
+	key, err := wallet.ParsePrivateKey(mustDecodeHex(t, "0x4646464646464646464646464646464646464646464646464646464646464646"))
+	require.NoError(t, err)
+
+	txs := testTransactions()
+	for _, tx := range txs {
+		require.NoError(t, tx.Sign(key))
+	}
+
+	buf := EncodeList(txs)
+	txs2, err := DecodeList(buf)
+	require.NoError(t, err)
+	assert.Equal(t, txs, txs2)
+	assert.Equal(t, buf, EncodeList(txs2))
+}
+
+func TestTransaction_BlobNetworkEncoding(t *testing.T) {
+	key, err := wallet.ParsePrivateKey(mustDecodeHex(t, "0x4646464646464646464646464646464646464646464646464646464646464646"))
+	require.NoError(t, err)
+
+	tx := testTransactions()[3]
+	require.NoError(t, tx.Sign(key))
+
+	// [tx, blobs, commitments, proofs]
+	a := &fastrlp.Arena{}
+	v := a.NewArray()
+	v.Set(tx.MarshalRLPWith(a))
+	v.Set(a.NewArray())
+	v.Set(a.NewArray())
+	v.Set(a.NewArray())
+
+	tx2 := new(Transaction)
+	require.NoError(t, tx2.UnmarshalRLP(v.MarshalTo([]byte{byte(BlobTxType)})))
+	assert.Equal(t, tx.Hash(), tx2.Hash())
+}
+
+func TestTransaction_Invalid(t *testing.T) {
+	key, err := wallet.ParsePrivateKey(mustDecodeHex(t, "0x4646464646464646464646464646464646464646464646464646464646464646"))
+	require.NoError(t, err)
+
+	tx := testTransactions()[2]
+	require.NoError(t, tx.Sign(key))
+
+	// the signatures with a high s are malleable (eip-2)
+	tx.S = new(big.Int).Sub(secp256k1N, tx.S)
+	_, err = tx.Sender(true)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	// the typed transactions use the recovery id as v
+	tx.V = big.NewInt(27)
+	_, err = tx.Sender(true)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	// the legacy v values below 35 are either 27 or 28 (eip-155)
+	for _, v := range []int64{0, 1, 30} {
+		legacy := testTransactions()[0]
+		require.NoError(t, legacy.Sign(key))
+		legacy.V = big.NewInt(v)
+
+		legacy2 := new(Transaction)
+		require.NoError(t, legacy2.UnmarshalRLP(legacy.MarshalRLP()))
+		assert.Nil(t, legacy2.ChainID)
+
+		_, err = legacy2.Sender(false)
+		assert.Equal(t, ErrInvalidSignature, err, "v %d", v)
+
+		_, err = LegacyChainID(legacy.V)
+		assert.Equal(t, ErrInvalidSignature, err)
+	}
+
+	// the fee fields of the type are required
+	for i, tx := range testTransactions() {
+		require.NoError(t, tx.Sign(key))
+		tx.GasPrice, tx.GasFeeCap = nil, nil
+		_, err = tx.ToMessage(true)
+		assert.Equal(t, ErrFeeNotSet, err, "type %s", tx.Type)
+
+		if tx.Type == BlobTxType {
+			tx = testTransactions()[i]
+			tx.BlobGasFeeCap = nil
+			_, err = tx.ToMessage(true)
+			assert.Equal(t, ErrFeeNotSet, err)
+		}
+	}
+
+	assert.Equal(t, ErrTxTypeNotSupported, new(Transaction).UnmarshalRLP([]byte{0x7f, 0xc0}))
+
+	// the blob transactions cannot create contracts
+	blobTx := testTransactions()[3]
+	blobTx.To = nil
+	assert.Error(t, new(Transaction).UnmarshalRLP(blobTx.MarshalRLP()))
+}
+
+func TestAuthority(t *testing.T) {
+	key, err := wallet.GenerateKey()
+	require.NoError(t, err)
+	priv, err := wallet.ParsePrivateKey(mustMarshalKey(t, key))
+	require.NoError(t, err)
+
+	auth := &state.SetCodeAuthorization{
+		ChainID: big.NewInt(1),
+		Address: evmc.Address{0x1},
+		Nonce:   3,
+	}
+	require.NoError(t, SignAuthorization(auth, priv))
+
+	authority, err := Authority(auth)
+	require.NoError(t, err)
+	assert.Equal(t, evmc.Address(key.Address()), authority)
+}
+
+func TestTransaction_Transition(t *testing.T) {
+	key, err := wallet.GenerateKey()
+	require.NoError(t, err)
+	priv, err := wallet.ParsePrivateKey(mustMarshalKey(t, key))
+	require.NoError(t, err)
+
+	sender := evmc.Address(key.Address())
+	to := evmc.Address{0x1}
+
+	ctx := state.TxContext{
+		BaseFee:     evmc.Hash{31: 10},
+		BlobBaseFee: evmc.Hash{31: 2},
+	}
+
+	t.Run("DynamicFee", func(t *testing.T) {
+		transition := state.NewTransition(state.WithRevision(evm.Cancun), state.WithContext(ctx))
+		transition.Txn().AddBalance(sender, big.NewInt(1000000))
+
+		tx := &Transaction{
+			Type:      DynamicFeeTxType,
+			ChainID:   big.NewInt(1),
+			GasTipCap: big.NewInt(1),
+			GasFeeCap: big.NewInt(20),
+			Gas:       21000,
+			To:        &to,
+			Value:     big.NewInt(100),
+		}
+		require.NoError(t, tx.Sign(priv))
+
+		raw := tx.MarshalRLP()
+		tx2 := new(Transaction)
+		require.NoError(t, tx2.UnmarshalRLP(raw))
+
+		msg, err := tx2.ToMessage(true)
+		require.NoError(t, err)
+
+		output, err := transition.Write(msg)
+		require.NoError(t, err)
+		assert.True(t, output.Success)
+
+		assert.Equal(t, big.NewInt(100), transition.Txn().GetBalance(to))
+		assert.Equal(t, big.NewInt(1000000-100-11*21000), transition.Txn().GetBalance(sender))
+	})
+
+	t.Run("Blob", func(t *testing.T) {
+		transition := state.NewTransition(state.WithRevision(evm.Cancun), state.WithContext(ctx))
+		transition.Txn().AddBalance(sender, big.NewInt(1000000))
+
+		tx := &Transaction{
+			Type:          BlobTxType,
+			ChainID:       big.NewInt(1),
+			GasTipCap:     big.NewInt(0),
+			GasFeeCap:     big.NewInt(10),
+			Gas:           21000,
+			To:            &to,
+			Value:         big.NewInt(0),
+			BlobGasFeeCap: big.NewInt(1),
+			BlobHashes:    []evmc.Hash{{0x1}},
+		}
+		require.NoError(t, tx.Sign(priv))
+
+		msg, err := tx.ToMessage(true)
+		require.NoError(t, err)
+
+		// the fee cap is lower than the blob base fee
+		_, err = transition.Write(msg)
+		assert.Error(t, err)
+		assert.Equal(t, big.NewInt(1000000), transition.Txn().GetBalance(sender))
+
+		msg.BlobGasFeeCap = big.NewInt(2)
+		_, err = transition.Write(msg)
+		require.NoError(t, err)
+
+		// the blob fee is burned along the gas
+		assert.Equal(t, big.NewInt(1000000-10*21000-2*int64(state.GasPerBlob)), transition.Txn().GetBalance(sender))
+	})
+
+	t.Run("SetCode", func(t *testing.T) {
+		transition := state.NewTransition(state.WithRevision(evm.Prague), state.WithContext(ctx))
+		transition.Txn().AddBalance(sender, big.NewInt(1000000))
+
+		tx := testTransactions()[4]
+		require.NoError(t, tx.Sign(priv))
+
+		msg, err := tx.ToMessage(true)
+		require.NoError(t, err)
+
+		_, err = transition.Write(msg)
+		assert.EqualError(t, err, "set code transactions not supported")
+	})
+}
+
+func mustMarshalKey(t *testing.T, key *wallet.Key) []byte {
+	buf, err := key.MarshallPrivateKey()
+	require.NoError(t, err)
+	return buf
+}
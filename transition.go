@@ -1,6 +1,7 @@
 package state
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 	"github.com/umbracle/go-evm/evm"
+	"github.com/umbracle/go-evm/precompiled/kzg"
 )
 
 const (
@@ -18,8 +20,32 @@ const (
 
 	// Per transaction that creates a contract
 	TxGasContractCreation uint64 = 53000
+
+	// Per address in the access list (eip-2930)
+	TxAccessListAddressGas uint64 = 2400
+
+	// Per storage key in the access list (eip-2930)
+	TxAccessListStorageKeyGas uint64 = 1900
+
+	// Per word of the initcode of a contract creation (eip-3860)
+	TxInitCodeWordGas uint64 = 2
+
+	// Per blob of a blob transaction (eip-4844)
+	GasPerBlob uint64 = 1 << 17
+
+	// Gas of the system calls (eip-4788)
+	SystemCallGas uint64 = 30_000_000
+
+	// blobTxType is the type of the blob transactions (eip-4844)
+	blobTxType = 0x3
 )
 
+// SystemAddress is the caller of the system calls (eip-4788)
+var SystemAddress = evmc.Address{
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff,
+	0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe,
+}
+
 // getHashByNumber returns the hash function of a block number
 type GetHashByNumber = func(i uint64) evmc.Hash
 
@@ -29,19 +55,36 @@ type Transition struct {
 
 	// parametrization of the transition
 	config *Config
+
+	// interrupt aborts the transaction being applied
+	interrupt *evm.Interrupt
+
+	// blobHashes are the blob hashes of the transaction being applied
+	blobHashes []evmc.Hash
+
+	// receipts are the receipts of the transactions written
+	receipts []*Receipt
+
+	// gasUsed and numLogs are the gas used and the number of logs of
+	// the transactions written
+	gasUsed uint64
+	numLogs uint64
 }
 
 // TxContext is the context of the transaction
 type TxContext struct {
-	Hash       evmc.Hash
-	GasPrice   evmc.Hash
-	Origin     evmc.Address
-	Coinbase   evmc.Address
-	Number     int64
-	Timestamp  int64
-	GasLimit   int64
-	ChainID    int64
-	Difficulty evmc.Hash
+	Hash        evmc.Hash
+	GasPrice    evmc.Hash
+	Origin      evmc.Address
+	Coinbase    evmc.Address
+	Number      int64
+	Timestamp   int64
+	GasLimit    int64
+	ChainID     int64
+	Difficulty  evmc.Hash
+	BaseFee     evmc.Hash
+	BlobHashes  []evmc.Hash
+	BlobBaseFee evmc.Hash
 }
 
 // NewExecutor creates a new executor
@@ -50,6 +93,13 @@ func NewTransition(opts ...ConfigOption) *Transition {
 	for _, opt := range opts {
 		opt(config)
 	}
+	if chainConfig := config.ChainConfig; chainConfig != nil {
+		config.Rev = chainConfig.Revision(uint64(config.Ctx.Number), uint64(config.Ctx.Timestamp))
+		// a config built without chain id keeps the one of the context
+		if chainConfig.ChainID != nil {
+			config.Ctx.ChainID = chainConfig.ChainID.Int64()
+		}
+	}
 
 	txn := NewTxn(config.State)
 	txn.rev = config.Rev
@@ -69,13 +119,41 @@ func (t *Transition) Txn() *Txn {
 	return t.txn
 }
 
+// Receipts returns the receipts of the transactions written
+func (t *Transition) Receipts() []*Receipt {
+	return t.receipts
+}
+
+// GasUsed returns the cumulative gas used by the transactions written
+func (t *Transition) GasUsed() uint64 {
+	return t.gasUsed
+}
+
 // Write writes another transaction to the executor
 func (t *Transition) Write(msg *Message) (*Output, error) {
-	output, err := t.applyImpl(msg)
+	return t.WriteContext(context.Background(), msg)
+}
+
+// WriteContext writes another transaction to the executor. The transaction is
+// discarded and evm.ErrExecutionAborted is returned if the context is done or
+// the execution reaches the step limit before it completes.
+func (t *Transition) WriteContext(ctx context.Context, msg *Message) (*Output, error) {
+	t.txn.checkpointState()
+
+	output, err := t.applyImpl(ctx, msg)
 	if err != nil {
 		return nil, err
 	}
+	t.finalise()
 
+	output.Receipt = t.newReceipt(msg, output)
+	t.receipts = append(t.receipts, output.Receipt)
+
+	return output, nil
+}
+
+// finalise ends the transaction being applied
+func (t *Transition) finalise() {
 	if t.isRevision(evmc.Byzantium) {
 		// The suicided accounts are set as deleted for the next iteration
 		t.txn.CleanDeleteObjects(true)
@@ -83,16 +161,85 @@ func (t *Transition) Write(msg *Message) (*Output, error) {
 		// TODO: If byzntium is enabled you need a special step to commit the data yourself
 		t.txn.CleanDeleteObjects(t.isRevision(evmc.SpuriousDragon))
 	}
+}
 
-	return output, nil
+// SystemCall calls the contract at addr from the system address outside of
+// any transaction (eip-4788). The call does not pay for the gas, its logs
+// are discarded and it is skipped if the contract has no code.
+func (t *Transition) SystemCall(addr evmc.Address, input []byte) ([]byte, error) {
+	if t.txn.GetCodeSize(addr) == 0 {
+		return nil, nil
+	}
+
+	// the context of the transactions is restored after the call
+	gasPrice, origin, blobHashes := t.config.Ctx.GasPrice, t.config.Ctx.Origin, t.blobHashes
+	defer func() {
+		t.config.Ctx.GasPrice, t.config.Ctx.Origin, t.blobHashes = gasPrice, origin, blobHashes
+	}()
+
+	t.config.Ctx.GasPrice = evmc.Hash{}
+	t.config.Ctx.Origin = SystemAddress
+	t.blobHashes = nil
+
+	c := NewContractCall(0, SystemAddress, addr, big.NewInt(0), SystemCallGas, input)
+	retValue, _, _, err := t.applyCall(c, evmc.Call)
+
+	t.txn.DiscardLogs()
+	t.finalise()
+	return retValue, err
+}
+
+// newReceipt returns the receipt of the message written in the transition
+// and sets the position of its logs
+func (t *Transition) newReceipt(msg *Message, output *Output) *Receipt {
+	gasUsed := msg.Gas - output.GasLeft
+	t.gasUsed += gasUsed
+
+	receipt := &Receipt{
+		Type:              msg.Type,
+		Status:            ReceiptFailed,
+		CumulativeGasUsed: t.gasUsed,
+		GasUsed:           gasUsed,
+		Logs:              output.Logs,
+		TxHash:            msg.Hash,
+		TxIndex:           uint64(len(t.receipts)),
+	}
+	if output.Success {
+		receipt.Status = ReceiptSuccess
+	}
+	if msg.IsContractCreation() {
+		addr := output.ContractAddress
+		receipt.ContractAddress = &addr
+	}
+	for _, log := range output.Logs {
+		log.TxHash = msg.Hash
+		log.TxIndex = receipt.TxIndex
+		log.Index = t.numLogs
+		t.numLogs++
+
+		receipt.Bloom.AddLog(log)
+	}
+	return receipt
 }
 
 // Apply applies a new transaction
-func (t *Transition) applyImpl(msg *Message) (*Output, error) {
+func (t *Transition) applyImpl(ctx context.Context, msg *Message) (*Output, error) {
+	snapshot := t.txn.Snapshot()
+	gas := msg.Gas
+
 	if err := t.preCheck(msg); err != nil {
+		// discard the fees charged before the transaction was found invalid
+		t.txn.RevertToSnapshot(snapshot)
+		msg.Gas = gas
+		return nil, err
+	}
+	output, err := t.ApplyContext(ctx, msg)
+	if err != nil {
+		// discard the changes of the aborted transaction
+		t.txn.RevertToSnapshot(snapshot)
+		msg.Gas = gas
 		return nil, err
 	}
-	output := t.Apply(msg)
 	t.postCheck(msg, output)
 	return output, nil
 }
@@ -101,6 +248,20 @@ func (t *Transition) isRevision(rev evmc.Revision) bool {
 	return rev <= t.config.Rev
 }
 
+// baseFee returns the base fee of the block or nil before the London fork
+func (t *Transition) baseFee() *big.Int {
+	if !t.isRevision(evmc.London) {
+		return nil
+	}
+	return new(big.Int).SetBytes(t.config.Ctx.BaseFee[:])
+}
+
+// blobGas returns the blob gas used by the message and the blob base fee
+func (t *Transition) blobGas(msg *Message) (uint64, *big.Int) {
+	blobBaseFee := new(big.Int).SetBytes(t.config.Ctx.BlobBaseFee[:])
+	return uint64(len(msg.BlobHashes)) * GasPerBlob, blobBaseFee
+}
+
 func (t *Transition) preCheck(msg *Message) error {
 	// 1. the nonce of the message caller is correct
 	nonce := t.txn.GetNonce(msg.From)
@@ -108,9 +269,54 @@ func (t *Transition) preCheck(msg *Message) error {
 		return fmt.Errorf("incorrect nonce")
 	}
 
-	// 2. deduct the upfront max gas cost to cover transaction fee(gaslimit * gasprice)
-	upfrontGasCost := new(big.Int).Set(msg.GasPrice)
+	// 2. the transaction type is supported
+	if len(msg.AuthorizationList) != 0 {
+		return fmt.Errorf("set code transactions not supported")
+	}
+	if (msg.Type == blobTxType || len(msg.BlobHashes) != 0) && !t.isRevision(evm.Cancun) {
+		return fmt.Errorf("blob transactions not supported")
+	}
+	if msg.Type == blobTxType && len(msg.BlobHashes) == 0 {
+		return fmt.Errorf("blob transaction without blobs")
+	}
+	for _, hash := range msg.BlobHashes {
+		if hash[0] != kzg.VersionedHashVersionKZG {
+			return fmt.Errorf("invalid blob versioned hash version %d", hash[0])
+		}
+	}
+
+	baseFee := t.baseFee()
+	blobGas, blobBaseFee := t.blobGas(msg)
+
+	// 3. the fee caps are consistent with the base fee (eip-1559)
+	if baseFee != nil {
+		if msg.FeeCap().Cmp(msg.TipCap()) < 0 {
+			return fmt.Errorf("max priority fee per gas higher than max fee per gas")
+		}
+		if msg.FeeCap().Cmp(baseFee) < 0 {
+			return fmt.Errorf("max fee per gas less than block base fee")
+		}
+
+		if blobGas != 0 && (msg.BlobGasFeeCap == nil || msg.BlobGasFeeCap.Cmp(blobBaseFee) < 0) {
+			return fmt.Errorf("max fee per blob gas less than block blob base fee")
+		}
+
+		// the sender must be able to pay for the gas at the fee cap
+		maxCost := new(big.Int).Mul(msg.FeeCap(), new(big.Int).SetUint64(msg.Gas))
+		maxCost.Add(maxCost, msg.Value)
+		if blobGas != 0 {
+			maxCost.Add(maxCost, new(big.Int).Mul(msg.BlobGasFeeCap, new(big.Int).SetUint64(blobGas)))
+		}
+		if balance := t.txn.GetBalance(msg.From); balance.Cmp(maxCost) < 0 {
+			return fmt.Errorf("not enough funds to cover gas costs")
+		}
+	}
+
+	// 4. deduct the upfront max gas cost to cover transaction fee(gaslimit * gasprice)
+	// and the blob fee, which is burned (eip-4844)
+	upfrontGasCost := msg.EffectiveGasPrice(baseFee)
 	upfrontGasCost.Mul(upfrontGasCost, new(big.Int).SetUint64(msg.Gas))
+	upfrontGasCost.Add(upfrontGasCost, new(big.Int).Mul(blobBaseFee, new(big.Int).SetUint64(blobGas)))
 
 	err := t.txn.SubBalance(msg.From, upfrontGasCost)
 	if err != nil {
@@ -120,24 +326,53 @@ func (t *Transition) preCheck(msg *Message) error {
 		return err
 	}
 
-	// 4. there is no overflow when calculating intrinsic gas
-	intrinsicGasCost, err := TransactionGasCost(msg, t.isRevision(evmc.Homestead), t.isRevision(evmc.Istanbul))
+	// 5. there is no overflow when calculating intrinsic gas
+	intrinsicGasCost, err := TransactionGasCost(msg, t.isRevision(evmc.Homestead), t.isRevision(evmc.Istanbul), t.isRevision(evmc.Shanghai))
 	if err != nil {
 		return err
 	}
 
-	// 5. the purchased gas is enough to cover intrinsic usage
+	// 6. the purchased gas is enough to cover intrinsic usage
 	gasLeft := msg.Gas - intrinsicGasCost
 	// Because we are working with unsigned integers for gas, the `>` operator is used instead of the more intuitive `<`
 	if gasLeft > msg.Gas {
 		return fmt.Errorf("not enough gas supplied for intrinsic gas costs")
 	}
 
-	// 6. caller has enough balance to cover asset transfer for **topmost** call
+	// 7. caller has enough balance to cover asset transfer for **topmost** call
 	if balance := t.txn.GetBalance(msg.From); balance.Cmp(msg.Value) < 0 {
 		return errNotEnoughFunds
 	}
 
+	// 8. the initcode of a contract creation is within the size limit (eip-3860)
+	if t.isRevision(evmc.Shanghai) && msg.IsContractCreation() && len(msg.Input) > evm.MaxInitCodeSize {
+		return evm.ErrMaxInitCodeSizeExceeded
+	}
+
+	// 9. pre-warm the sender, the recipient, the precompiles and the access list (eip-2929, eip-2930)
+	if t.isRevision(evmc.Berlin) {
+		t.txn.AddAddressToAccessList(msg.From)
+		if msg.To != nil {
+			t.txn.AddAddressToAccessList(*msg.To)
+		}
+		for addr := range t.config.precompiles {
+			if t.isPrecompiled(addr) {
+				t.txn.AddAddressToAccessList(addr)
+			}
+		}
+		for _, tuple := range msg.AccessList {
+			t.txn.AddAddressToAccessList(tuple.Address)
+			for _, key := range tuple.StorageKeys {
+				t.txn.AddSlotToAccessList(tuple.Address, key)
+			}
+		}
+	}
+
+	// 10. pre-warm the coinbase (eip-3651)
+	if t.isRevision(evmc.Shanghai) {
+		t.txn.AddAddressToAccessList(t.config.Ctx.Coinbase)
+	}
+
 	msg.Gas = gasLeft
 	return nil
 }
@@ -145,15 +380,19 @@ func (t *Transition) preCheck(msg *Message) error {
 func (t *Transition) postCheck(msg *Message, output *Output) {
 	var gasUsed uint64
 
-	intrinsicGasCost, _ := TransactionGasCost(msg, t.isRevision(evmc.Homestead), t.isRevision(evmc.Istanbul))
+	intrinsicGasCost, _ := TransactionGasCost(msg, t.isRevision(evmc.Homestead), t.isRevision(evmc.Istanbul), t.isRevision(evmc.Shanghai))
 	msg.Gas += intrinsicGasCost
 
 	// Update gas used depending on the refund.
 	refund := t.txn.GetRefund()
 	{
 		gasUsed = msg.Gas - output.GasLeft
+
+		// Refund can go up to half the gas used, a fifth after London (eip-3529)
 		maxRefund := gasUsed / 2
-		// Refund can go up to half the gas used
+		if t.isRevision(evmc.London) {
+			maxRefund = gasUsed / 5
+		}
 		if refund > maxRefund {
 			refund = maxRefund
 		}
@@ -162,39 +401,76 @@ func (t *Transition) postCheck(msg *Message, output *Output) {
 		gasUsed -= refund
 	}
 
-	gasPrice := new(big.Int).Set(msg.GasPrice)
+	if tracer, ok := t.config.Tracer.(evm.TxTracer); ok {
+		tracer.CaptureTxEnd(gasUsed)
+	}
+
+	baseFee := t.baseFee()
+	gasPrice := msg.EffectiveGasPrice(baseFee)
 
 	// refund the sender
 	remaining := new(big.Int).Mul(new(big.Int).SetUint64(output.GasLeft), gasPrice)
 	t.txn.AddBalance(msg.From, remaining)
 
-	// pay the coinbase for the transaction
-	coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), gasPrice)
+	// pay the coinbase only the tip, the base fee is burned (eip-1559)
+	tip := new(big.Int).Set(gasPrice)
+	if baseFee != nil {
+		tip.Sub(tip, baseFee)
+	}
+	coinbaseFee := new(big.Int).Mul(new(big.Int).SetUint64(gasUsed), tip)
 	t.txn.AddBalance(t.config.Ctx.Coinbase, coinbaseFee)
 }
 
 func (t *Transition) Apply(msg *Message) *Output {
-	gasPrice := new(big.Int).Set(msg.GasPrice)
+	output, _ := t.ApplyContext(context.Background(), msg)
+	return output
+}
+
+// ApplyContext applies a new transaction and aborts it with evm.ErrExecutionAborted
+// if the context is done or the execution reaches the step limit. The output
+// of an aborted transaction is returned along with the error.
+func (t *Transition) ApplyContext(ctx context.Context, msg *Message) (*Output, error) {
+	if ctx.Done() != nil || t.config.StepLimit != 0 {
+		t.interrupt = evm.NewInterrupt(ctx, t.config.StepLimit)
+		defer func() {
+			t.interrupt = nil
+		}()
+	}
+
+	gasPrice := msg.EffectiveGasPrice(t.baseFee())
 	value := new(big.Int).Set(msg.Value)
 
 	// Override the context and set the specific transaction fields
 	t.config.Ctx.GasPrice = bytesToHash(gasPrice.Bytes())
 	t.config.Ctx.Origin = msg.From
+	t.blobHashes = msg.BlobHashes
 
 	var retValue []byte
 	var gasLeft int64
 	var err error
 
+	tracer := t.config.Tracer
+
 	if msg.IsContractCreation() {
 		address := createAddress(msg.From, t.txn.GetNonce(msg.From))
+		if tracer != nil {
+			tracer.CaptureStart(t, msg.From, address, true, msg.Input, msg.Gas, value)
+		}
 		contract := NewContractCreation(0, msg.From, address, value, msg.Gas, msg.Input)
 		retValue, gasLeft, _, err = t.applyCreate(contract)
 	} else {
+		if tracer != nil {
+			tracer.CaptureStart(t, msg.From, *msg.To, false, msg.Input, msg.Gas, value)
+		}
 		t.txn.IncrNonce(msg.From)
 		c := NewContractCall(0, msg.From, *msg.To, value, msg.Gas, msg.Input)
 		retValue, gasLeft, _, err = t.applyCall(c, evmc.Call)
 	}
 
+	if tracer != nil {
+		tracer.CaptureEnd(retValue, msg.Gas-uint64(gasLeft), err)
+	}
+
 	output := &Output{
 		ReturnValue: retValue,
 		Logs:        t.txn.Logs(),
@@ -212,33 +488,33 @@ func (t *Transition) Apply(msg *Message) *Output {
 		output.ContractAddress = createAddress(msg.From, msg.Nonce)
 	}
 
-	return output
+	if t.interrupt != nil && t.interrupt.Aborted() {
+		return output, evm.ErrExecutionAborted
+	}
+	return output, nil
 }
 
-func (t *Transition) isPrecompiled(codeAddr evmc.Address) bool {
-	if _, ok := precompiledContracts[codeAddr]; !ok {
-		return false
-	}
+// IsPrecompiled returns whether the address is a precompile in the revision
+func (t *Transition) IsPrecompiled(addr evmc.Address) bool {
+	return t.isPrecompiled(addr)
+}
 
-	// byzantium precompiles
-	switch codeAddr {
-	case addr5:
-		fallthrough
-	case addr6:
-		fallthrough
-	case addr7:
-		fallthrough
-	case addr8:
-		return t.isRevision(evmc.Byzantium)
+// IsCheatcode returns whether a call to the address runs a cheatcode
+func (t *Transition) IsCheatcode(addr evmc.Address) bool {
+	for _, cheat := range t.config.Cheatcodes {
+		if cheat.CanRun(addr) {
+			return true
+		}
 	}
+	return false
+}
 
-	// istanbul precompiles
-	switch codeAddr {
-	case addr9:
-		return t.isRevision(evmc.Istanbul)
+func (t *Transition) isPrecompiled(codeAddr evmc.Address) bool {
+	p, ok := t.config.precompiles[codeAddr]
+	if !ok {
+		return false
 	}
-
-	return true
+	return t.isRevision(p.rev)
 }
 
 func (t *Transition) run(c *Contract) ([]byte, int64, error) {
@@ -251,12 +527,15 @@ func (t *Transition) run(c *Contract) ([]byte, int64, error) {
 		}
 	}
 	if t.isPrecompiled(c.CodeAddress) {
-		return runPrecompiled(c.CodeAddress, c.Input, c.Gas, t.config.Rev)
+		return runPrecompiled(t.config.precompiles[c.CodeAddress].contract, c.Input, c.Gas, t.config.Rev)
 	}
 
 	evm := evm.EVM{
-		Host: t,
-		Rev:  t.config.Rev,
+		Host:          t,
+		Rev:           t.config.Rev,
+		Tracer:        t.config.Tracer,
+		JumpdestCache: t.config.JumpdestCache,
+		Interrupt:     t.interrupt,
 	}
 	return evm.Run(c.Type, c.Address, c.Caller, c.Value, c.Input, int64(c.Gas), c.Depth, c.Static, c.CodeAddress)
 }
@@ -321,12 +600,22 @@ func (t *Transition) applyCreate(c *Contract) ([]byte, int64, evmc.Address, erro
 		panic("X1")
 	}
 
+	if t.isRevision(evmc.Shanghai) && len(c.Input) > evm.MaxInitCodeSize {
+		// Initcode size exceeds the 'Shanghai' size limit (eip-3860)
+		return nil, 0, address, evm.ErrMaxInitCodeSizeExceeded
+	}
+
 	c.CodeAddress = address
 	c.Address = address
 
 	// Increment the nonce of the caller
 	t.txn.IncrNonce(c.Caller)
 
+	// The address is warm even if the creation fails (eip-2929)
+	if t.isRevision(evmc.Berlin) {
+		t.txn.AddAddressToAccessList(address)
+	}
+
 	// Check if there if there is a collision and the address already exists
 	if t.hasCodeOrNonce(c.Address) {
 		return nil, 0, address, errors.New("contract address collision")
@@ -363,6 +652,12 @@ func (t *Transition) applyCreate(c *Contract) ([]byte, int64, evmc.Address, erro
 		return nil, 0, address, errors.New("evm: max code size exceeded")
 	}
 
+	if t.isRevision(evmc.London) && len(retValue) > 0 && retValue[0] == 0xEF {
+		// Reject new contracts starting with the 0xEF byte (eip-3541)
+		t.txn.RevertToSnapshot(snapshot)
+		return nil, 0, address, errors.New("evm: invalid code: must not begin with 0xef")
+	}
+
 	gasCost := int64(len(retValue)) * 200
 
 	if gasLeft < gasCost {
@@ -400,10 +695,24 @@ func (t *Transition) GetTxContext() evmc.TxContext {
 		GasLimit:   t.config.Ctx.GasLimit,
 		Difficulty: t.config.Ctx.Difficulty,
 		ChainID:    cc,
+		BaseFee:    t.config.Ctx.BaseFee,
 	}
 	return ctx
 }
 
+// GetBlobTxContext returns the blob hashes of the transaction or, if it has
+// none, the ones of the context
+func (t *Transition) GetBlobTxContext() evm.BlobTxContext {
+	blobHashes := t.blobHashes
+	if len(blobHashes) == 0 {
+		blobHashes = t.config.Ctx.BlobHashes
+	}
+	return evm.BlobTxContext{
+		BlobHashes:  blobHashes,
+		BlobBaseFee: t.config.Ctx.BlobBaseFee,
+	}
+}
+
 func (t *Transition) GetBlockHash(number int64) (res evmc.Hash) {
 	return t.config.GetHash(uint64(number))
 }
@@ -432,28 +741,61 @@ func (t *Transition) GetStorage(addr evmc.Address, key evmc.Hash) evmc.Hash {
 	return t.txn.GetState(addr, key)
 }
 
+func (t *Transition) GetTransientStorage(addr evmc.Address, key evmc.Hash) evmc.Hash {
+	return t.txn.GetTransientState(addr, key)
+}
+
+func (t *Transition) SetTransientStorage(addr evmc.Address, key evmc.Hash, value evmc.Hash) {
+	t.txn.SetTransientState(addr, key, value)
+}
+
 func (t *Transition) AccountExists(addr evmc.Address) bool {
 	return t.txn.AccountExists(addr)
 }
 
+// GetRefund returns the gas refund counter of the transaction
+func (t *Transition) GetRefund() uint64 {
+	return t.txn.GetRefund()
+}
+
 func (t *Transition) GetNonce(addr evmc.Address) uint64 {
 	return t.txn.GetNonce(addr)
 }
 
 func (t *Transition) AccessAccount(addr evmc.Address) evmc.AccessStatus {
-	panic("TODO")
+	if t.txn.AddressInAccessList(addr) {
+		return evmc.WarmAccess
+	}
+	t.txn.AddAddressToAccessList(addr)
+	return evmc.ColdAccess
 }
 
 func (t *Transition) AccessStorage(addr evmc.Address, key evmc.Hash) evmc.AccessStatus {
-	panic("TODO")
+	if _, slotOk := t.txn.SlotInAccessList(addr, key); slotOk {
+		return evmc.WarmAccess
+	}
+	t.txn.AddSlotToAccessList(addr, key)
+	return evmc.ColdAccess
 }
 
 func (t *Transition) Selfdestruct(addr evmc.Address, beneficiary evmc.Address) {
-	if !t.txn.HasSuicided(addr) {
+	// The selfdestruct refund is removed in London (eip-3529)
+	if !t.isRevision(evmc.London) && !t.txn.HasSuicided(addr) {
 		t.txn.AddRefund(24000)
 	}
-	t.txn.AddBalance(beneficiary, t.txn.GetBalance(addr))
+
+	// From Cancun the account is only removed if it was created in the same
+	// transaction, otherwise only the balance is moved (eip-6780)
+	balance := t.txn.GetBalance(addr)
+	if err := t.txn.SubBalance(addr, balance); err != nil {
+		panic(err)
+	}
+	t.txn.AddBalance(beneficiary, balance)
 	t.txn.Suicide(addr)
+
+	if tracer := t.config.Tracer; tracer != nil {
+		tracer.CaptureSelfDestruct(addr, beneficiary, balance)
+	}
 }
 
 func (t *Transition) Call(kind evmc.CallKind,
@@ -477,13 +819,34 @@ func (t *Transition) Call(kind evmc.CallKind,
 }
 
 func (t *Transition) Callx(c *Contract) ([]byte, int64, evmc.Address, error) {
+	if tracer := t.config.Tracer; tracer != nil {
+		from, to, value := c.Caller, c.CodeAddress, c.Value
+		switch c.Type {
+		case evmc.Create:
+			to = createAddress(c.Caller, t.GetNonce(c.Caller))
+		case evmc.Create2:
+			to = createAddress2(c.Caller, c.Salt, c.Input)
+		case evmc.DelegateCall:
+			// the delegatecall keeps the caller and value of the parent
+			from, value = c.Address, nil
+		}
+		tracer.CaptureEnter(c.Type, from, to, c.Input, c.Gas, value, c.Static)
+
+		retValue, gasLeft, addr, err := t.callx(c)
+		tracer.CaptureExit(retValue, c.Gas-uint64(gasLeft), err)
+		return retValue, gasLeft, addr, err
+	}
+	return t.callx(c)
+}
+
+func (t *Transition) callx(c *Contract) ([]byte, int64, evmc.Address, error) {
 	if c.Type == evmc.Create || c.Type == evmc.Create2 {
 		return t.applyCreate(c)
 	}
 	return t.applyCall(c, c.Type)
 }
 
-func TransactionGasCost(msg *Message, isHomestead, isIstanbul bool) (uint64, error) {
+func TransactionGasCost(msg *Message, isHomestead, isIstanbul, isShanghai bool) (uint64, error) {
 	cost := uint64(0)
 
 	// Contract creation is only paid on the homestead fork
@@ -519,6 +882,18 @@ func TransactionGasCost(msg *Message, isHomestead, isIstanbul bool) (uint64, err
 		}
 
 		cost += zeros * 4
+
+		// initcode of a contract creation (eip-3860)
+		if msg.IsContractCreation() && isShanghai {
+			words := (uint64(len(payload)) + 31) / 32
+			cost += words * TxInitCodeWordGas
+		}
+	}
+
+	// access list (eip-2930)
+	if len(msg.AccessList) > 0 {
+		cost += uint64(len(msg.AccessList)) * TxAccessListAddressGas
+		cost += uint64(msg.AccessList.StorageKeys()) * TxAccessListStorageKeyGas
 	}
 
 	return cost, nil
@@ -0,0 +1,636 @@
+package state
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/go-evm/evm"
+	"github.com/umbracle/go-evm/precompiled"
+)
+
+var (
+	testSender   = evmc.Address{0x1}
+	testContract = evmc.Address{0x2}
+)
+
+func newTestTransition(t *testing.T, code []byte, opts ...ConfigOption) *Transition {
+	transition := NewTransition(opts...)
+	transition.Txn().AddBalance(testSender, big.NewInt(1000000000))
+	transition.Txn().SetCode(testContract, code)
+	return transition
+}
+
+// testState is an in-memory snapshot with preloaded accounts and storage
+type testState struct {
+	accounts map[evmc.Address]*Account
+	storage  map[evmc.Address]map[evmc.Hash]evmc.Hash
+}
+
+func newTestState() *testState {
+	return &testState{
+		accounts: map[evmc.Address]*Account{},
+		storage:  map[evmc.Address]map[evmc.Hash]evmc.Hash{},
+	}
+}
+
+func (s *testState) setCode(addr evmc.Address, code []byte) {
+	s.accounts[addr] = &Account{
+		Balance:  big.NewInt(0),
+		CodeHash: ethgo.Keccak256(code),
+		Code:     code,
+	}
+}
+
+func (s *testState) setStorage(addr evmc.Address, key, val evmc.Hash) {
+	if _, ok := s.storage[addr]; !ok {
+		s.storage[addr] = map[evmc.Hash]evmc.Hash{}
+	}
+	s.storage[addr][key] = val
+}
+
+func (s *testState) GetStorage(addr evmc.Address, root evmc.Hash, key evmc.Hash) evmc.Hash {
+	return s.storage[addr][key]
+}
+
+func (s *testState) GetAccount(addr evmc.Address) (*Account, error) {
+	return s.accounts[addr], nil
+}
+
+func newTestMessage(gas uint64) *Message {
+	to := testContract
+	return &Message{
+		From:     testSender,
+		To:       &to,
+		Gas:      gas,
+		GasPrice: big.NewInt(1),
+		Value:    big.NewInt(0),
+	}
+}
+
+func TestTransition_AccessList(t *testing.T) {
+	// PUSH1 0x0 SLOAD PUSH1 0x0 SLOAD STOP
+	code := []byte{0x60, 0x00, 0x54, 0x60, 0x00, 0x54, 0x00}
+
+	t.Run("Cold", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.Berlin))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+
+		// the first sload is cold and the second one is warm
+		assert.Equal(t, uint64(100000-21000-3-2100-3-100), output.GasLeft)
+	})
+
+	t.Run("PreWarmed", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.Berlin))
+
+		msg := newTestMessage(100000)
+		msg.AccessList = AccessList{
+			{Address: testContract, StorageKeys: []evmc.Hash{{}}},
+		}
+
+		output, err := transition.Write(msg)
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+
+		intrinsic := 21000 + TxAccessListAddressGas + TxAccessListStorageKeyGas
+		assert.Equal(t, uint64(100000)-intrinsic-3-100-3-100, output.GasLeft)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.Berlin))
+
+		_, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+
+		// the access list does not leak into the next transaction
+		msg := newTestMessage(100000)
+		msg.Nonce = 1
+
+		output, err := transition.Write(msg)
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(100000-21000-3-2100-3-100), output.GasLeft)
+	})
+}
+
+func TestTxn_AccessListSnapshot(t *testing.T) {
+	txn := NewTxn(&EmptyState{})
+
+	addr := evmc.Address{0x1}
+	slot := evmc.Hash{0x1}
+
+	txn.AddAddressToAccessList(addr)
+	snapshot := txn.Snapshot()
+
+	txn.AddSlotToAccessList(addr, slot)
+
+	addrOk, slotOk := txn.SlotInAccessList(addr, slot)
+	assert.True(t, addrOk)
+	assert.True(t, slotOk)
+
+	txn.RevertToSnapshot(snapshot)
+
+	addrOk, slotOk = txn.SlotInAccessList(addr, slot)
+	assert.True(t, addrOk)
+	assert.False(t, slotOk)
+}
+
+func TestTransition_FeeMarket(t *testing.T) {
+	// BASEFEE PUSH1 0x0 MSTORE PUSH1 0x20 PUSH1 0x0 RETURN
+	code := []byte{0x48, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+
+	coinbase := evmc.Address{0x3}
+	ctx := TxContext{
+		Coinbase: coinbase,
+		BaseFee:  bytesToHash(big.NewInt(10).Bytes()),
+	}
+
+	t.Run("EffectiveTip", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.London), WithContext(ctx))
+
+		msg := newTestMessage(100000)
+		msg.GasPrice = nil
+		msg.GasFeeCap = big.NewInt(15)
+		msg.GasTipCap = big.NewInt(2)
+
+		output, err := transition.Write(msg)
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+		assert.Equal(t, uint64(10), new(big.Int).SetBytes(output.ReturnValue).Uint64())
+
+		gasUsed := int64(100000 - output.GasLeft)
+
+		// the sender pays base fee + tip and the coinbase only gets the tip
+		assert.Equal(t, big.NewInt(1000000000-gasUsed*12), transition.Txn().GetBalance(testSender))
+		assert.Equal(t, big.NewInt(gasUsed*2), transition.Txn().GetBalance(coinbase))
+	})
+
+	t.Run("FeeCapTooLow", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.London), WithContext(ctx))
+
+		msg := newTestMessage(100000)
+		msg.GasPrice = nil
+		msg.GasFeeCap = big.NewInt(9)
+		msg.GasTipCap = big.NewInt(1)
+
+		_, err := transition.Write(msg)
+		assert.Error(t, err)
+	})
+
+	t.Run("BaseFeeOpcodeBeforeLondon", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.Berlin), WithContext(ctx))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.False(t, output.Success)
+	})
+}
+
+func TestTransition_RefundReduction(t *testing.T) {
+	// PUSH1 0x0 PUSH1 0x0 SSTORE STOP
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0x55, 0x00}
+
+	cases := []struct {
+		rev    evmc.Revision
+		refund uint64
+	}{
+		// the refund is capped at half the gas used
+		{evmc.Berlin, (21000 + 3 + 3 + 2100 + 2900) / 2},
+		// the clear refund is reduced and capped at a fifth of the gas used (eip-3529)
+		{evmc.London, 4800},
+	}
+
+	for _, c := range cases {
+		snapshot := newTestState()
+		snapshot.setCode(testContract, code)
+		snapshot.setStorage(testContract, evmc.Hash{}, evmc.Hash{31: 0x1})
+
+		transition := NewTransition(WithRevision(c.rev), WithState(snapshot))
+		transition.Txn().AddBalance(testSender, big.NewInt(1000000000))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+
+		assert.Equal(t, uint64(100000-21000-3-3-2100-2900)+c.refund, output.GasLeft)
+	}
+}
+
+func TestTransition_Shanghai(t *testing.T) {
+	// COINBASE BALANCE POP PUSH0 STOP
+	code := []byte{0x41, 0x31, 0x50, 0x5f, 0x00}
+
+	t.Run("WarmCoinbase", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.Shanghai))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+		assert.Equal(t, uint64(100000-21000-2-100-2-2), output.GasLeft)
+	})
+
+	t.Run("Push0BeforeShanghai", func(t *testing.T) {
+		transition := newTestTransition(t, code, WithRevision(evmc.London))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.False(t, output.Success)
+	})
+
+	t.Run("InitCode", func(t *testing.T) {
+		msg := &Message{
+			Input: make([]byte, 33),
+		}
+
+		cost, err := TransactionGasCost(msg, true, true, false)
+		assert.NoError(t, err)
+		assert.Equal(t, TxGasContractCreation+33*4, cost)
+
+		cost, err = TransactionGasCost(msg, true, true, true)
+		assert.NoError(t, err)
+		assert.Equal(t, TxGasContractCreation+33*4+2*TxInitCodeWordGas, cost)
+	})
+
+	t.Run("InitCodeSizeLimit", func(t *testing.T) {
+		transition := newTestTransition(t, nil, WithRevision(evmc.Shanghai))
+
+		msg := newTestMessage(10000000)
+		msg.To = nil
+		msg.Input = make([]byte, evm.MaxInitCodeSize+1)
+
+		_, err := transition.Write(msg)
+		assert.Error(t, err)
+	})
+
+	t.Run("InitCodeSizeLimitCreate", func(t *testing.T) {
+		// CREATE(1, 0, MaxInitCodeSize+1) STOP without balance for the value,
+		// the size limit fails the frame before the balance check
+		code := []byte{0x62, 0x00, 0xc0, 0x01, 0x60, 0x00, 0x60, 0x01, 0xf0, 0x00}
+		transition := newTestTransition(t, code, WithRevision(evmc.Shanghai))
+
+		output, err := transition.Write(newTestMessage(1000000))
+		assert.NoError(t, err)
+		assert.False(t, output.Success)
+		assert.Equal(t, uint64(0), output.GasLeft)
+	})
+}
+
+func TestTransition_TransientStorage(t *testing.T) {
+	// TSTORE(0, 1) MSTORE(0, TLOAD(0)) MCOPY(32, 0, 32) RETURN(32, 32)
+	code := []byte{
+		0x60, 0x01, 0x60, 0x00, 0x5d,
+		0x60, 0x00, 0x5c, 0x60, 0x00, 0x52,
+		0x60, 0x20, 0x60, 0x00, 0x60, 0x20, 0x5e,
+		0x60, 0x20, 0x60, 0x20, 0xf3,
+	}
+
+	transition := newTestTransition(t, code, WithRevision(evm.Cancun))
+
+	output, err := transition.Write(newTestMessage(100000))
+	assert.NoError(t, err)
+	assert.True(t, output.Success)
+	assert.Equal(t, uint64(1), new(big.Int).SetBytes(output.ReturnValue).Uint64())
+
+	// the transient storage is cleared at the end of the transaction
+	assert.Equal(t, evmc.Hash{}, transition.Txn().GetTransientState(testContract, evmc.Hash{}))
+}
+
+func TestTransition_BlobHash(t *testing.T) {
+	// MSTORE(0, BLOBHASH(1)) RETURN(0, 32)
+	code := []byte{0x60, 0x01, 0x49, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3}
+
+	ctx := TxContext{
+		BlobHashes: []evmc.Hash{{0x1}, {0x2}},
+	}
+	transition := newTestTransition(t, code, WithRevision(evm.Cancun), WithContext(ctx))
+
+	output, err := transition.Write(newTestMessage(100000))
+	assert.NoError(t, err)
+	assert.True(t, output.Success)
+	assert.Equal(t, evmc.Hash{0x2}, bytesToHash(output.ReturnValue))
+}
+
+func TestTxn_TransientStorageSnapshot(t *testing.T) {
+	txn := NewTxn(&EmptyState{})
+
+	addr := evmc.Address{0x1}
+	key := evmc.Hash{0x1}
+
+	txn.SetTransientState(addr, key, evmc.Hash{0x1})
+	snapshot := txn.Snapshot()
+
+	txn.SetTransientState(addr, key, evmc.Hash{0x2})
+	assert.Equal(t, evmc.Hash{0x2}, txn.GetTransientState(addr, key))
+
+	txn.RevertToSnapshot(snapshot)
+	assert.Equal(t, evmc.Hash{0x1}, txn.GetTransientState(addr, key))
+}
+
+func TestTransition_SelfdestructEIP6780(t *testing.T) {
+	beneficiary := evmc.Address{0x4}
+
+	// PUSH20 beneficiary SELFDESTRUCT
+	code := append(append([]byte{0x73}, beneficiary[:]...), 0xff)
+
+	findObject := func(objs []*Object, addr evmc.Address) *Object {
+		for _, obj := range objs {
+			if obj.Address == addr {
+				return obj
+			}
+		}
+		t.Fatalf("object %s not found", addr)
+		return nil
+	}
+
+	t.Run("Existing", func(t *testing.T) {
+		for _, rev := range []evmc.Revision{evmc.Shanghai, evm.Cancun} {
+			transition := newTestTransition(t, code, WithRevision(rev))
+			transition.Txn().AddBalance(testContract, big.NewInt(100))
+
+			output, err := transition.Write(newTestMessage(100000))
+			assert.NoError(t, err)
+			assert.True(t, output.Success)
+
+			objs := transition.Commit()
+
+			// only the accounts created in the same transaction are removed in Cancun
+			assert.Equal(t, rev < evm.Cancun, findObject(objs, testContract).Deleted)
+			assert.Equal(t, big.NewInt(100), findObject(objs, beneficiary).Balance)
+		}
+	})
+
+	t.Run("Created", func(t *testing.T) {
+		transition := newTestTransition(t, nil, WithRevision(evm.Cancun))
+
+		msg := newTestMessage(100000)
+		msg.To = nil
+		msg.Input = code
+
+		output, err := transition.Write(msg)
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+
+		objs := transition.Commit()
+		assert.True(t, findObject(objs, output.ContractAddress).Deleted)
+	})
+}
+
+type testPrecompile struct {
+	calls int
+}
+
+func (p *testPrecompile) Gas(input []byte, rev evmc.Revision) uint64 {
+	return 100
+}
+
+func (p *testPrecompile) Run(input []byte) ([]byte, error) {
+	p.calls++
+	return nil, nil
+}
+
+func TestTransition_Precompile(t *testing.T) {
+	// CALL(0xffff, addr, 0, 0, 0, 0, 0) STOP
+	callCode := func(addr evmc.Address) []byte {
+		return []byte{
+			0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00,
+			0x61, addr[18], addr[19], 0x61, 0xff, 0xff, 0xf1, 0x00,
+		}
+	}
+
+	t.Run("Custom", func(t *testing.T) {
+		addr := evmc.Address{19: 0xff}
+
+		for _, rev := range []evmc.Revision{evmc.Berlin, evmc.London} {
+			p := &testPrecompile{}
+			transition := newTestTransition(t, callCode(addr), WithRevision(rev), WithPrecompile(addr, p, evmc.London))
+
+			output, err := transition.Write(newTestMessage(100000))
+			assert.NoError(t, err)
+			assert.True(t, output.Success)
+
+			// the precompile is only enabled from London
+			assert.Equal(t, rev >= evmc.London, p.calls == 1)
+		}
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		p := &testPrecompile{}
+		transition := newTestTransition(t, callCode(addr4), WithPrecompile(addr4, p, evmc.Frontier))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+		assert.Equal(t, 1, p.calls)
+
+		// other transitions still use the default precompile
+		assert.True(t, NewTransition().isPrecompiled(addr4))
+		assert.Equal(t, &precompiled.Identity{}, NewTransition().config.precompiles[addr4].contract)
+	})
+
+	t.Run("Remove", func(t *testing.T) {
+		transition := NewTransition(WithPrecompile(addr4, nil, evmc.Frontier))
+		assert.False(t, transition.isPrecompiled(addr4))
+		assert.True(t, transition.isPrecompiled(addr1))
+	})
+}
+
+type testTracerStep struct {
+	op    evm.OpCode
+	cost  uint64
+	stack int
+	depth int
+}
+
+type testTracer struct {
+	events []string
+	steps  []testTracerStep
+}
+
+func (t *testTracer) CaptureStart(host evm.Host, from, to evmc.Address, create bool, input []byte, gas uint64, value *big.Int) {
+	t.events = append(t.events, "start")
+}
+
+func (t *testTracer) CaptureState(pc uint64, op evm.OpCode, gas, cost, refund uint64, stack []*big.Int, memory []byte, memSize int, depth int) {
+	t.events = append(t.events, op.String())
+	t.steps = append(t.steps, testTracerStep{op: op, cost: cost, stack: len(stack), depth: depth})
+}
+
+func (t *testTracer) CaptureStack() bool {
+	return true
+}
+
+func (t *testTracer) CaptureMemory() bool {
+	return false
+}
+
+func (t *testTracer) CaptureFault(pc uint64, op evm.OpCode, gas, cost uint64, depth int, err error) {
+	t.events = append(t.events, "fault")
+}
+
+func (t *testTracer) CaptureEnter(typ evmc.CallKind, from, to evmc.Address, input []byte, gas uint64, value *big.Int, static bool) {
+	t.events = append(t.events, "enter")
+}
+
+func (t *testTracer) CaptureExit(output []byte, gasUsed uint64, err error) {
+	t.events = append(t.events, "exit")
+}
+
+func (t *testTracer) CaptureEnd(output []byte, gasUsed uint64, err error) {
+	t.events = append(t.events, "end")
+}
+
+func (t *testTracer) CaptureSelfDestruct(addr, beneficiary evmc.Address, balance *big.Int) {
+	t.events = append(t.events, "selfdestruct")
+}
+
+func TestTransition_JumpdestCache(t *testing.T) {
+	// PUSH1 0x4 JUMP INVALID JUMPDEST STOP
+	code := []byte{0x60, 0x04, 0x56, 0xfe, 0x5b, 0x00}
+
+	cache := evm.MustJumpdestCache(16)
+
+	for i := 0; i < 3; i++ {
+		transition := newTestTransition(t, code, WithJumpdestCache(cache))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+	}
+
+	// only the first call analyzes the code
+	metrics := cache.Metrics()
+	assert.Equal(t, uint64(2), metrics.Hits)
+	assert.Equal(t, uint64(1), metrics.Misses)
+
+	// the cache is opt-in and the code is analyzed on every call without it
+	assert.Nil(t, DefaultConfig().JumpdestCache)
+	transition := newTestTransition(t, code)
+
+	output, err := transition.Write(newTestMessage(100000))
+	assert.NoError(t, err)
+	assert.True(t, output.Success)
+}
+
+func TestTransition_Interrupt(t *testing.T) {
+	// CALL(GAS, 0x20, 0, 0, 0, 0, 0) STOP
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x20, 0x5a, 0xf1, 0x00}
+
+	// JUMPDEST PUSH1 0x0 JUMP
+	loop := []byte{0x5b, 0x60, 0x00, 0x56}
+
+	newTransition := func(opts ...ConfigOption) *Transition {
+		transition := newTestTransition(t, code, opts...)
+		transition.Txn().SetCode(evmc.Address{19: 0x20}, loop)
+		return transition
+	}
+
+	t.Run("StepLimit", func(t *testing.T) {
+		transition := newTransition(WithStepLimit(1000))
+
+		msg := newTestMessage(10000000)
+		_, err := transition.Write(msg)
+		assert.ErrorIs(t, err, evm.ErrExecutionAborted)
+
+		// the aborted call in the sub call is not handled by the parent
+		// and the transaction is discarded
+		assert.Equal(t, uint64(10000000), msg.Gas)
+		assert.Equal(t, uint64(0), transition.Txn().GetNonce(testSender))
+		assert.Equal(t, big.NewInt(1000000000), transition.Txn().GetBalance(testSender))
+	})
+
+	t.Run("Context", func(t *testing.T) {
+		transition := newTransition()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := transition.WriteContext(ctx, newTestMessage(10000000))
+		assert.ErrorIs(t, err, evm.ErrExecutionAborted)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		transition := newTransition()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		msg := newTestMessage(1 << 40)
+		msg.GasPrice = big.NewInt(0)
+
+		_, err := transition.WriteContext(ctx, msg)
+		assert.ErrorIs(t, err, evm.ErrExecutionAborted)
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		transition := newTestTransition(t, []byte{0x00}, WithStepLimit(1))
+
+		output, err := transition.WriteContext(context.Background(), newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.True(t, output.Success)
+	})
+}
+
+func TestTransition_Tracer(t *testing.T) {
+	// PUSH1 0x1 PUSH1 0x2 ADD POP CALL(0xffff, 0x4, 0, 0, 0, 0, 0) STOP
+	code := []byte{
+		0x60, 0x01, 0x60, 0x02, 0x01, 0x50,
+		0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00, 0x60, 0x00,
+		0x61, 0x00, 0x04, 0x61, 0xff, 0xff, 0xf1, 0x00,
+	}
+
+	tracer := &testTracer{}
+	transition := newTestTransition(t, code, WithRevision(evmc.Berlin), WithTracer(tracer))
+
+	output, err := transition.Write(newTestMessage(100000))
+	assert.NoError(t, err)
+	assert.True(t, output.Success)
+
+	assert.Equal(t, []string{
+		"start", "PUSH1", "PUSH1", "ADD", "POP",
+		"PUSH1", "PUSH1", "PUSH1", "PUSH1", "PUSH1", "PUSH2", "PUSH2",
+		"CALL", "enter", "exit", "STOP", "end",
+	}, tracer.events)
+
+	assert.Equal(t, testTracerStep{op: evm.ADD, cost: 3, stack: 2, depth: 0}, tracer.steps[2])
+
+	// the cost of the call includes the gas sent to the precompile
+	assert.Equal(t, testTracerStep{op: evm.CALL, cost: 100 + 0xffff, stack: 7, depth: 0}, tracer.steps[11])
+
+	t.Run("Fault", func(t *testing.T) {
+		// ADD with an empty stack
+		tracer := &testTracer{}
+		transition := newTestTransition(t, []byte{0x01}, WithTracer(tracer))
+
+		output, err := transition.Write(newTestMessage(100000))
+		assert.NoError(t, err)
+		assert.False(t, output.Success)
+		assert.Equal(t, []string{"start", "ADD", "fault", "end"}, tracer.events)
+	})
+}
+
+func TestTransition_SystemCall(t *testing.T) {
+	// LOG0(0, 0) SSTORE(0, ORIGIN)
+	code := []byte{0x60, 0x00, 0x60, 0x00, 0xa0, 0x32, 0x60, 0x00, 0x55, 0x00}
+
+	ctx := TxContext{
+		Origin:   testSender,
+		GasPrice: evmc.Hash{0x1},
+	}
+	transition := newTestTransition(t, code, WithRevision(evm.Cancun), WithContext(ctx))
+
+	_, err := transition.SystemCall(testContract, nil)
+	assert.NoError(t, err)
+	origin := transition.Txn().GetState(testContract, evmc.Hash{})
+	assert.Equal(t, SystemAddress[:], origin[12:])
+
+	// the context is restored and the logs of the call are discarded
+	assert.Equal(t, testSender, transition.config.Ctx.Origin)
+	assert.Equal(t, evmc.Hash{0x1}, transition.config.Ctx.GasPrice)
+	assert.Empty(t, transition.Txn().Logs())
+}
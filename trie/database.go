@@ -0,0 +1,146 @@
+package trie
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+// DefaultNodeCacheSize is the number of decoded nodes kept by the database
+const DefaultNodeCacheSize = 65536
+
+// codePrefix is the prefix of the keys of the code of the contracts
+var codePrefix = []byte("code")
+
+// Storage is the key value store where the nodes of the tries are persisted
+// by their hash
+type Storage interface {
+	Get(key []byte) ([]byte, bool, error)
+	Put(key, value []byte) error
+}
+
+// MemoryStorage is a storage in memory
+type MemoryStorage struct {
+	lock sync.RWMutex
+	db   map[string][]byte
+}
+
+// NewMemoryStorage creates an empty storage in memory
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		db: map[string][]byte{},
+	}
+}
+
+func (m *MemoryStorage) Get(key []byte) ([]byte, bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	val, ok := m.db[string(key)]
+	return val, ok, nil
+}
+
+func (m *MemoryStorage) Put(key, value []byte) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.db[string(key)] = append([]byte{}, value...)
+	return nil
+}
+
+// Len returns the number of entries of the storage
+func (m *MemoryStorage) Len() int {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	return len(m.db)
+}
+
+// MissingNodeError is returned when a node of a trie is not in the storage
+type MissingNodeError struct {
+	Hash evmc.Hash
+}
+
+func (e *MissingNodeError) Error() string {
+	return fmt.Sprintf("missing trie node %x", e.Hash[:])
+}
+
+// Database loads the nodes of the tries from the storage and keeps the
+// decoded nodes in a LRU cache. It is safe for concurrent use.
+type Database struct {
+	storage Storage
+	cache   *lru.Cache
+}
+
+// NewDatabase creates a database on top of the storage with the default
+// size of the cache
+func NewDatabase(storage Storage) *Database {
+	db, err := NewDatabaseWithCache(storage, DefaultNodeCacheSize)
+	if err != nil {
+		panic(err)
+	}
+	return db
+}
+
+// NewDatabaseWithCache creates a database that caches up to size nodes
+func NewDatabaseWithCache(storage Storage, size int) (*Database, error) {
+	cache, err := lru.New(size)
+	if err != nil {
+		return nil, err
+	}
+	return &Database{storage: storage, cache: cache}, nil
+}
+
+// node returns the decoded node of the hash
+func (db *Database) node(hash hashNode) (node, error) {
+	if n, ok := db.cache.Get(string(hash)); ok {
+		return n.(node), nil
+	}
+
+	buf, ok, err := db.storage.Get(hash)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, &MissingNodeError{Hash: evmc.Hash(toHash(hash))}
+	}
+	n, err := decodeNode(hash, buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode node %x: %v", []byte(hash), err)
+	}
+	db.cache.Add(string(hash), n)
+	return n, nil
+}
+
+// put stores the encoding of the node with the hash and caches the node
+func (db *Database) put(hash []byte, buf []byte, n node) error {
+	if err := db.storage.Put(hash, buf); err != nil {
+		return err
+	}
+	db.cache.Add(string(hash), n)
+	return nil
+}
+
+// Code returns the code with the hash
+func (db *Database) Code(hash evmc.Hash) ([]byte, error) {
+	code, ok, err := db.storage.Get(concat(codePrefix, hash[:]))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("missing code %x", hash[:])
+	}
+	return code, nil
+}
+
+// PutCode stores the code by its hash
+func (db *Database) PutCode(hash evmc.Hash, code []byte) error {
+	return db.storage.Put(concat(codePrefix, hash[:]), code)
+}
+
+func toHash(buf []byte) (hash evmc.Hash) {
+	copy(hash[:], buf)
+	return
+}
@@ -0,0 +1,83 @@
+package trie
+
+// keybytesToHex returns the path of nibbles of the key with the
+// terminator (16) at the end
+func keybytesToHex(str []byte) []byte {
+	l := len(str)*2 + 1
+	nibbles := make([]byte, l)
+	for i, b := range str {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	nibbles[l-1] = 16
+	return nibbles
+}
+
+func hasTerm(s []byte) bool {
+	return len(s) > 0 && s[len(s)-1] == 16
+}
+
+// hexToCompact returns the compact (hex prefix) encoding of the path of
+// a short node, the flags of the first nibble mark the leaves and the
+// odd lengths
+func hexToCompact(hex []byte) []byte {
+	terminator := byte(0)
+	if hasTerm(hex) {
+		terminator = 1
+		hex = hex[:len(hex)-1]
+	}
+
+	buf := make([]byte, len(hex)/2+1)
+	buf[0] = terminator << 5
+	if len(hex)&1 == 1 {
+		buf[0] |= 1 << 4
+		buf[0] |= hex[0]
+		hex = hex[1:]
+	}
+	for bi, ni := 1, 0; ni < len(hex); bi, ni = bi+1, ni+2 {
+		buf[bi] = hex[ni]<<4 | hex[ni+1]
+	}
+	return buf
+}
+
+// compactToHex returns the path of nibbles of the compact encoding
+func compactToHex(compact []byte) []byte {
+	if len(compact) == 0 {
+		return compact
+	}
+
+	base := make([]byte, len(compact)*2+1)
+	for i, b := range compact {
+		base[i*2] = b / 16
+		base[i*2+1] = b % 16
+	}
+	base[len(base)-1] = 16
+
+	// remove the terminator if it is not a leaf
+	if base[0] < 2 {
+		base = base[:len(base)-1]
+	}
+	// remove the flags and the padding nibble if the length is even
+	chop := 2 - base[0]&1
+	return base[chop:]
+}
+
+func prefixLen(a, b []byte) int {
+	i, length := 0, len(a)
+	if len(b) < length {
+		length = len(b)
+	}
+	for ; i < length; i++ {
+		if a[i] != b[i] {
+			break
+		}
+	}
+	return i
+}
+
+func concat(a, b []byte) []byte {
+	c := make([]byte, len(a)+len(b))
+	copy(c, a)
+	copy(c[len(a):], b)
+	return c
+}
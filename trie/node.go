@@ -0,0 +1,227 @@
+package trie
+
+import (
+	"fmt"
+
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/fastrlp"
+)
+
+// node is a node of the trie, one of *fullNode, *shortNode, hashNode or
+// valueNode. The nodes are never modified after they are part of a trie,
+// the updates create new nodes along the path (copy on write) so that the
+// previous roots are still valid.
+type node interface{}
+
+type (
+	// fullNode is a branch with a child for each nibble and the value of
+	// the path that ends in the node at index 16
+	fullNode struct {
+		Children [17]node
+		flags    nodeFlag
+	}
+
+	// shortNode is an extension if the child is a node or a leaf if the
+	// key has the terminator and the child is a value
+	shortNode struct {
+		Key   []byte
+		Val   node
+		flags nodeFlag
+	}
+
+	// hashNode is a reference to a node stored in the database
+	hashNode []byte
+
+	// valueNode is the value of a key
+	valueNode []byte
+)
+
+// nodeFlag is the cached state of a node
+type nodeFlag struct {
+	// hash is the hash of the encoding of the node or nil if it has not
+	// been hashed yet or if the encoding is shorter than 32 bytes, in
+	// which case the node is embedded in its parent
+	hash hashNode
+
+	// dirty is set if the node is not in the database
+	dirty bool
+}
+
+func (n *fullNode) copy() *fullNode {
+	c := *n
+	return &c
+}
+
+func (n *shortNode) copy() *shortNode {
+	c := *n
+	return &c
+}
+
+// hasher computes the encodings and the hashes of the nodes, the hashes
+// are cached in the nodes so that only the dirty paths are hashed again
+type hasher struct {
+	a fastrlp.Arena
+}
+
+// encode returns the rlp encoding of the node, the children are replaced
+// by their references
+func (h *hasher) encode(n node) *fastrlp.Value {
+	a := &h.a
+
+	switch n := n.(type) {
+	case *shortNode:
+		v := a.NewArray()
+		v.Set(a.NewCopyBytes(hexToCompact(n.Key)))
+		v.Set(h.ref(n.Val))
+		return v
+
+	case *fullNode:
+		v := a.NewArray()
+		for _, child := range n.Children {
+			v.Set(h.ref(child))
+		}
+		return v
+
+	default:
+		panic(fmt.Sprintf("cannot encode node %T", n))
+	}
+}
+
+// ref returns the reference of the node in its parent, which is the hash of
+// its encoding or the encoding itself if it is shorter than 32 bytes
+func (h *hasher) ref(n node) *fastrlp.Value {
+	a := &h.a
+
+	switch n := n.(type) {
+	case nil:
+		return a.NewNull()
+
+	case valueNode:
+		return a.NewCopyBytes(n)
+
+	case hashNode:
+		return a.NewCopyBytes(n)
+	}
+
+	flags := nodeFlags(n)
+	if flags.hash != nil {
+		return a.NewCopyBytes(flags.hash)
+	}
+
+	v := h.encode(n)
+	buf := v.MarshalTo(nil)
+	if len(buf) < 32 {
+		return v
+	}
+	flags.hash = ethgo.Keccak256(buf)
+	return a.NewCopyBytes(flags.hash)
+}
+
+// root returns the hash of the root node, which is hashed even if its
+// encoding is shorter than 32 bytes, and its encoding
+func (h *hasher) root(n node) ([]byte, []byte) {
+	if hash, ok := n.(hashNode); ok {
+		return hash, nil
+	}
+
+	buf := h.encode(n).MarshalTo(nil)
+	hash := ethgo.Keccak256(buf)
+	if len(buf) >= 32 {
+		nodeFlags(n).hash = hash
+	}
+	return hash, buf
+}
+
+func nodeFlags(n node) *nodeFlag {
+	switch n := n.(type) {
+	case *fullNode:
+		return &n.flags
+	case *shortNode:
+		return &n.flags
+	default:
+		panic(fmt.Sprintf("node %T has no flags", n))
+	}
+}
+
+// decodeNode decodes the encoding of the node with the hash
+func decodeNode(hash, buf []byte) (node, error) {
+	p := &fastrlp.Parser{}
+	v, err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+	n, err := decodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) >= 32 {
+		nodeFlags(n).hash = hashNode(hash)
+	}
+	return n, nil
+}
+
+func decodeValue(v *fastrlp.Value) (node, error) {
+	elems, err := v.GetElems()
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(elems) {
+	case 2:
+		compact, err := elems[0].GetBytes(nil)
+		if err != nil {
+			return nil, err
+		}
+		n := &shortNode{Key: compactToHex(compact)}
+		if hasTerm(n.Key) {
+			val, err := elems[1].GetBytes(nil)
+			if err != nil {
+				return nil, err
+			}
+			n.Val = valueNode(val)
+		} else if n.Val, err = decodeRef(elems[1]); err != nil {
+			return nil, err
+		}
+		return n, nil
+
+	case 17:
+		n := &fullNode{}
+		for i := 0; i < 16; i++ {
+			if n.Children[i], err = decodeRef(elems[i]); err != nil {
+				return nil, err
+			}
+		}
+		val, err := elems[16].GetBytes(nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(val) != 0 {
+			n.Children[16] = valueNode(val)
+		}
+		return n, nil
+
+	default:
+		return nil, fmt.Errorf("invalid number of elements of a node %d", len(elems))
+	}
+}
+
+// decodeRef decodes the reference of a child, which is empty, a hash or an
+// embedded node
+func decodeRef(v *fastrlp.Value) (node, error) {
+	if v.Type() == fastrlp.TypeArray {
+		return decodeValue(v)
+	}
+
+	buf, err := v.GetBytes(nil)
+	if err != nil {
+		return nil, err
+	}
+	switch len(buf) {
+	case 0:
+		return nil, nil
+	case 32:
+		return hashNode(buf), nil
+	default:
+		return nil, fmt.Errorf("invalid reference of %d bytes", len(buf))
+	}
+}
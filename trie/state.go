@@ -0,0 +1,220 @@
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/umbracle/ethgo"
+	"github.com/umbracle/fastrlp"
+	state "github.com/umbracle/go-evm"
+)
+
+// State is the state of the accounts at a root. It is the snapshot of the
+// transitions of a block and it moves to the next root with StateRoot.
+type State struct {
+	db   *Database
+	trie *Trie
+}
+
+// NewState opens the state at the root
+func NewState(db *Database, root evmc.Hash) (*State, error) {
+	t, err := Open(db, root)
+	if err != nil {
+		return nil, err
+	}
+	return &State{db: db, trie: t}, nil
+}
+
+// Root returns the current root of the state
+func (s *State) Root() evmc.Hash {
+	return s.trie.Hash()
+}
+
+// GetAccount returns the account or nil if it does not exist
+func (s *State) GetAccount(addr evmc.Address) (*state.Account, error) {
+	acct, err := s.getAccount(ethgo.Keccak256(addr[:]))
+	if err != nil || acct == nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(acct.CodeHash, state.EmptyCodeHash[:]) {
+		if acct.Code, err = s.db.Code(toHash(acct.CodeHash)); err != nil {
+			return nil, err
+		}
+	}
+	return acct, nil
+}
+
+func (s *State) getAccount(addrHash []byte) (*state.Account, error) {
+	buf, err := s.trie.Get(addrHash)
+	if err != nil || buf == nil {
+		return nil, err
+	}
+	return decodeAccount(buf)
+}
+
+// GetStorage returns the value of the key in the storage with the root. It
+// panics if the storage trie is not in the database.
+func (s *State) GetStorage(addr evmc.Address, root evmc.Hash, key evmc.Hash) evmc.Hash {
+	t, err := Open(s.db, root)
+	if err != nil {
+		panic(fmt.Sprintf("failed to open the storage of %x: %v", addr[:], err))
+	}
+	buf, err := t.Get(ethgo.Keccak256(key[:]))
+	if err != nil {
+		panic(fmt.Sprintf("failed to read the storage of %x: %v", addr[:], err))
+	}
+	if buf == nil {
+		return evmc.Hash{}
+	}
+
+	p := &fastrlp.Parser{}
+	v, err := p.Parse(buf)
+	if err != nil {
+		panic(fmt.Sprintf("failed to decode the storage of %x: %v", addr[:], err))
+	}
+	val, err := v.Bytes()
+	if err != nil {
+		panic(fmt.Sprintf("failed to decode the storage of %x: %v", addr[:], err))
+	}
+
+	var res evmc.Hash
+	copy(res[32-len(val):], val)
+	return res
+}
+
+// StateRoot applies the objects committed by a transition to the state,
+// writes the updated nodes and the new code to the database and returns
+// the new root
+func (s *State) StateRoot(objs []*state.Object) (evmc.Hash, error) {
+	arena := &fastrlp.Arena{}
+
+	for _, obj := range objs {
+		addrHash := ethgo.Keccak256(obj.Address[:])
+
+		if obj.Deleted {
+			if err := s.trie.Delete(addrHash); err != nil {
+				return evmc.Hash{}, err
+			}
+			continue
+		}
+
+		// the storage is updated from the root of the account in the
+		// snapshot, which is empty if the account was created again
+		root, err := s.commitStorage(obj.Root, obj.Storage)
+		if err != nil {
+			return evmc.Hash{}, fmt.Errorf("failed to commit the storage of %x: %v", obj.Address[:], err)
+		}
+
+		if obj.DirtyCode && len(obj.Code) != 0 {
+			if err := s.db.PutCode(obj.CodeHash, obj.Code); err != nil {
+				return evmc.Hash{}, err
+			}
+		}
+
+		balance := obj.Balance
+		if balance == nil {
+			balance = new(big.Int)
+		}
+		acct := &state.Account{
+			Nonce:    obj.Nonce,
+			Balance:  balance,
+			Root:     root,
+			CodeHash: obj.CodeHash[:],
+		}
+
+		arena.Reset()
+		if err := s.trie.Insert(addrHash, marshalAccount(arena, acct).MarshalTo(nil)); err != nil {
+			return evmc.Hash{}, err
+		}
+	}
+	return s.trie.Commit()
+}
+
+func (s *State) commitStorage(root evmc.Hash, entries []*state.StorageObject) (evmc.Hash, error) {
+	t, err := Open(s.db, root)
+	if err != nil {
+		return evmc.Hash{}, err
+	}
+
+	arena := &fastrlp.Arena{}
+	for _, entry := range entries {
+		key := ethgo.Keccak256(entry.Key)
+
+		val := bytes.TrimLeft(entry.Val, "\x00")
+		if entry.Deleted || len(val) == 0 {
+			err = t.Delete(key)
+		} else {
+			arena.Reset()
+			err = t.Insert(key, arena.NewBytes(val).MarshalTo(nil))
+		}
+		if err != nil {
+			return evmc.Hash{}, err
+		}
+	}
+	return t.Commit()
+}
+
+// StateRoot returns the root of the state with only the objects, each one
+// with all its storage
+func StateRoot(objs []*state.Object) evmc.Hash {
+	s, err := NewState(NewDatabase(NewMemoryStorage()), state.EmptyRootHash)
+	if err != nil {
+		panic(err)
+	}
+
+	full := make([]*state.Object, 0, len(objs))
+	for _, obj := range objs {
+		obj := *obj
+		obj.Root = state.EmptyRootHash
+		full = append(full, &obj)
+	}
+	root, err := s.StateRoot(full)
+	if err != nil {
+		panic(err)
+	}
+	return root
+}
+
+func marshalAccount(a *fastrlp.Arena, acct *state.Account) *fastrlp.Value {
+	v := a.NewArray()
+	v.Set(a.NewUint(acct.Nonce))
+	v.Set(a.NewBigInt(acct.Balance))
+	v.Set(a.NewCopyBytes(acct.Root[:]))
+	v.Set(a.NewCopyBytes(acct.CodeHash))
+	return v
+}
+
+func decodeAccount(buf []byte) (*state.Account, error) {
+	p := &fastrlp.Parser{}
+	v, err := p.Parse(buf)
+	if err != nil {
+		return nil, err
+	}
+	elems, err := v.GetElems()
+	if err != nil {
+		return nil, err
+	}
+	if len(elems) != 4 {
+		return nil, fmt.Errorf("invalid account with %d elements", len(elems))
+	}
+
+	acct := &state.Account{
+		Balance: new(big.Int),
+	}
+	if acct.Nonce, err = elems[0].GetUint64(); err != nil {
+		return nil, err
+	}
+	if err := elems[1].GetBigInt(acct.Balance); err != nil {
+		return nil, err
+	}
+	if err := elems[2].GetHash(acct.Root[:]); err != nil {
+		return nil, err
+	}
+	if acct.CodeHash, err = elems[3].GetBytes(nil); err != nil {
+		return nil, err
+	}
+	return acct, nil
+}
@@ -0,0 +1,371 @@
+package trie
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	state "github.com/umbracle/go-evm"
+)
+
+// errNoDatabase is returned when a trie without database needs a node
+var errNoDatabase = errors.New("trie without database")
+
+// Trie is a Merkle Patricia Trie. The nodes are loaded from the database
+// when they are needed and the updates are kept in memory until Commit.
+// A Trie is not safe for concurrent use.
+type Trie struct {
+	db   *Database
+	root node
+}
+
+// New creates an empty trie. The database can be nil if the trie is only
+// hashed and never committed.
+func New(db *Database) *Trie {
+	return &Trie{db: db}
+}
+
+// Open opens the trie with the root in the database
+func Open(db *Database, root evmc.Hash) (*Trie, error) {
+	t := New(db)
+	if root == (evmc.Hash{}) || root == state.EmptyRootHash {
+		return t, nil
+	}
+
+	n, err := t.resolve(hashNode(root[:]))
+	if err != nil {
+		return nil, err
+	}
+	t.root = n
+	return t, nil
+}
+
+// Copy returns a copy of the trie, the updates of the copy do not change
+// the original one
+func (t *Trie) Copy() *Trie {
+	return &Trie{db: t.db, root: t.root}
+}
+
+func (t *Trie) resolve(n hashNode) (node, error) {
+	if t.db == nil {
+		return nil, errNoDatabase
+	}
+	return t.db.node(n)
+}
+
+// Get returns the value of the key or nil if it is not in the trie
+func (t *Trie) Get(key []byte) ([]byte, error) {
+	n := t.root
+	path := keybytesToHex(key)
+
+	for {
+		switch nn := n.(type) {
+		case nil:
+			return nil, nil
+
+		case valueNode:
+			return nn, nil
+
+		case *shortNode:
+			if len(path) < len(nn.Key) || !bytes.Equal(nn.Key, path[:len(nn.Key)]) {
+				return nil, nil
+			}
+			n, path = nn.Val, path[len(nn.Key):]
+
+		case *fullNode:
+			n, path = nn.Children[path[0]], path[1:]
+
+		case hashNode:
+			var err error
+			if n, err = t.resolve(nn); err != nil {
+				return nil, err
+			}
+
+		default:
+			panic(fmt.Sprintf("unknown node type %T", nn))
+		}
+	}
+}
+
+// Insert sets the value of the key, an empty value deletes the key
+func (t *Trie) Insert(key, value []byte) error {
+	if len(value) == 0 {
+		return t.Delete(key)
+	}
+
+	val := make(valueNode, len(value))
+	copy(val, value)
+
+	_, n, err := t.insert(t.root, keybytesToHex(key), val)
+	if err != nil {
+		return err
+	}
+	t.root = n
+	return nil
+}
+
+// insert returns the node with the value at the path and whether the node
+// changed. The nodes along the path are copied.
+func (t *Trie) insert(n node, path []byte, value node) (bool, node, error) {
+	if len(path) == 0 {
+		if v, ok := n.(valueNode); ok {
+			return !bytes.Equal(v, value.(valueNode)), value, nil
+		}
+		return true, value, nil
+	}
+
+	switch n := n.(type) {
+	case nil:
+		return true, &shortNode{Key: path, Val: value, flags: newFlag()}, nil
+
+	case *shortNode:
+		plen := prefixLen(path, n.Key)
+		if plen == len(n.Key) {
+			// the key goes through the node, insert in the child
+			dirty, child, err := t.insert(n.Val, path[plen:], value)
+			if !dirty || err != nil {
+				return false, n, err
+			}
+			return true, &shortNode{Key: n.Key, Val: child, flags: newFlag()}, nil
+		}
+
+		// introduce a branch where the paths diverge
+		branch := &fullNode{flags: newFlag()}
+		_, branch.Children[n.Key[plen]], _ = t.insert(nil, n.Key[plen+1:], n.Val)
+		_, branch.Children[path[plen]], _ = t.insert(nil, path[plen+1:], value)
+
+		if plen == 0 {
+			return true, branch, nil
+		}
+		return true, &shortNode{Key: path[:plen], Val: branch, flags: newFlag()}, nil
+
+	case *fullNode:
+		dirty, child, err := t.insert(n.Children[path[0]], path[1:], value)
+		if !dirty || err != nil {
+			return false, n, err
+		}
+		n = n.copy()
+		n.flags = newFlag()
+		n.Children[path[0]] = child
+		return true, n, nil
+
+	case hashNode:
+		rn, err := t.resolve(n)
+		if err != nil {
+			return false, n, err
+		}
+		dirty, nn, err := t.insert(rn, path, value)
+		if !dirty || err != nil {
+			return false, rn, err
+		}
+		return true, nn, nil
+
+	default:
+		panic(fmt.Sprintf("unknown node type %T", n))
+	}
+}
+
+// Delete removes the key from the trie
+func (t *Trie) Delete(key []byte) error {
+	_, n, err := t.delete(t.root, keybytesToHex(key))
+	if err != nil {
+		return err
+	}
+	t.root = n
+	return nil
+}
+
+// delete returns the node without the path and whether the node changed.
+// The branches with a single child left are collapsed into short nodes.
+func (t *Trie) delete(n node, path []byte) (bool, node, error) {
+	switch n := n.(type) {
+	case nil:
+		return false, nil, nil
+
+	case valueNode:
+		return true, nil, nil
+
+	case *shortNode:
+		plen := prefixLen(path, n.Key)
+		if plen < len(n.Key) {
+			// the key is not in the trie
+			return false, n, nil
+		}
+		if plen == len(path) {
+			// the leaf of the key
+			return true, nil, nil
+		}
+
+		dirty, child, err := t.delete(n.Val, path[len(n.Key):])
+		if !dirty || err != nil {
+			return false, n, err
+		}
+		if short, ok := child.(*shortNode); ok {
+			// merge the short nodes
+			return true, &shortNode{Key: concat(n.Key, short.Key), Val: short.Val, flags: newFlag()}, nil
+		}
+		return true, &shortNode{Key: n.Key, Val: child, flags: newFlag()}, nil
+
+	case *fullNode:
+		dirty, child, err := t.delete(n.Children[path[0]], path[1:])
+		if !dirty || err != nil {
+			return false, n, err
+		}
+		n = n.copy()
+		n.flags = newFlag()
+		n.Children[path[0]] = child
+
+		if child != nil {
+			return true, n, nil
+		}
+
+		// find whether there is only one child left
+		pos := -1
+		for i, c := range n.Children {
+			if c != nil {
+				if pos != -1 {
+					return true, n, nil
+				}
+				pos = i
+			}
+		}
+		if pos == -1 {
+			return true, nil, nil
+		}
+		if pos != 16 {
+			// merge with the child if it is a short node
+			c, err := t.resolveChild(n.Children[pos])
+			if err != nil {
+				return false, n, err
+			}
+			if short, ok := c.(*shortNode); ok {
+				return true, &shortNode{Key: concat([]byte{byte(pos)}, short.Key), Val: short.Val, flags: newFlag()}, nil
+			}
+		}
+		return true, &shortNode{Key: []byte{byte(pos)}, Val: n.Children[pos], flags: newFlag()}, nil
+
+	case hashNode:
+		rn, err := t.resolve(n)
+		if err != nil {
+			return false, n, err
+		}
+		dirty, nn, err := t.delete(rn, path)
+		if !dirty || err != nil {
+			return false, rn, err
+		}
+		return true, nn, nil
+
+	default:
+		panic(fmt.Sprintf("unknown node type %T", n))
+	}
+}
+
+func (t *Trie) resolveChild(n node) (node, error) {
+	if hash, ok := n.(hashNode); ok {
+		return t.resolve(hash)
+	}
+	return n, nil
+}
+
+// Hash returns the root of the trie. Only the nodes updated since the last
+// hash are hashed again.
+func (t *Trie) Hash() evmc.Hash {
+	if t.root == nil {
+		return state.EmptyRootHash
+	}
+
+	h := &hasher{}
+	hash, _ := h.root(t.root)
+	return toHash(hash)
+}
+
+// Commit writes the nodes updated since the last commit to the database and
+// returns the root. The committed nodes are released from memory and they
+// are loaded again from the database when they are needed.
+func (t *Trie) Commit() (evmc.Hash, error) {
+	if t.root == nil {
+		return state.EmptyRootHash, nil
+	}
+	if t.db == nil {
+		return evmc.Hash{}, errNoDatabase
+	}
+
+	h := &hasher{}
+	hash, buf := h.root(t.root)
+	if buf == nil {
+		// nothing changed since the root was loaded
+		return toHash(hash), nil
+	}
+
+	root, err := t.commit(h, t.root)
+	if err != nil {
+		return evmc.Hash{}, err
+	}
+	if _, ok := root.(hashNode); !ok {
+		// the root is stored by its hash even if it is shorter than 32 bytes
+		if err := t.db.put(hash, buf, root); err != nil {
+			return evmc.Hash{}, err
+		}
+	}
+	t.root = hashNode(hash)
+	return toHash(hash), nil
+}
+
+// commit writes the dirty node and its dirty children to the database and
+// returns the reference of the node, which is its hash or the node itself
+// if it is embedded in its parent
+func (t *Trie) commit(h *hasher, n node) (node, error) {
+	var err error
+
+	switch n := n.(type) {
+	case *shortNode:
+		if !n.flags.dirty {
+			return cleanRef(n), nil
+		}
+		c := n.copy()
+		if c.Val, err = t.commit(h, n.Val); err != nil {
+			return nil, err
+		}
+		return t.store(h, c, &c.flags)
+
+	case *fullNode:
+		if !n.flags.dirty {
+			return cleanRef(n), nil
+		}
+		c := n.copy()
+		for i, child := range n.Children {
+			if c.Children[i], err = t.commit(h, child); err != nil {
+				return nil, err
+			}
+		}
+		return t.store(h, c, &c.flags)
+
+	default:
+		return n, nil
+	}
+}
+
+func (t *Trie) store(h *hasher, n node, flags *nodeFlag) (node, error) {
+	flags.dirty = false
+	if flags.hash == nil {
+		// embedded in the parent
+		return n, nil
+	}
+	if err := t.db.put(flags.hash, h.encode(n).MarshalTo(nil), n); err != nil {
+		return nil, err
+	}
+	return flags.hash, nil
+}
+
+// cleanRef returns the reference of a node that is in the database
+func cleanRef(n node) node {
+	if hash := nodeFlags(n).hash; hash != nil {
+		return hash
+	}
+	return n
+}
+
+func newFlag() nodeFlag {
+	return nodeFlag{dirty: true}
+}
@@ -0,0 +1,262 @@
+package trie
+
+import (
+	"encoding/hex"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/evmc/v10/bindings/go/evmc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/umbracle/ethgo"
+	state "github.com/umbracle/go-evm"
+)
+
+func TestEncoding_Compact(t *testing.T) {
+	cases := []struct {
+		hex, compact []byte
+	}{
+		{[]byte{}, []byte{0x00}},
+		{[]byte{16}, []byte{0x20}},
+		{[]byte{1, 2, 3, 4, 5}, []byte{0x11, 0x23, 0x45}},
+		{[]byte{0, 1, 2, 3, 4, 5}, []byte{0x00, 0x01, 0x23, 0x45}},
+		{[]byte{15, 1, 12, 11, 8, 16}, []byte{0x3f, 0x1c, 0xb8}},
+		{[]byte{0, 15, 1, 12, 11, 8, 16}, []byte{0x20, 0x0f, 0x1c, 0xb8}},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.compact, hexToCompact(c.hex))
+		assert.Equal(t, c.hex, compactToHex(c.compact))
+	}
+}
+
+func TestTrie_Empty(t *testing.T) {
+	tt := New(nil)
+	assert.Equal(t, state.EmptyRootHash, tt.Hash())
+
+	root, err := tt.Commit()
+	assert.NoError(t, err)
+	assert.Equal(t, state.EmptyRootHash, root)
+}
+
+func TestTrie_Hash(t *testing.T) {
+	tt := New(nil)
+	require.NoError(t, tt.Insert([]byte("doe"), []byte("reindeer")))
+	require.NoError(t, tt.Insert([]byte("dog"), []byte("puppy")))
+	require.NoError(t, tt.Insert([]byte("dogglesworth"), []byte("cat")))
+
+	root := tt.Hash()
+	assert.Equal(t, "8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3", hex.EncodeToString(root[:]))
+
+	val, err := tt.Get([]byte("dog"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("puppy"), val)
+
+	val, err = tt.Get([]byte("do"))
+	assert.NoError(t, err)
+	assert.Nil(t, val)
+
+	// the deleted keys do not change the root
+	require.NoError(t, tt.Insert([]byte("dogs"), []byte("wolves")))
+	require.NoError(t, tt.Delete([]byte("dogs")))
+	require.NoError(t, tt.Delete([]byte("unknown")))
+	assert.Equal(t, root, tt.Hash())
+}
+
+func hashBytes(h evmc.Hash) []byte {
+	return h[:]
+}
+
+type testEntry struct {
+	key, val []byte
+}
+
+func randomEntries(r *rand.Rand, n int) []testEntry {
+	entries := make([]testEntry, n)
+	for i := range entries {
+		// short keys make the paths share prefixes and embed small nodes
+		key := make([]byte, 1+r.Intn(4))
+		r.Read(key)
+		val := make([]byte, 1+r.Intn(40))
+		r.Read(val)
+		entries[i] = testEntry{key, val}
+	}
+	return entries
+}
+
+// buildTrie returns the root of a trie with only the entries
+func buildTrie(t *testing.T, entries map[string][]byte) evmc.Hash {
+	tt := New(nil)
+	for k, v := range entries {
+		require.NoError(t, tt.Insert([]byte(k), v))
+	}
+	return tt.Hash()
+}
+
+func TestTrie_InsertDelete(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+
+	tt := New(nil)
+	entries := map[string][]byte{}
+
+	for _, e := range randomEntries(r, 500) {
+		require.NoError(t, tt.Insert(e.key, e.val))
+		entries[string(e.key)] = e.val
+	}
+	assert.Equal(t, buildTrie(t, entries), tt.Hash())
+
+	// delete half of the keys in between hashes
+	i := 0
+	for k := range entries {
+		if i%2 == 0 {
+			require.NoError(t, tt.Delete([]byte(k)))
+			delete(entries, k)
+		}
+		if i%50 == 0 {
+			assert.Equal(t, buildTrie(t, entries), tt.Hash())
+		}
+		i++
+	}
+	assert.Equal(t, buildTrie(t, entries), tt.Hash())
+
+	for k, v := range entries {
+		val, err := tt.Get([]byte(k))
+		require.NoError(t, err)
+		assert.Equal(t, v, val)
+	}
+
+	for k := range entries {
+		require.NoError(t, tt.Delete([]byte(k)))
+	}
+	assert.Equal(t, state.EmptyRootHash, tt.Hash())
+}
+
+func TestTrie_Commit(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+
+	storage := NewMemoryStorage()
+	db := NewDatabase(storage)
+
+	tt := New(db)
+	entries := map[string][]byte{}
+	for _, e := range randomEntries(r, 200) {
+		require.NoError(t, tt.Insert(e.key, e.val))
+		entries[string(e.key)] = e.val
+	}
+
+	root1, err := tt.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, buildTrie(t, entries), root1)
+
+	// nothing is written if the trie did not change
+	size := storage.Len()
+	_, err = tt.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, size, storage.Len())
+
+	// an update only writes the nodes of its path
+	require.NoError(t, tt.Insert([]byte{0x1, 0x2, 0x3, 0x4, 0x5}, []byte{0x1}))
+	root2, err := tt.Commit()
+	require.NoError(t, err)
+	assert.NotEqual(t, root1, root2)
+	assert.LessOrEqual(t, storage.Len()-size, 6)
+
+	// the nodes are loaded from the storage without the cache
+	db2, err := NewDatabaseWithCache(storage, 16)
+	require.NoError(t, err)
+
+	tt2, err := Open(db2, root1)
+	require.NoError(t, err)
+	for k, v := range entries {
+		val, err := tt2.Get([]byte(k))
+		require.NoError(t, err)
+		assert.Equal(t, v, val)
+	}
+
+	// the previous root is still valid after the update
+	require.NoError(t, tt2.Insert([]byte{0x1, 0x2, 0x3, 0x4, 0x5}, []byte{0x1}))
+	root3, err := tt2.Commit()
+	require.NoError(t, err)
+	assert.Equal(t, root2, root3)
+
+	_, err = Open(NewDatabase(NewMemoryStorage()), root1)
+	assert.IsType(t, &MissingNodeError{}, err)
+}
+
+func TestTrie_Copy(t *testing.T) {
+	tt := New(nil)
+	require.NoError(t, tt.Insert([]byte("a"), []byte("1")))
+	root := tt.Hash()
+
+	tt2 := tt.Copy()
+	require.NoError(t, tt2.Insert([]byte("b"), []byte("2")))
+
+	assert.Equal(t, root, tt.Hash())
+	assert.NotEqual(t, root, tt2.Hash())
+}
+
+func TestState_StateRoot(t *testing.T) {
+	addr1, addr2 := evmc.Address{0x1}, evmc.Address{0x2}
+	code := []byte{0x60, 0x00}
+
+	obj1 := &state.Object{
+		Address:   addr1,
+		Nonce:     1,
+		Balance:   big.NewInt(100),
+		CodeHash:  evmc.Hash(ethgo.BytesToHash(ethgo.Keccak256(code))),
+		DirtyCode: true,
+		Code:      code,
+		Storage: []*state.StorageObject{
+			{Key: hashBytes(evmc.Hash{0x1}), Val: hashBytes(evmc.Hash{31: 0x1})},
+			{Key: hashBytes(evmc.Hash{0x2}), Val: hashBytes(evmc.Hash{31: 0x2})},
+		},
+	}
+	obj2 := &state.Object{
+		Address:  addr2,
+		Balance:  big.NewInt(5),
+		CodeHash: state.EmptyCodeHash,
+	}
+
+	db := NewDatabase(NewMemoryStorage())
+	s, err := NewState(db, state.EmptyRootHash)
+	require.NoError(t, err)
+
+	root1, err := s.StateRoot([]*state.Object{obj1, obj2})
+	require.NoError(t, err)
+	assert.Equal(t, StateRoot([]*state.Object{obj1, obj2}), root1)
+
+	acct, err := s.GetAccount(addr1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), acct.Nonce)
+	assert.Equal(t, code, acct.Code)
+	assert.Equal(t, evmc.Hash{31: 0x2}, s.GetStorage(addr1, acct.Root, evmc.Hash{0x2}))
+
+	acct, err = s.GetAccount(evmc.Address{0x3})
+	require.NoError(t, err)
+	assert.Nil(t, acct)
+
+	// run a transition on top of the state
+	transition := state.NewTransition(state.WithState(s))
+	transition.Txn().SetState(addr1, evmc.Hash{0x1}, evmc.Hash{})
+	transition.Txn().SetState(addr1, evmc.Hash{0x3}, evmc.Hash{31: 0x3})
+	transition.Txn().AddBalance(addr2, big.NewInt(1))
+
+	root2, err := s.StateRoot(transition.Commit())
+	require.NoError(t, err)
+
+	// the same state built from scratch
+	obj1.Storage = []*state.StorageObject{
+		{Key: hashBytes(evmc.Hash{0x2}), Val: hashBytes(evmc.Hash{31: 0x2})},
+		{Key: hashBytes(evmc.Hash{0x3}), Val: hashBytes(evmc.Hash{31: 0x3})},
+	}
+	obj2.Balance = big.NewInt(6)
+	assert.Equal(t, StateRoot([]*state.Object{obj1, obj2}), root2)
+	assert.Equal(t, root2, s.Root())
+
+	// the previous root can still be opened
+	s1, err := NewState(db, root1)
+	require.NoError(t, err)
+	acct, err = s1.GetAccount(addr2)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), acct.Balance)
+}
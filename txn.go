@@ -7,6 +7,7 @@ import (
 	"github.com/ethereum/evmc/v10/bindings/go/evmc"
 	iradix "github.com/hashicorp/go-immutable-radix"
 	"github.com/umbracle/ethgo"
+	"github.com/umbracle/go-evm/evm"
 )
 
 var (
@@ -15,6 +16,15 @@ var (
 
 	// refundIndex is the index of the refund
 	refundIndex = bytesToHash([]byte{3})
+
+	// accessListIndex is the prefix of the access list entries in the trie
+	accessListIndex = bytesToHash([]byte{4})
+
+	// transientStorageIndex is the prefix of the transient storage entries in the trie
+	transientStorageIndex = bytesToHash([]byte{5})
+
+	// createdIndex is the prefix of the accounts created in the transaction
+	createdIndex = bytesToHash([]byte{6})
 )
 
 // Txn is a reference of the state
@@ -72,6 +82,8 @@ func (txn *Txn) getStateObject(addr evmc.Address) (*stateObject, bool) {
 	// Try to get state from radix tree which holds transient states during block processing first
 	val, exists := txn.txn.Get(addr[:])
 	if exists {
+		txn.trackAccount(addr)
+
 		obj := val.(*stateObject)
 		if obj.Deleted {
 			return nil, false
@@ -208,14 +220,22 @@ func (txn *Txn) SetStorage(addr evmc.Address, key evmc.Hash, value evmc.Hash) (s
 	txn.SetState(addr, key, value)
 
 	isIstanbul := txn.isRevision(evmc.Istanbul)
+	isBerlin := txn.isRevision(evmc.Berlin)
 	legacyGasMetering := !isIstanbul && (txn.isRevision(evmc.Petersburg) || !txn.isRevision(evmc.Constantinople))
 
+	// refund for clearing a storage slot
+	clearRefund := uint64(15000)
+	if txn.isRevision(evmc.London) {
+		// eip-3529
+		clearRefund = 4800
+	}
+
 	if legacyGasMetering {
 		status = evmc.StorageModified
 		if oldValue == zeroHash {
 			return evmc.StorageAdded
 		} else if value == zeroHash {
-			txn.AddRefund(15000)
+			txn.AddRefund(clearRefund)
 			return evmc.StorageDeleted
 		}
 		return evmc.StorageModified
@@ -226,28 +246,34 @@ func (txn *Txn) SetStorage(addr evmc.Address, key evmc.Hash, value evmc.Hash) (s
 			return evmc.StorageAdded
 		}
 		if value == zeroHash { // delete slot (2.1.2b)
-			txn.AddRefund(15000)
+			txn.AddRefund(clearRefund)
 			return evmc.StorageDeleted
 		}
 		return evmc.StorageModified
 	}
 	if original != zeroHash { // Storage slot was populated before this transaction started
 		if current == zeroHash { // recreate slot (2.2.1.1)
-			txn.SubRefund(15000)
+			txn.SubRefund(clearRefund)
 		} else if value == zeroHash { // delete slot (2.2.1.2)
-			txn.AddRefund(15000)
+			txn.AddRefund(clearRefund)
 		}
 	}
 	if original == value {
 		if original == zeroHash { // reset to original nonexistent slot (2.2.2.1)
 			// Storage was used as memory (allocation and deallocation occurred within the same contract)
-			if isIstanbul {
+			if isBerlin {
+				// eip-2929
+				txn.AddRefund(19900)
+			} else if isIstanbul {
 				txn.AddRefund(19200)
 			} else {
 				txn.AddRefund(19800)
 			}
 		} else { // reset to original existing slot (2.2.2.2)
-			if isIstanbul {
+			if isBerlin {
+				// eip-2929
+				txn.AddRefund(2800)
+			} else if isIstanbul {
 				txn.AddRefund(4200)
 			} else {
 				txn.AddRefund(4800)
@@ -284,6 +310,7 @@ func (txn *Txn) GetState(addr evmc.Address, key evmc.Hash) evmc.Hash {
 	// if account state update happened in previous transactions of same block
 	if object.Txn != nil {
 		if val, ok := object.Txn.Get(key[:]); ok {
+			txn.trackSlot(addr, key)
 			if val == nil {
 				return evmc.Hash{}
 			}
@@ -356,8 +383,13 @@ func (txn *Txn) GetCodeHash(addr evmc.Address) (res evmc.Hash) {
 	return
 }
 
-// Suicide marks the given account as suicided
+// Suicide marks the given account as suicided. After Cancun only the accounts
+// created in the same transaction can be suicided (eip-6780).
 func (txn *Txn) Suicide(addr evmc.Address) bool {
+	if txn.isRevision(evm.Cancun) && !txn.Created(addr) {
+		return false
+	}
+
 	var suicided bool
 	txn.upsertAccount(addr, false, func(object *stateObject) {
 		if object == nil || object.Suicide {
@@ -390,6 +422,66 @@ func (txn *Txn) SubRefund(gas uint64) {
 	txn.txn.Insert(refundIndex[:], refund)
 }
 
+// Access list
+
+func accessListKey(addr evmc.Address) []byte {
+	return append(accessListIndex[:], addr[:]...)
+}
+
+func accessListSlotKey(addr evmc.Address, slot evmc.Hash) []byte {
+	return append(accessListKey(addr), slot[:]...)
+}
+
+// AddAddressToAccessList marks the address as warm (eip-2929)
+func (txn *Txn) AddAddressToAccessList(addr evmc.Address) {
+	txn.txn.Insert(accessListKey(addr), true)
+}
+
+// AddSlotToAccessList marks the address and the storage slot as warm (eip-2929)
+func (txn *Txn) AddSlotToAccessList(addr evmc.Address, slot evmc.Hash) {
+	txn.AddAddressToAccessList(addr)
+	txn.txn.Insert(accessListSlotKey(addr, slot), true)
+}
+
+// AddressInAccessList returns true if the address is warm
+func (txn *Txn) AddressInAccessList(addr evmc.Address) bool {
+	_, ok := txn.txn.Get(accessListKey(addr))
+	return ok
+}
+
+// SlotInAccessList returns whether the address and the storage slot are warm
+func (txn *Txn) SlotInAccessList(addr evmc.Address, slot evmc.Hash) (addressOk bool, slotOk bool) {
+	_, addressOk = txn.txn.Get(accessListKey(addr))
+	_, slotOk = txn.txn.Get(accessListSlotKey(addr, slot))
+	return
+}
+
+// Transient storage
+
+func transientStorageKey(addr evmc.Address, key evmc.Hash) []byte {
+	k := append(transientStorageIndex[:], addr[:]...)
+	return append(k, key[:]...)
+}
+
+// GetTransientState returns the transient storage of the address at a given key (eip-1153)
+func (txn *Txn) GetTransientState(addr evmc.Address, key evmc.Hash) evmc.Hash {
+	val, ok := txn.txn.Get(transientStorageKey(addr, key))
+	if !ok {
+		return evmc.Hash{}
+	}
+	return val.(evmc.Hash)
+}
+
+// SetTransientState changes the transient storage of an address (eip-1153)
+func (txn *Txn) SetTransientState(addr evmc.Address, key, value evmc.Hash) {
+	if value == zeroHash {
+		txn.txn.Delete(transientStorageKey(addr, key))
+	} else {
+		txn.txn.Insert(transientStorageKey(addr, key), value)
+	}
+}
+
+// Logs returns the logs emitted since the last call and clears them
 func (txn *Txn) Logs() []*Log {
 	data, exists := txn.txn.Get(logIndex[:])
 	if !exists {
@@ -399,6 +491,11 @@ func (txn *Txn) Logs() []*Log {
 	return data.([]*Log)
 }
 
+// DiscardLogs clears the logs emitted since the last call to Logs
+func (txn *Txn) DiscardLogs() {
+	txn.txn.Delete(logIndex[:])
+}
+
 func (txn *Txn) GetRefund() uint64 {
 	data, exists := txn.txn.Get(refundIndex[:])
 	if !exists {
@@ -463,6 +560,15 @@ func (txn *Txn) CreateAccount(addr evmc.Address) {
 	}
 
 	txn.txn.Insert(addr[:], obj)
+
+	// track the creation for the eip-6780 selfdestruct rules
+	txn.txn.Insert(append(createdIndex[:], addr[:]...), true)
+}
+
+// Created returns true if the account was created in the current transaction
+func (txn *Txn) Created(addr evmc.Address) bool {
+	_, ok := txn.txn.Get(append(createdIndex[:], addr[:]...))
+	return ok
 }
 
 func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
@@ -495,6 +601,12 @@ func (txn *Txn) CleanDeleteObjects(deleteEmptyObjects bool) {
 
 	// delete refunds
 	txn.txn.Delete(refundIndex[:])
+
+	// the access list, the transient storage and the created accounts only
+	// live for the duration of the transaction
+	txn.txn.DeletePrefix(accessListIndex[:])
+	txn.txn.DeletePrefix(transientStorageIndex[:])
+	txn.txn.DeletePrefix(createdIndex[:])
 }
 
 func (txn *Txn) Commit() []*Object {